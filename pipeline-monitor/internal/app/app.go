@@ -2,27 +2,48 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"pipeline-monitor/internal/config"
+	"pipeline-monitor/internal/domain/audit"
+	"pipeline-monitor/internal/domain/heartbeat"
+	"pipeline-monitor/internal/domain/pipeline"
+	"pipeline-monitor/internal/domain/secret"
 	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/snapshot"
+	"pipeline-monitor/internal/domain/team"
 	"pipeline-monitor/internal/handlers"
 	"pipeline-monitor/internal/infrastructure/database"
 	"pipeline-monitor/internal/infrastructure/monitor"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"gopkg.in/yaml.v3"
 )
 
 // Application holds all the application dependencies
 type Application struct {
 	config      *config.Config
 	serviceRepo service.Repository
+	auditRepo   audit.Repository
+	teamRepo    team.Repository
+	checkRepo   service.CheckRepository
+	pingRepo    heartbeat.Repository
 	monitor     *monitor.ServiceMonitor
 	handlers    *handlers.Handlers
 	router      *gin.Engine
+
+	// Background jobs owned by the application (e.g. retention)
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // New creates a new application instance with all dependencies wired up
@@ -45,19 +66,68 @@ func New(cfg *config.Config) *Application {
 
 	// Repository layer
 	serviceRepo := database.NewServiceRepository(db)
+	auditRepo := database.NewAuditRepository(db)
+	revisionRepo := database.NewRevisionRepository(db)
+	teamRepo := database.NewTeamRepository(db)
+	checkRepo := database.NewCheckRepository(db, checkRetention(cfg))
+	incidentRepo := database.NewIncidentRepository(db)
+	statusPageRepo := database.NewStatusPageRepository(db)
+	certRepo := database.NewCertificateRepository(db)
+	snapshotRepo := database.NewSnapshotRepository(db)
+	pingRepo := database.NewHeartbeatRepository(db)
+	pipelineRepo := database.NewPipelineRepository(db)
+	eventRepo := database.NewEventRepository(db)
+	secretRepo := secret.WithEnvironment(database.NewSecretRepository(db, newKeyring(cfg)), cfg.Secrets)
+
+	// Re-encrypt secrets still sealed with a previous key
+	rotated, err := secretRepo.Rotate(context.Background())
+	if err != nil {
+		log.Fatal("Failed to rotate secrets:", err)
+	}
+	if rotated > 0 {
+		log.Printf("Re-encrypted %d secrets with the current key", rotated)
+	}
 
 	// Service monitor (this is where Go concurrency shines)
-	serviceMonitor := monitor.New(serviceRepo, cfg.CheckInterval)
+	serviceMonitor := monitor.New(serviceRepo, checkRepo, incidentRepo, certRepo, snapshotRepo, pingRepo, cfg.CheckInterval, cfg.CertWarningDays, snapshot.Policy{
+		Keep:          cfg.SnapshotLimit,
+		BodyBytes:     cfg.SnapshotBodyBytes,
+		RedactHeaders: cfg.SnapshotRedactHeaders,
+	}, cfg.DataSources, cfg.ExecCommands, cfg.ClientCerts, cfg.CABundles, secretRepo)
 
 	// Handlers
-	handlers := handlers.New(serviceRepo, serviceMonitor)
+	handlers := handlers.New(handlers.Dependencies{
+		Config:         cfg,
+		ServiceRepo:    serviceRepo,
+		AuditRepo:      auditRepo,
+		RevisionRepo:   revisionRepo,
+		TeamRepo:       teamRepo,
+		CheckRepo:      checkRepo,
+		IncidentRepo:   incidentRepo,
+		StatusPageRepo: statusPageRepo,
+		CertRepo:       certRepo,
+		SnapshotRepo:   snapshotRepo,
+		PingRepo:       pingRepo,
+		PipelineRepo:   pipelineRepo,
+		EventRepo:      eventRepo,
+		SecretRepo:     secretRepo,
+		Monitor:        serviceMonitor,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create application instance
 	app := &Application{
 		config:      cfg,
 		serviceRepo: serviceRepo,
+		auditRepo:   auditRepo,
+		teamRepo:    teamRepo,
+		checkRepo:   checkRepo,
+		pingRepo:    pingRepo,
 		monitor:     serviceMonitor,
 		handlers:    handlers,
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 
 	// Setup router
@@ -68,6 +138,16 @@ func New(cfg *config.Config) *Application {
 		log.Fatal("Failed to start service monitor:", err)
 	}
 
+	// Start audit log retention
+	if cfg.AuditRetentionDays > 0 {
+		app.wg.Add(1)
+		go app.auditRetentionLoop()
+	}
+
+	// Start check history rollups and retention
+	app.wg.Add(1)
+	go app.rollupLoop()
+
 	return app
 }
 
@@ -85,9 +165,141 @@ func (a *Application) Shutdown(ctx context.Context) error {
 		log.Printf("Error stopping monitor: %v", err)
 	}
 
+	// Stop background jobs
+	a.cancel()
+	a.wg.Wait()
+
 	return nil
 }
 
+// auditRetentionLoop periodically prunes audit entries older than the
+// configured retention period
+func (a *Application) auditRetentionLoop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().AddDate(0, 0, -a.config.AuditRetentionDays)
+		deleted, err := a.auditRepo.DeleteBefore(a.ctx, cutoff)
+		if err != nil {
+			log.Printf("Error pruning audit log: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Pruned %d audit entries older than %s", deleted, cutoff.Format(time.RFC3339))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// newKeyring returns the keyring sealing stored secrets, or nil when no
+// key is configured
+func newKeyring(cfg *config.Config) *secret.Keyring {
+	if cfg.SecretsKey == "" {
+		return nil
+	}
+	keyring, err := secret.NewKeyring(cfg.SecretsKey, cfg.SecretsPreviousKeys)
+	if err != nil {
+		log.Fatal("Failed to load secrets key:", err)
+	}
+	return keyring
+}
+
+// checkRetention converts the configured retention days per resolution
+func checkRetention(cfg *config.Config) service.Retention {
+	days := func(n int) time.Duration {
+		return time.Duration(n) * 24 * time.Hour
+	}
+	return service.Retention{
+		Raw:    days(cfg.CheckRetentionDays),
+		Minute: days(cfg.MinuteRollupRetentionDays),
+		Hour:   days(cfg.HourRollupRetentionDays),
+		Day:    days(cfg.DayRollupRetentionDays),
+	}
+}
+
+// rollupLoop aggregates check history into coarser buckets every minute,
+// then prunes each resolution according to its retention. History is never
+// pruned past what the next resolution has already aggregated, so a backlog
+// being rolled up is kept until it has been.
+func (a *Application) rollupLoop() {
+	defer a.wg.Done()
+
+	retention := checkRetention(a.config)
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		a.rollUpChecks()
+
+		for i, res := range service.Resolutions {
+			keep := retention.For(res)
+			if keep == 0 {
+				continue
+			}
+
+			cutoff := time.Now().Add(-keep)
+			if i+1 < len(service.Resolutions) {
+				latest, err := a.checkRepo.LatestRollup(a.ctx, service.Resolutions[i+1])
+				if err != nil {
+					log.Printf("Error pruning %s check history: %v", res, err)
+					continue
+				}
+				if latest.Before(cutoff) {
+					cutoff = latest
+				}
+			}
+
+			deleted, err := a.checkRepo.DeleteBefore(a.ctx, res, cutoff)
+			if err != nil {
+				log.Printf("Error pruning %s check history: %v", res, err)
+			} else if deleted > 0 {
+				log.Printf("Pruned %d %s check history rows older than %s", deleted, res, cutoff.Format(time.RFC3339))
+			}
+		}
+
+		// Heartbeat pings are kept as long as raw checks
+		if retention.Raw > 0 {
+			cutoff := time.Now().Add(-retention.Raw)
+			deleted, err := a.pingRepo.DeleteBefore(a.ctx, cutoff)
+			if err != nil {
+				log.Printf("Error pruning heartbeat pings: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Pruned %d heartbeat pings older than %s", deleted, cutoff.Format(time.RFC3339))
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+// rollUpChecks brings each rollup resolution up to date with its source,
+// finest first
+func (a *Application) rollUpChecks() {
+	for _, res := range service.Resolutions[1:] {
+		latest, err := a.checkRepo.LatestRollup(a.ctx, res)
+		if err != nil {
+			log.Printf("Error rolling up %s check history: %v", res, err)
+			return
+		}
+
+		if _, err := a.checkRepo.RollUp(a.ctx, res, latest); err != nil {
+			log.Printf("Error rolling up %s check history: %v", res, err)
+			return
+		}
+	}
+}
+
 // setupRouter configures all routes and middleware
 func (a *Application) setupRouter() {
 	router := gin.New()
@@ -96,9 +308,10 @@ func (a *Application) setupRouter() {
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(a.corsMiddleware())
+	router.Use(a.identityMiddleware())
 
 	// Load HTML templates
-	router.SetHTMLTemplate(a.loadTemplates())
+	router.HTMLRender = a.loadTemplates()
 
 	// Static files
 	router.Static("/static", "./templates/static")
@@ -123,74 +336,296 @@ func (a *Application) setupRoutes(router *gin.Engine) {
 	router.GET("/services/:id/edit", a.handlers.EditServiceForm)
 	router.PUT("/services/:id", a.handlers.UpdateService)
 	router.DELETE("/services/:id", a.handlers.DeleteService)
+	router.GET("/services/:id/revisions/compare", a.handlers.CompareRevisionsPartial)
+	router.POST("/services/:id/badge-token", a.handlers.GenerateBadgeToken)
+	router.DELETE("/services/:id/badge-token", a.handlers.RemoveBadgeToken)
+	router.POST("/services/:id/revisions/:version/revert", a.handlers.RevertService)
+
+	// Audit log
+	router.GET("/audit", a.handlers.AuditLog)
+	router.GET("/labels", a.handlers.ListLabels)
+	router.GET("/pipelines", a.handlers.ListPipelines)
+	router.GET("/pipelines/:id", a.handlers.ShowPipeline)
+	router.POST("/pipelines/:id/service", a.handlers.LinkPipeline)
+	router.GET("/reports", a.handlers.DORAReport)
+
+	// Status page configuration and incidents
+	router.GET("/status-page", a.handlers.StatusPageAdmin)
+	router.POST("/status-page/components", a.handlers.CreateStatusComponent)
+	router.POST("/status-page/components/:id", a.handlers.UpdateStatusComponent)
+	router.DELETE("/status-page/components/:id", a.handlers.DeleteStatusComponent)
+	router.POST("/status-page/components/:id/services", a.handlers.SetStatusEntry)
+	router.DELETE("/status-page/components/:id/services/:serviceID", a.handlers.RemoveStatusEntry)
+	router.POST("/incidents", a.handlers.CreateIncident)
+	router.GET("/incidents/:id", a.handlers.ShowIncident)
+	router.POST("/incidents/:id", a.handlers.UpdateIncident)
+	router.POST("/incidents/:id/resolve", a.handlers.ResolveIncident)
+
+	// Embeddable SVG badges, served without auth
+	router.GET("/badge/:id/status.svg", a.handlers.StatusBadge)
+	router.GET("/badge/:id/uptime.svg", a.handlers.UptimeBadge)
+
+	// Heartbeat pings from cron jobs and batch pipelines, authenticated by
+	// the token in the URL
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		router.Handle(method, "/ping/:token", a.handlers.PingSuccess)
+		router.Handle(method, "/ping/:token/start", a.handlers.PingStart)
+		router.Handle(method, "/ping/:token/fail", a.handlers.PingFail)
+	}
+
+	// CI/CD webhooks, authenticated by their signature
+	router.POST("/webhooks/github", a.handlers.GitHubWebhook)
+	router.POST("/webhooks/gitlab", a.handlers.GitLabWebhook)
+
+	// Atom and RSS feeds, filtered with ?service_id= and ?tag=
+	router.GET("/feeds/incidents.atom", a.handlers.IncidentsAtomFeed)
+	router.GET("/feeds/incidents.rss", a.handlers.IncidentsRSSFeed)
+	router.GET("/feeds/status.atom", a.handlers.StatusAtomFeed)
+	router.GET("/feeds/status.rss", a.handlers.StatusRSSFeed)
+	router.GET("/feeds/certificates.atom", a.handlers.CertificatesAtomFeed)
+	router.GET("/feeds/certificates.rss", a.handlers.CertificatesRSSFeed)
+
+	// Public status page, read-only and served without auth
+	router.GET(a.config.StatusPagePath, a.handlers.PublicStatusPage)
+
+	// Team management routes
+	router.GET("/teams", a.handlers.ListTeams)
+	router.POST("/teams", a.handlers.CreateTeam)
+	router.POST("/teams/switch", a.handlers.SwitchTeam)
+	router.GET("/teams/:id", a.handlers.GetTeam)
+	router.POST("/teams/:id/members", a.handlers.SetTeamMember)
+	router.DELETE("/teams/:id/members/:username", a.handlers.RemoveTeamMember)
+
+	// Secret management routes
+	router.GET("/secrets", a.handlers.ListSecrets)
+	router.POST("/secrets", a.handlers.PutSecret)
+	router.DELETE("/secrets/:name", a.handlers.DeleteSecret)
 
 	// HTMX partial routes for real-time updates
 	router.GET("/partials/service-status/:id", a.handlers.ServiceStatusPartial)
 	router.GET("/partials/services-table", a.handlers.ServicesTablePartial)
 	router.GET("/partials/dashboard-stats", a.handlers.DashboardStatsPartial)
+	router.GET("/partials/service-revisions/:id", a.handlers.ServiceRevisionsPartial)
+	router.GET("/partials/service-chart/:id", a.handlers.ServiceChartPartial)
+	router.GET("/partials/service-timings/:id", a.handlers.ServiceTimingsPartial)
+	router.GET("/partials/service-metrics/:id", a.handlers.ServiceMetricsPartial)
+	router.GET("/partials/service-pings/:id", a.handlers.ServicePingsPartial)
+	router.GET("/partials/service-pipelines/:id", a.handlers.ServicePipelinesPartial)
+	router.GET("/partials/service-snapshots/:id", a.handlers.ServiceSnapshotsPartial)
+	router.GET("/partials/incident-snapshots/:id", a.handlers.IncidentSnapshotsPartial)
+	router.GET("/partials/expiring-certificates", a.handlers.ExpiringCertificatesPartial)
+	router.GET("/partials/team-switcher", a.handlers.TeamSwitcherPartial)
 
 	// API routes for external access
 	api := router.Group("/api/v1")
 	{
 		api.GET("/services", a.handlers.APIListServices)
+		api.POST("/services/bulk", a.handlers.APIBulkServices)
 		api.GET("/services/:id", a.handlers.APIGetService)
 		api.POST("/services", a.handlers.APICreateService)
 		api.PUT("/services/:id", a.handlers.APIUpdateService)
 		api.DELETE("/services/:id", a.handlers.APIDeleteService)
+		api.POST("/services/:id/badge-token", a.handlers.APIGenerateBadgeToken)
+		api.DELETE("/services/:id/badge-token", a.handlers.APIRemoveBadgeToken)
+		api.GET("/services/:id/revisions", a.handlers.APIListRevisions)
+		api.GET("/services/:id/snapshots", a.handlers.APIListSnapshots)
+		api.GET("/services/:id/pings", a.handlers.APIListPings)
+		api.GET("/services/:id/events", a.handlers.APIListServiceEvents)
+		api.POST("/services/:id/events", a.handlers.APICreateServiceEvent)
+		api.GET("/services/:id/revisions/compare", a.handlers.APICompareRevisions)
+		api.POST("/services/:id/revisions/:version/revert", a.handlers.APIRevertService)
+		api.GET("/audit", a.handlers.APIListAudit)
+		api.GET("/labels", a.handlers.APIListLabels)
+		api.GET("/incidents", a.handlers.APIListIncidents)
+		api.GET("/certificates", a.handlers.APIListCertificates)
+		api.GET("/events", a.handlers.APIListTagEvents)
+		api.POST("/events", a.handlers.APICreateTagEvent)
+		api.DELETE("/events/:id", a.handlers.APIDeleteEvent)
+		api.GET("/pipelines", a.handlers.APIListPipelines)
+		api.GET("/pipelines/:id/runs", a.handlers.APIListPipelineRuns)
+		api.PUT("/pipelines/:id", a.handlers.APILinkPipeline)
+		api.GET("/reports/dora", a.handlers.APIDORAReport)
+		api.GET("/teams", a.handlers.APIListTeams)
+		api.POST("/teams", a.handlers.APICreateTeam)
+		api.DELETE("/teams/:id", a.handlers.APIDeleteTeam)
+		api.GET("/teams/:id/members", a.handlers.APIListTeamMembers)
+		api.PUT("/teams/:id/members/:username", a.handlers.APISetTeamMember)
+		api.DELETE("/teams/:id/members/:username", a.handlers.APIRemoveTeamMember)
+		api.GET("/secrets", a.handlers.APIListSecrets)
+		api.PUT("/secrets/:name", a.handlers.APIPutSecret)
+		api.DELETE("/secrets/:name", a.handlers.APIDeleteSecret)
 		api.GET("/health", a.handlers.APIHealthCheck)
 	}
 
 	// Server-Sent Events for real-time updates
 	router.GET("/events/service-updates", a.handlers.ServiceUpdatesSSE)
+
+	// Prometheus metrics
+	router.GET("/metrics", a.handlers.Metrics)
+}
+
+// pageTemplates are full pages rendered inside base.html. Each of them
+// defines "content", so every page gets a template set of its own.
+var pageTemplates = []string{
+	"templates/dashboard.html",
+	"templates/error.html",
+	"templates/services/list.html",
+	"templates/services/form.html",
+	"templates/services/detail.html",
+	"templates/audit/list.html",
+	"templates/labels/list.html",
+	"templates/statuspage/admin.html",
+	"templates/incidents/detail.html",
+	"templates/teams/list.html",
+	"templates/teams/detail.html",
+	"templates/secrets/list.html",
+	"templates/pipelines/list.html",
+	"templates/pipelines/detail.html",
+	"templates/reports/dora.html",
+}
+
+// partialTemplates are HTML fragments returned to HTMX requests
+var partialTemplates = []string{
+	"templates/partials/service-status.html",
+	"templates/partials/services-table.html",
+	"templates/partials/service-row.html",
+	"templates/partials/dashboard-stats.html",
+	"templates/partials/service-revisions.html",
+	"templates/partials/revision-diff.html",
+	"templates/partials/service-chart.html",
+	"templates/partials/service-timings.html",
+	"templates/partials/service-metrics.html",
+	"templates/partials/expiring-certificates.html",
+	"templates/partials/response-snapshots.html",
+	"templates/partials/service-pings.html",
+	"templates/partials/service-pipelines.html",
+	"templates/partials/team-switcher.html",
+}
+
+// standaloneTemplates are complete documents that do not use base.html,
+// such as the public status page
+var standaloneTemplates = []string{
+	"templates/status/public.html",
+}
+
+// templateRenderer looks up templates by their path relative to templates/,
+// e.g. "services/list.html" or "partials/service-row.html"
+type templateRenderer struct {
+	templates map[string]*render.HTML
+}
+
+// Instance implements gin's render.HTMLRender
+func (r templateRenderer) Instance(name string, data any) render.Render {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		log.Printf("Warning: Unknown template %s", name)
+		return render.HTML{Template: template.New(name), Name: name, Data: data}
+	}
+	return render.HTML{Template: tmpl.Template, Name: tmpl.Name, Data: data}
 }
 
 // loadTemplates loads and parses HTML templates
-func (a *Application) loadTemplates() *template.Template {
-	tmpl := template.New("")
+func (a *Application) loadTemplates() render.HTMLRender {
+	renderer := templateRenderer{templates: make(map[string]*render.HTML)}
+
+	for _, file := range pageTemplates {
+		tmpl, err := template.New("").Funcs(templateFuncs()).ParseFiles("templates/base.html", file)
+		if err != nil {
+			log.Printf("Warning: Could not load template %s: %v", file, err)
+			continue
+		}
+		renderer.templates[templateName(file)] = &render.HTML{Template: tmpl, Name: "base.html"}
+	}
+
+	for _, file := range append(partialTemplates, standaloneTemplates...) {
+		tmpl, err := template.New("").Funcs(templateFuncs()).ParseFiles(file)
+		if err != nil {
+			log.Printf("Warning: Could not load template %s: %v", file, err)
+			continue
+		}
+		renderer.templates[templateName(file)] = &render.HTML{Template: tmpl, Name: filepath.Base(file)}
+	}
+
+	return renderer
+}
+
+// templateName strips the templates/ prefix from a template file path
+func templateName(file string) string {
+	name, err := filepath.Rel("templates", file)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(name)
+}
 
+// templateFuncs returns the functions available to all templates
+func templateFuncs() template.FuncMap {
 	// Template functions for HTMX integration
-	tmpl.Funcs(template.FuncMap{
-		"statusClass": func(status string) string {
-			switch status {
-			case "healthy":
-				return "status-healthy"
-			case "unhealthy":
-				return "status-unhealthy"
-			case "timeout":
-				return "status-timeout"
-			default:
-				return "status-unknown"
-			}
-		},
+	return template.FuncMap{
+		"statusClass": handlers.StatusClass,
 		"formatResponseTime": func(responseTime int) string {
 			if responseTime < 1000 {
 				return fmt.Sprintf("%dms", responseTime)
 			}
 			return fmt.Sprintf("%.1fs", float64(responseTime)/1000)
 		},
-	})
-
-	// Load template files
-	templateFiles := []string{
-		"templates/base.html",
-		"templates/dashboard.html",
-		"templates/error.html",
-		"templates/services/list.html",
-		"templates/services/form.html",
-		"templates/services/detail.html",
-		"templates/partials/service-status.html",
-		"templates/partials/services-table.html",
-		"templates/partials/service-row.html",
-		"templates/partials/dashboard-stats.html",
-	}
-
-	for _, file := range templateFiles {
-		if _, err := tmpl.ParseFiles(file); err != nil {
-			log.Printf("Warning: Could not load template %s: %v", file, err)
-		}
+		"formatLabels": service.FormatLabels,
+		"formatDuration": func(d time.Duration) string {
+			return d.Round(time.Second).String()
+		},
+		"outcomeClass": func(outcome pipeline.Outcome) string {
+			switch outcome {
+			case pipeline.OutcomeSuccess:
+				return "bg-green-100 text-green-800 dark:bg-green-800 dark:text-green-100"
+			case pipeline.OutcomeFailure:
+				return "bg-red-100 text-red-800 dark:bg-red-800 dark:text-red-100"
+			case pipeline.OutcomeRunning:
+				return "bg-blue-100 text-blue-800 dark:bg-blue-800 dark:text-blue-100"
+			default:
+				return "bg-gray-100 text-gray-800 dark:bg-gray-700 dark:text-gray-100"
+			}
+		},
+		"outcomeDot": func(outcome pipeline.Outcome) string {
+			switch outcome {
+			case pipeline.OutcomeSuccess:
+				return "bg-green-500"
+			case pipeline.OutcomeFailure:
+				return "bg-red-500"
+			case pipeline.OutcomeRunning:
+				return "bg-blue-400"
+			default:
+				return "bg-gray-300 dark:bg-gray-600"
+			}
+		},
+		"uptimeClass": func(uptime service.Uptime) string {
+			switch percent := uptime.Percent(); {
+			case uptime.Checks == 0:
+				return "bg-gray-300 dark:bg-gray-600"
+			case percent >= 99.9:
+				return "bg-green-500"
+			case percent >= 99:
+				return "bg-green-300"
+			case percent >= 95:
+				return "bg-yellow-400"
+			default:
+				return "bg-red-500"
+			}
+		},
+		"toJSON": func(v any) string {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Sprint(v)
+			}
+			return string(data)
+		},
+		"toYAML": func(v any) string {
+			data, err := yaml.Marshal(v)
+			if err != nil {
+				return fmt.Sprint(v)
+			}
+			return string(data)
+		},
 	}
-
-	return tmpl
 }
 
 // corsMiddleware adds CORS headers
@@ -198,7 +633,7 @@ func (a *Application) corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Team-ID")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -209,3 +644,40 @@ func (a *Application) corsMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// identityMiddleware identifies the caller from the auth proxy header and
+// attaches their team memberships and active team to the request context.
+// The active team comes from the X-Team-ID header or the team switcher cookie.
+func (a *Application) identityMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.GetHeader(a.config.AuthUserHeader)
+		if username == "" || username == team.Anonymous {
+			// Without an identity the caller has no role in any team
+			c.Request = c.Request.WithContext(team.WithCaller(c.Request.Context(), &team.Caller{Username: team.Anonymous}))
+			c.Next()
+			return
+		}
+
+		caller := &team.Caller{
+			Username: username,
+			Admin:    a.config.IsAdmin(username),
+		}
+
+		memberships, err := a.teamRepo.ListMemberships(c.Request.Context(), username)
+		if err != nil {
+			log.Printf("Failed to load team memberships for %s: %v", username, err)
+		}
+		caller.Memberships = memberships
+
+		activeTeam := c.GetHeader("X-Team-ID")
+		if activeTeam == "" {
+			activeTeam, _ = c.Cookie(handlers.ActiveTeamCookie)
+		}
+		if activeTeam != "" && caller.Can(activeTeam, team.RoleViewer) {
+			caller.ActiveTeamID = activeTeam
+		}
+
+		c.Request = c.Request.WithContext(team.WithCaller(c.Request.Context(), caller))
+		c.Next()
+	}
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+
+	"pipeline-monitor/internal/domain/team"
 )
 
 type Config struct {
@@ -11,6 +14,74 @@ type Config struct {
 	Environment   string
 	LogLevel      string
 	CheckInterval int // seconds
+
+	// AuthUserHeader is the request header set by the auth proxy to identify the caller
+	AuthUserHeader     string
+	AuditRetentionDays int // 0 keeps audit entries forever
+
+	// Check history is kept as raw checks and rolled up into 1-minute,
+	// 1-hour and 1-day buckets, each kept for its own number of days
+	// (0 keeps it forever)
+	CheckRetentionDays        int
+	MinuteRollupRetentionDays int
+	HourRollupRetentionDays   int
+	DayRollupRetentionDays    int
+
+	// AdminUsers can see and manage every team. There are none by default,
+	// and anonymous requests are never admins.
+	AdminUsers []string
+
+	// CertWarningDays sets a service to warning when its TLS certificate
+	// expires within that many days
+	CertWarningDays int
+
+	// Failing checks keep a snapshot of the request and response: the
+	// newest SnapshotLimit per service (0 disables them), bodies cut to
+	// SnapshotBodyBytes, and the values of SnapshotRedactHeaders hidden
+	SnapshotLimit         int
+	SnapshotBodyBytes     int
+	SnapshotRedactHeaders []string
+
+	// DataSources maps data source names to the Postgres connection
+	// strings SQL checks run their queries with, from DATA_SOURCE_<NAME>
+	// variables. Keeping them here keeps credentials out of services.
+	DataSources map[string]string
+
+	// ExecCommands is the allow-list of Nagios plugins exec checks may run,
+	// mapping command names to executable paths, from EXEC_COMMAND_<NAME>
+	// variables. Services name a command and never give a path.
+	ExecCommands map[string]string
+
+	// ClientCerts maps names to PEM files holding a client certificate and
+	// its private key, from CLIENT_CERT_<NAME> variables. Checks that
+	// authenticate with a certificate name one of them.
+	ClientCerts map[string]string
+
+	// CABundles maps names to PEM files of CA certificates, from
+	// CA_BUNDLE_<NAME> variables. HTTP checks that name one trust only
+	// those CAs.
+	CABundles map[string]string
+
+	// Secrets maps names to the values of secrets set in the environment,
+	// from SECRET_<NAME> variables. Checks reference them by name like the
+	// stored secrets, which they take precedence over.
+	Secrets map[string]string
+
+	// SecretsKey is the base64 encoded 32 byte key that the credentials
+	// checks reference by name are encrypted with. To rotate it, move the
+	// old key to SecretsPreviousKeys and set a new one; secrets are
+	// re-encrypted under the new key at startup.
+	SecretsKey          string
+	SecretsPreviousKeys []string
+
+	// Secrets that CI/CD webhooks must be signed with. Webhooks of a
+	// provider without a secret are rejected.
+	GitHubWebhookSecret string
+	GitLabWebhookToken  string
+
+	// StatusPagePath serves the public, unauthenticated status page
+	StatusPagePath  string
+	StatusPageTitle string
 }
 
 func Load() *Config {
@@ -20,6 +91,39 @@ func Load() *Config {
 		Environment:   getEnv("ENVIRONMENT", "development"),
 		LogLevel:      getEnv("LOG_LEVEL", "info"),
 		CheckInterval: getEnvInt("CHECK_INTERVAL", 30),
+
+		AuthUserHeader:     getEnv("AUTH_USER_HEADER", "X-Forwarded-User"),
+		AuditRetentionDays: getEnvInt("AUDIT_RETENTION_DAYS", 90),
+
+		CheckRetentionDays:        getEnvInt("CHECK_RETENTION_DAYS", 14),
+		MinuteRollupRetentionDays: getEnvInt("ROLLUP_1M_RETENTION_DAYS", 30),
+		HourRollupRetentionDays:   getEnvInt("ROLLUP_1H_RETENTION_DAYS", 400),
+		DayRollupRetentionDays:    getEnvInt("ROLLUP_1D_RETENTION_DAYS", 0),
+
+		AdminUsers: getEnvList("ADMIN_USERS", nil),
+
+		CertWarningDays: getEnvInt("CERT_WARNING_DAYS", 14),
+
+		SnapshotLimit:     getEnvInt("SNAPSHOT_LIMIT", 10),
+		SnapshotBodyBytes: getEnvInt("SNAPSHOT_BODY_BYTES", 4096),
+		SnapshotRedactHeaders: getEnvList("SNAPSHOT_REDACT_HEADERS", []string{
+			"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key",
+		}),
+
+		DataSources:  getEnvPrefix("DATA_SOURCE_"),
+		ExecCommands: getEnvPrefix("EXEC_COMMAND_"),
+		ClientCerts:  getEnvPrefix("CLIENT_CERT_"),
+		CABundles:    getEnvPrefix("CA_BUNDLE_"),
+		Secrets:      getEnvPrefix("SECRET_"),
+
+		SecretsKey:          getEnv("SECRETS_KEY", ""),
+		SecretsPreviousKeys: getEnvList("SECRETS_PREVIOUS_KEYS", nil),
+
+		GitHubWebhookSecret: getEnv("GITHUB_WEBHOOK_SECRET", ""),
+		GitLabWebhookToken:  getEnv("GITLAB_WEBHOOK_TOKEN", ""),
+
+		StatusPagePath:  getEnv("STATUS_PAGE_PATH", "/status"),
+		StatusPageTitle: getEnv("STATUS_PAGE_TITLE", "System Status"),
 	}
 }
 
@@ -38,3 +142,43 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvList(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+	return defaultValue
+}
+
+// getEnvPrefix collects the variables starting with prefix, keyed by the
+// rest of their name in lower case
+func getEnvPrefix(prefix string) map[string]string {
+	values := make(map[string]string)
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if name, ok := strings.CutPrefix(key, prefix); ok && name != "" && value != "" {
+			values[strings.ToLower(name)] = value
+		}
+	}
+	return values
+}
+
+// IsAdmin reports whether the user is listed in AdminUsers. The anonymous
+// user is never an admin, even when listed.
+func (c *Config) IsAdmin(username string) bool {
+	if username == "" || username == team.Anonymous {
+		return false
+	}
+	for _, admin := range c.AdminUsers {
+		if admin == username {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// Entry records a single configuration change made to a service
+type Entry struct {
+	ID          string                `json:"id"`
+	Actor       string                `json:"actor"`
+	Source      Source                `json:"source"`
+	Action      Action                `json:"action"`
+	ServiceID   string                `json:"service_id"`
+	ServiceName string                `json:"service_name"`
+	TeamID      string                `json:"team_id,omitempty"`
+	Timestamp   time.Time             `json:"timestamp"`
+	Before      json.RawMessage       `json:"before,omitempty"`
+	After       json.RawMessage       `json:"after,omitempty"`
+	Changes     []service.FieldChange `json:"changes"`
+}
+
+// Action is the kind of change that was made
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	ActionRevert Action = "revert"
+)
+
+// Source is the interface through which a change was made
+type Source string
+
+const (
+	SourceUI  Source = "ui"
+	SourceAPI Source = "api"
+)
+
+// Filter narrows down which audit entries are returned
+type Filter struct {
+	Actor     string
+	Source    Source
+	Action    Action
+	ServiceID string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// Repository defines what the audit log needs from the data layer
+type Repository interface {
+	Record(ctx context.Context, entry *Entry) error
+	List(ctx context.Context, filter Filter) ([]Entry, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// NewEntry builds an audit entry from the service state before and after a
+// change. Either side may be nil for creates and deletes.
+func NewEntry(actor string, source Source, action Action, before, after *service.Service) (*Entry, error) {
+	entry := &Entry{
+		Actor:     actor,
+		Source:    source,
+		Action:    action,
+		Timestamp: time.Now(),
+	}
+
+	for _, svc := range []*service.Service{after, before} {
+		if svc != nil {
+			entry.ServiceID = svc.ID
+			entry.ServiceName = svc.Name
+			entry.TeamID = svc.TeamID
+			break
+		}
+	}
+
+	var err error
+	if entry.Before, err = snapshot(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = snapshot(after); err != nil {
+		return nil, err
+	}
+	if entry.Changes, err = service.Diff(before, after); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// snapshot marshals a service into raw JSON, leaving nil services empty
+func snapshot(svc *service.Service) (json.RawMessage, error) {
+	if svc == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(svc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal service: %w", err)
+	}
+
+	return data, nil
+}
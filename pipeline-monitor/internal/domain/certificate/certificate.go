@@ -0,0 +1,128 @@
+package certificate
+
+import (
+	"context"
+	"time"
+)
+
+// Problem classifies what is wrong with a certificate chain
+type Problem string
+
+const (
+	ProblemNone             Problem = ""
+	ProblemExpiringSoon     Problem = "expiring_soon"
+	ProblemExpired          Problem = "expired"
+	ProblemHostnameMismatch Problem = "hostname_mismatch"
+	ProblemChainInvalid     Problem = "chain_invalid"
+)
+
+// Label returns the text shown for a problem
+func (p Problem) Label() string {
+	switch p {
+	case ProblemExpiringSoon:
+		return "Expiring soon"
+	case ProblemExpired:
+		return "Expired"
+	case ProblemHostnameMismatch:
+		return "Hostname mismatch"
+	case ProblemChainInvalid:
+		return "Invalid chain"
+	default:
+		return "OK"
+	}
+}
+
+// AlertDays are the days before expiry at which an alert is raised
+var AlertDays = []int{30, 14, 7, 1}
+
+// Certificate is one certificate of a peer's chain
+type Certificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans,omitempty"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// Chain is the certificate chain a service presented on its latest TLS
+// check, leaf first
+type Chain struct {
+	ServiceID    string        `json:"service_id"`
+	ServiceName  string        `json:"service_name"`
+	Host         string        `json:"host"`
+	Certificates []Certificate `json:"certificates"`
+	Problem      Problem       `json:"problem,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	CheckedAt    time.Time     `json:"checked_at"`
+}
+
+// Leaf returns the server's own certificate, or nil if the chain is empty
+func (c *Chain) Leaf() *Certificate {
+	if len(c.Certificates) == 0 {
+		return nil
+	}
+	return &c.Certificates[0]
+}
+
+// NotAfter returns when the first certificate of the chain expires, or the
+// zero time if the chain is empty
+func (c *Chain) NotAfter() time.Time {
+	var earliest time.Time
+	for _, cert := range c.Certificates {
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+	return earliest
+}
+
+// DaysLeft returns the whole days until the chain expires, negative once
+// it has expired
+func (c *Chain) DaysLeft(now time.Time) int {
+	left := c.NotAfter().Sub(now)
+	days := int(left / (24 * time.Hour))
+	if left < 0 {
+		days--
+	}
+	return days
+}
+
+// AlertThreshold returns the most urgent entry of AlertDays that daysLeft
+// has reached, if any
+func AlertThreshold(daysLeft int) (int, bool) {
+	threshold, ok := 0, false
+	for _, days := range AlertDays {
+		if daysLeft <= days {
+			threshold, ok = days, true
+		}
+	}
+	return threshold, ok
+}
+
+// Alert records that a chain came within one of the AlertDays of expiring.
+// Each threshold alerts once per certificate.
+type Alert struct {
+	ID          int64     `json:"id"`
+	ServiceID   string    `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	Host        string    `json:"host"`
+	Subject     string    `json:"subject"`
+	NotAfter    time.Time `json:"not_after"`
+	Days        int       `json:"days"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Repository defines the interface for certificate data access
+type Repository interface {
+	// Save replaces the chain recorded for a service
+	Save(ctx context.Context, chain *Chain) error
+	// Get returns the chain recorded for a service, or nil if there is none
+	Get(ctx context.Context, serviceID string) (*Chain, error)
+	// ListExpiring returns chains expiring before the given time or with a
+	// validation problem, soonest first
+	ListExpiring(ctx context.Context, before time.Time) ([]Chain, error)
+	// RecordAlert stores an alert unless the same certificate already
+	// alerted at that threshold, and reports whether it was new
+	RecordAlert(ctx context.Context, alert *Alert) (bool, error)
+	ListAlerts(ctx context.Context, serviceIDs []string, since time.Time, limit int) ([]Alert, error)
+}
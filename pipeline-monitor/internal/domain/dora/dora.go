@@ -0,0 +1,148 @@
+package dora
+
+import (
+	"sort"
+	"time"
+
+	"pipeline-monitor/internal/domain/event"
+	"pipeline-monitor/internal/domain/incident"
+)
+
+// FailureWindow is how long after a deploy an incident of the same service
+// counts as caused by it, unless the service is deployed again sooner
+const FailureWindow = 24 * time.Hour
+
+// Rules documents how each metric is calculated. The reports API returns
+// it alongside the metrics.
+var Rules = map[string]string{
+	"deployment_frequency": "Deploy events of the services that occurred in the period, " +
+		"divided by the length of the period in days. Tag-wide events and other kinds of events do not count.",
+	"lead_time_for_changes": "Median time from committed_at to occurred_at of the deploys in the period. " +
+		"Deploys that do not report committed_at are left out.",
+	"change_failure_rate": "Share of the deploys in the period followed by an incident of the same service " +
+		"that started after the deploy and before the next deploy of that service, at most 24 hours later.",
+	"time_to_restore": "Mean duration of the incidents that started in the period and have been resolved. " +
+		"Incidents still open are counted separately.",
+}
+
+// Metrics are the four DORA metrics of a set of services over a period
+type Metrics struct {
+	Deployments       int     `json:"deployments"`
+	DeploymentsPerDay float64 `json:"deployments_per_day"`
+
+	// LeadTimeSeconds is the median lead time of the LeadTimeSamples
+	// deploys that reported when their change was committed
+	LeadTimeSeconds int64 `json:"lead_time_seconds"`
+	LeadTimeSamples int   `json:"lead_time_samples"`
+
+	FailedDeployments int `json:"failed_deployments"`
+	// ChangeFailureRate is the share of failed deployments in percent
+	ChangeFailureRate float64 `json:"change_failure_rate"`
+
+	// TimeToRestoreSeconds is the mean duration of the RestoredIncidents
+	TimeToRestoreSeconds int64 `json:"time_to_restore_seconds"`
+	RestoredIncidents    int   `json:"restored_incidents"`
+	OpenIncidents        int   `json:"open_incidents"`
+}
+
+// LeadTime returns the median lead time for changes
+func (m Metrics) LeadTime() time.Duration {
+	return time.Duration(m.LeadTimeSeconds) * time.Second
+}
+
+// TimeToRestore returns the mean time to restore service
+func (m Metrics) TimeToRestore() time.Duration {
+	return time.Duration(m.TimeToRestoreSeconds) * time.Second
+}
+
+// Compute calculates the metrics between from and to from the deploy
+// events and incidents of a set of services, following Rules. Events and
+// incidents outside the period are ignored, except that a deploy just
+// after the period still ends the failure window of the one before it.
+func Compute(from, to time.Time, events []event.Event, incidents []incident.Incident) Metrics {
+	var m Metrics
+
+	deploys := make(map[string][]event.Event)
+	for _, e := range events {
+		if e.Kind == event.KindDeploy && e.ServiceID != "" {
+			deploys[e.ServiceID] = append(deploys[e.ServiceID], e)
+		}
+	}
+
+	incidentStarts := make(map[string][]time.Time)
+	for _, inc := range incidents {
+		incidentStarts[inc.ServiceID] = append(incidentStarts[inc.ServiceID], inc.StartedAt)
+	}
+
+	var leadTimes []time.Duration
+	for serviceID, list := range deploys {
+		sort.Slice(list, func(i, j int) bool { return list[i].OccurredAt.Before(list[j].OccurredAt) })
+
+		for i, deploy := range list {
+			if !within(deploy.OccurredAt, from, to) {
+				continue
+			}
+			m.Deployments++
+
+			if deploy.CommittedAt != nil {
+				leadTimes = append(leadTimes, deploy.OccurredAt.Sub(*deploy.CommittedAt))
+			}
+
+			end := deploy.OccurredAt.Add(FailureWindow)
+			if i+1 < len(list) && list[i+1].OccurredAt.Before(end) {
+				end = list[i+1].OccurredAt
+			}
+			for _, started := range incidentStarts[serviceID] {
+				if started.After(deploy.OccurredAt) && started.Before(end) {
+					m.FailedDeployments++
+					break
+				}
+			}
+		}
+	}
+
+	if days := to.Sub(from).Hours() / 24; days > 0 {
+		m.DeploymentsPerDay = float64(m.Deployments) / days
+	}
+	if m.Deployments > 0 {
+		m.ChangeFailureRate = float64(m.FailedDeployments) / float64(m.Deployments) * 100
+	}
+	if len(leadTimes) > 0 {
+		m.LeadTimeSamples = len(leadTimes)
+		m.LeadTimeSeconds = int64(median(leadTimes).Seconds())
+	}
+
+	var restore time.Duration
+	for _, inc := range incidents {
+		if !within(inc.StartedAt, from, to) {
+			continue
+		}
+		if inc.ResolvedAt == nil {
+			m.OpenIncidents++
+			continue
+		}
+		m.RestoredIncidents++
+		restore += inc.ResolvedAt.Sub(inc.StartedAt)
+	}
+	if m.RestoredIncidents > 0 {
+		m.TimeToRestoreSeconds = int64((restore / time.Duration(m.RestoredIncidents)).Seconds())
+	}
+
+	return m
+}
+
+// within reports whether t lies in the half-open period [from, to)
+func within(t, from, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+// median returns the middle value of durations, averaging the two middle
+// ones for an even count. It sorts durations in place.
+func median(durations []time.Duration) time.Duration {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2
+	}
+	return durations[mid]
+}
@@ -0,0 +1,104 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Kind is what an event records
+type Kind string
+
+const (
+	KindDeploy       Kind = "deploy"
+	KindConfigChange Kind = "config_change"
+	KindCustom       Kind = "custom"
+)
+
+// Event annotates a point in time with a change that may explain what the
+// checks saw, such as a deploy. It belongs either to one service or, for
+// platform-wide changes, to every service carrying a tag.
+type Event struct {
+	ID        string `json:"id"`
+	ServiceID string `json:"service_id,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	Kind      Kind   `json:"kind"`
+	Title     string `json:"title"`
+	Version   string `json:"version,omitempty"`
+	Author    string `json:"author,omitempty"`
+	Link      string `json:"link,omitempty"`
+
+	// OccurredAt is when the change happened, which defaults to when the
+	// event was posted
+	OccurredAt time.Time `json:"occurred_at"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// CommittedAt is when the deployed change was committed. Deploys that
+	// report it count towards the lead time for changes.
+	CommittedAt *time.Time `json:"committed_at,omitempty"`
+}
+
+// Validate checks the kind and link of an event and that it names either
+// a service or a tag. An empty title is filled in from the kind and
+// version.
+func (e *Event) Validate() error {
+	switch e.Kind {
+	case KindDeploy, KindConfigChange, KindCustom:
+	default:
+		return fmt.Errorf("kind must be one of %s, %s or %s", KindDeploy, KindConfigChange, KindCustom)
+	}
+
+	if (e.ServiceID == "") == (e.Tag == "") {
+		return fmt.Errorf("an event belongs to either a service or a tag")
+	}
+
+	if e.Link != "" {
+		u, err := url.Parse(e.Link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("link must be an absolute http or https URL")
+		}
+	}
+
+	if e.CommittedAt != nil && e.CommittedAt.After(e.OccurredAt) {
+		return fmt.Errorf("committed_at cannot be after occurred_at")
+	}
+
+	if e.Title == "" {
+		e.Title = e.Kind.Label()
+		if e.Version != "" {
+			e.Title += " " + e.Version
+		}
+	}
+	return nil
+}
+
+// Label returns the kind for display
+func (k Kind) Label() string {
+	switch k {
+	case KindDeploy:
+		return "Deploy"
+	case KindConfigChange:
+		return "Config change"
+	default:
+		return "Event"
+	}
+}
+
+// Repository defines the interface for event data access
+type Repository interface {
+	Create(ctx context.Context, e *Event) error
+	GetByID(ctx context.Context, id string) (*Event, error)
+	// ListForService returns the events of a service and the tag-wide
+	// events of its tags that occurred between from and to (either may be
+	// zero), oldest first
+	ListForService(ctx context.Context, serviceID string, tags []string, from, to time.Time) ([]Event, error)
+	// ListByKind returns the events of a kind of the given services that
+	// occurred between from and to (either may be zero), oldest first.
+	// Tag-wide events are not included.
+	ListByKind(ctx context.Context, kind Kind, serviceIDs []string, from, to time.Time) ([]Event, error)
+	// ListForTag returns the tag-wide events of a tag that occurred
+	// between from and to (either may be zero), oldest first
+	ListForTag(ctx context.Context, tag string, from, to time.Time) ([]Event, error)
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,75 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// Kind is what a ping reports about a run of a job
+type Kind string
+
+const (
+	KindStart   Kind = "start"
+	KindSuccess Kind = "success"
+	KindFail    Kind = "fail"
+)
+
+// MaxPayload caps the size of the log a ping may carry, in bytes
+const MaxPayload = 16 << 10
+
+// Ping is a single call of a heartbeat service's ping URL
+type Ping struct {
+	ID         int64     `json:"id"`
+	ServiceID  string    `json:"service_id"`
+	Kind       Kind      `json:"kind"`
+	Payload    string    `json:"payload,omitempty"`
+	RemoteAddr string    `json:"remote_addr"`
+	ReceivedAt time.Time `json:"received_at"`
+
+	// Duration is how long the run took from its start ping, in
+	// milliseconds. It is only set on success and fail pings that follow
+	// a start ping.
+	Duration *int `json:"duration_ms,omitempty"`
+}
+
+// Evaluate returns the status of a heartbeat service at now from its last
+// success or fail ping, which is nil if it never completed a run. Services
+// that never pinged count from their creation.
+func Evaluate(svc *service.Service, last *Ping, now time.Time) (service.Status, error) {
+	deadline := svc.Period() + svc.Grace()
+
+	if last == nil {
+		if now.Sub(svc.CreatedAt) <= deadline {
+			return service.StatusUnknown, nil
+		}
+		return service.StatusUnhealthy, fmt.Errorf("no ping received within %s of creation", deadline)
+	}
+
+	if late := now.Sub(last.ReceivedAt) - deadline; late > 0 {
+		return service.StatusUnhealthy, fmt.Errorf("ping overdue by %s, last at %s",
+			late.Round(time.Second), last.ReceivedAt.UTC().Format(time.RFC3339))
+	}
+
+	if last.Kind == KindFail {
+		return service.StatusUnhealthy, fmt.Errorf("job reported failure at %s", last.ReceivedAt.UTC().Format(time.RFC3339))
+	}
+
+	return service.StatusHealthy, nil
+}
+
+// Repository defines the interface for heartbeat ping data access
+type Repository interface {
+	// Record stores a ping. Success and fail pings get the duration since
+	// the start of the run, if a start ping opened it.
+	Record(ctx context.Context, ping *Ping) error
+	// LastCompleted returns the latest success or fail ping of a service,
+	// or nil if there is none
+	LastCompleted(ctx context.Context, serviceID string) (*Ping, error)
+	// List returns the latest pings of a service, newest first
+	List(ctx context.Context, serviceID string, limit int) ([]Ping, error)
+	// DeleteBefore removes pings received before the given time
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
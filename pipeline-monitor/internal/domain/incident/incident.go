@@ -0,0 +1,64 @@
+package incident
+
+import (
+	"context"
+	"time"
+)
+
+// Status is the lifecycle state of an incident
+type Status string
+
+const (
+	StatusOpen     Status = "open"
+	StatusResolved Status = "resolved"
+)
+
+// Incident is a period during which a service was failing. The monitor
+// opens and resolves incidents automatically on status transitions, noting
+// the check error as the cause; people can also open them by hand and
+// write the message shown to customers.
+type Incident struct {
+	ID          string     `json:"id"`
+	ServiceID   string     `json:"service_id"`
+	ServiceName string     `json:"service_name"`
+	Title       string     `json:"title"`
+	Message     string     `json:"message"`
+	Cause       string     `json:"cause,omitempty"`
+	Status      Status     `json:"status"`
+	Automatic   bool       `json:"automatic"`
+	StartedAt   time.Time  `json:"started_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Active reports whether the incident is still open
+func (i *Incident) Active() bool {
+	return i.Status == StatusOpen
+}
+
+// Duration returns how long the incident lasted, or has lasted so far
+func (i *Incident) Duration() time.Duration {
+	if i.ResolvedAt != nil {
+		return i.ResolvedAt.Sub(i.StartedAt)
+	}
+	return time.Since(i.StartedAt)
+}
+
+// Filter narrows down the incidents returned by List
+type Filter struct {
+	ServiceIDs []string
+	ActiveOnly bool
+	Since      time.Time
+	Limit      int
+}
+
+// Repository defines the interface for incident data access
+type Repository interface {
+	Create(ctx context.Context, incident *Incident) error
+	GetByID(ctx context.Context, id string) (*Incident, error)
+	// GetActive returns the open incident of a service, or nil if there is none
+	GetActive(ctx context.Context, serviceID string) (*Incident, error)
+	List(ctx context.Context, filter Filter) ([]Incident, error)
+	Update(ctx context.Context, incident *Incident) error
+	Resolve(ctx context.Context, id string, at time.Time) error
+}
@@ -0,0 +1,249 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+)
+
+// Provider is the CI/CD system a pipeline runs on
+type Provider string
+
+const (
+	ProviderGitHub Provider = "github"
+	ProviderGitLab Provider = "gitlab"
+)
+
+// Outcome is the state of a run or of one of its stages
+type Outcome string
+
+const (
+	OutcomePending   Outcome = "pending"
+	OutcomeRunning   Outcome = "running"
+	OutcomeSuccess   Outcome = "success"
+	OutcomeFailure   Outcome = "failure"
+	OutcomeCancelled Outcome = "cancelled"
+	OutcomeSkipped   Outcome = "skipped"
+)
+
+// Finished reports whether a run or stage with this outcome has ended
+func (o Outcome) Finished() bool {
+	switch o {
+	case OutcomeSuccess, OutcomeFailure, OutcomeCancelled, OutcomeSkipped:
+		return true
+	}
+	return false
+}
+
+// Pipeline is a CI/CD workflow of a repository, e.g. a GitHub Actions
+// workflow or the pipeline of a GitLab project. It may be linked to the
+// service it deploys.
+type Pipeline struct {
+	ID         string   `json:"id"`
+	Provider   Provider `json:"provider"`
+	Repository string   `json:"repository"`
+	Name       string   `json:"name"`
+	URL        string   `json:"url,omitempty"`
+	ServiceID  string   `json:"service_id,omitempty"`
+
+	// ServiceName is the name of the linked service, filled in on reads
+	ServiceName string `json:"service_name,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Stage is a part of a run: a job of a GitHub workflow or a stage of a
+// GitLab pipeline
+type Stage struct {
+	Name       string     `json:"name"`
+	Outcome    Outcome    `json:"outcome"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Duration returns how long the stage ran, or zero if it has not finished
+func (s Stage) Duration() time.Duration {
+	return duration(s.StartedAt, s.FinishedAt)
+}
+
+// Run is a single execution of a pipeline
+type Run struct {
+	ID         int64      `json:"id"`
+	PipelineID string     `json:"pipeline_id"`
+	ExternalID string     `json:"external_id"`
+	Attempt    int        `json:"attempt"`
+	Number     int        `json:"number,omitempty"`
+	Branch     string     `json:"branch,omitempty"`
+	Commit     string     `json:"commit,omitempty"`
+	Actor      string     `json:"actor,omitempty"`
+	URL        string     `json:"url,omitempty"`
+	Outcome    Outcome    `json:"outcome"`
+	Stages     []Stage    `json:"stages"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Duration returns how long the run took, or zero if it has not finished
+func (r *Run) Duration() time.Duration {
+	return duration(r.StartedAt, r.FinishedAt)
+}
+
+// ShortCommit returns the commit SHA abbreviated to 7 characters
+func (r *Run) ShortCommit() string {
+	if len(r.Commit) > 7 {
+		return r.Commit[:7]
+	}
+	return r.Commit
+}
+
+// Merge applies a webhook update to a run. Providers deliver events out of
+// order, so an update of an earlier attempt is ignored, a later attempt
+// starts the run over, and a finished run or stage keeps its outcome when
+// a delayed pending or running event arrives. Empty fields of the update
+// leave the run unchanged.
+func (r *Run) Merge(update *Run) {
+	if update.Attempt < r.Attempt {
+		return
+	}
+	if update.Attempt > r.Attempt {
+		r.Attempt = update.Attempt
+		r.Outcome = ""
+		r.Stages = nil
+		r.StartedAt, r.FinishedAt = nil, nil
+	}
+
+	if update.Number != 0 {
+		r.Number = update.Number
+	}
+	r.Branch = pick(update.Branch, r.Branch)
+	r.Commit = pick(update.Commit, r.Commit)
+	r.Actor = pick(update.Actor, r.Actor)
+	r.URL = pick(update.URL, r.URL)
+
+	if update.Outcome != "" && (update.Outcome.Finished() || !r.Outcome.Finished()) {
+		r.Outcome = update.Outcome
+		if update.FinishedAt != nil || !update.Outcome.Finished() {
+			r.FinishedAt = update.FinishedAt
+		}
+	}
+	if r.Outcome == "" {
+		r.Outcome = OutcomeRunning
+	}
+	if update.StartedAt != nil {
+		r.StartedAt = update.StartedAt
+	}
+
+	for _, stage := range update.Stages {
+		r.mergeStage(stage)
+	}
+}
+
+// mergeStage adds a stage to the run or updates the stage of the same name
+func (r *Run) mergeStage(update Stage) {
+	for i := range r.Stages {
+		stage := &r.Stages[i]
+		if stage.Name != update.Name {
+			continue
+		}
+		if update.Outcome.Finished() || !stage.Outcome.Finished() {
+			stage.Outcome = update.Outcome
+			stage.FinishedAt = update.FinishedAt
+		}
+		if update.StartedAt != nil {
+			stage.StartedAt = update.StartedAt
+		}
+		return
+	}
+	r.Stages = append(r.Stages, update)
+}
+
+// Trend summarises the latest finished runs of a pipeline
+type Trend struct {
+	Runs      int `json:"runs"`
+	Passed    int `json:"passed"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+
+	// AverageDuration is the mean duration of the passed runs, in
+	// milliseconds
+	AverageDuration int `json:"average_duration_ms"`
+}
+
+// SuccessRate returns the share of passed runs in percent, or 0 without
+// runs
+func (t Trend) SuccessRate() float64 {
+	if t.Runs == 0 {
+		return 0
+	}
+	return float64(t.Passed) / float64(t.Runs) * 100
+}
+
+// NewTrend summarises the finished runs among the given ones. Skipped runs
+// do not count.
+func NewTrend(runs []Run) Trend {
+	var trend Trend
+	var total time.Duration
+	for _, run := range runs {
+		switch run.Outcome {
+		case OutcomeSuccess:
+			trend.Passed++
+			total += run.Duration()
+		case OutcomeFailure:
+			trend.Failed++
+		case OutcomeCancelled:
+			trend.Cancelled++
+		default:
+			continue
+		}
+		trend.Runs++
+	}
+	if trend.Passed > 0 {
+		trend.AverageDuration = int((total / time.Duration(trend.Passed)).Milliseconds())
+	}
+	return trend
+}
+
+// Summary is a pipeline with its latest runs, newest first, and their trend
+type Summary struct {
+	Pipeline
+	Runs  []Run `json:"runs"`
+	Trend Trend `json:"trend"`
+}
+
+// Repository defines the interface for pipeline data access
+type Repository interface {
+	// Record stores a webhook update: it creates the pipeline if it is new
+	// (filling in its ID) and merges the run into the stored one
+	Record(ctx context.Context, p *Pipeline, run *Run) error
+	// Get returns a pipeline by ID
+	Get(ctx context.Context, id string) (*Pipeline, error)
+	// List returns all pipelines visible to the caller, ordered by
+	// repository and name. Pipelines not linked to a service are visible
+	// to everyone.
+	List(ctx context.Context) ([]Pipeline, error)
+	// ListByService returns the pipelines linked to a service
+	ListByService(ctx context.Context, serviceID string) ([]Pipeline, error)
+	// SetService links a pipeline to a service, or unlinks it if the
+	// service ID is empty
+	SetService(ctx context.Context, id, serviceID string) error
+	// Runs returns the latest runs of a pipeline, newest first
+	Runs(ctx context.Context, pipelineID string, limit int) ([]Run, error)
+}
+
+// pick returns value unless it is empty
+func pick(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// duration returns the time between start and end, or zero if either is
+// missing
+func duration(start, end *time.Time) time.Duration {
+	if start == nil || end == nil {
+		return 0
+	}
+	return end.Sub(*start)
+}
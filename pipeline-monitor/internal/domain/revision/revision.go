@@ -0,0 +1,53 @@
+package revision
+
+import (
+	"context"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// Revision is a stored version of a service definition
+type Revision struct {
+	ID        string          `json:"id"`
+	ServiceID string          `json:"service_id"`
+	Version   int             `json:"version"`
+	Actor     string          `json:"actor"`
+	CreatedAt time.Time       `json:"created_at"`
+	Service   service.Service `json:"service"`
+}
+
+// Comparison is the difference between two revisions of the same service
+type Comparison struct {
+	From    *Revision             `json:"from"`
+	To      *Revision             `json:"to"`
+	Changes []service.FieldChange `json:"changes"`
+}
+
+// Repository defines what revision history needs from the data layer
+type Repository interface {
+	// Record stores a new revision, assigning it the next version number
+	Record(ctx context.Context, rev *Revision) error
+	ListByService(ctx context.Context, serviceID string) ([]Revision, error)
+	Get(ctx context.Context, serviceID string, version int) (*Revision, error)
+}
+
+// New creates an unsaved revision from the current state of a service
+func New(svc *service.Service, actor string) *Revision {
+	return &Revision{
+		ServiceID: svc.ID,
+		Actor:     actor,
+		CreatedAt: time.Now(),
+		Service:   *svc.Clone(),
+	}
+}
+
+// Compare diffs two revisions
+func Compare(from, to *Revision) (*Comparison, error) {
+	changes, err := service.Diff(&from.Service, &to.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Comparison{From: from, To: to, Changes: changes}, nil
+}
@@ -0,0 +1,83 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// ErrEnvironment is returned when changing a secret that is set in the
+// environment
+var ErrEnvironment = errors.New("secret is set in the environment and can only be changed there")
+
+// environment serves the secrets configured with SECRET_<NAME> variables
+// ahead of the stored ones. They are shared and read-only: operators
+// change them by changing the environment and restarting.
+type environment struct {
+	Repository
+	values map[string]string
+}
+
+// WithEnvironment returns a repository that adds the secrets of values, by
+// name, to those stored in repo. A secret in values hides a stored secret
+// of the same name.
+func WithEnvironment(repo Repository, values map[string]string) Repository {
+	if len(values) == 0 {
+		return repo
+	}
+	return &environment{Repository: repo, values: values}
+}
+
+// List returns the secrets of the environment and the stored ones ordered
+// by name
+func (e *environment) List(ctx context.Context) ([]Secret, error) {
+	stored, err := e.Repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]Secret, 0, len(e.values)+len(stored))
+	for name := range e.values {
+		secrets = append(secrets, Secret{Name: name, Environment: true})
+	}
+	for _, s := range stored {
+		if _, ok := e.values[s.Name]; !ok {
+			secrets = append(secrets, s)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	return secrets, nil
+}
+
+// Get returns a secret without its value
+func (e *environment) Get(ctx context.Context, name string) (*Secret, error) {
+	if _, ok := e.values[name]; ok {
+		return &Secret{Name: name, Environment: true}, nil
+	}
+	return e.Repository.Get(ctx, name)
+}
+
+// Value returns the value of a secret, which needs no key for secrets of
+// the environment
+func (e *environment) Value(ctx context.Context, name string) (string, error) {
+	if value, ok := e.values[name]; ok {
+		return value, nil
+	}
+	return e.Repository.Value(ctx, name)
+}
+
+// Put stores a secret unless the environment sets it
+func (e *environment) Put(ctx context.Context, name, teamID, value string) (*Secret, error) {
+	if _, ok := e.values[name]; ok {
+		return nil, ErrEnvironment
+	}
+	return e.Repository.Put(ctx, name, teamID, value)
+}
+
+// Delete removes a stored secret unless the environment sets it
+func (e *environment) Delete(ctx context.Context, name string) error {
+	if _, ok := e.values[name]; ok {
+		return ErrEnvironment
+	}
+	return e.Repository.Delete(ctx, name)
+}
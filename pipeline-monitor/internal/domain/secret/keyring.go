@@ -0,0 +1,155 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Sealed is a value encrypted with envelope encryption. Ciphertext is the
+// value encrypted with a data key of its own, and DataKey is that data key
+// encrypted with the key encryption key KeyID. Both start with their
+// nonce and are bound to the name of the secret, so sealed values cannot
+// be swapped between secrets.
+type Sealed struct {
+	KeyID      string
+	DataKey    []byte
+	Ciphertext []byte
+}
+
+// Keyring holds the key encryption keys: the current one, which seals
+// every value, and previous ones, which can still open the values sealed
+// before a rotation
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring parses base64 encoded 32 byte keys, as generated by
+// "openssl rand -base64 32"
+func NewKeyring(current string, previous []string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+
+	id, aead, err := parseKey(current)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SECRETS_KEY: %w", err)
+	}
+	k.current = id
+	k.keys[id] = aead
+
+	for i, encoded := range previous {
+		id, aead, err := parseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous key %d: %w", i+1, err)
+		}
+		if _, ok := k.keys[id]; !ok {
+			k.keys[id] = aead
+		}
+	}
+
+	return k, nil
+}
+
+// parseKey decodes a key and identifies it by a prefix of its SHA-256, so
+// stored values record their key without revealing it
+func parseKey(encoded string) (string, cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("key is not base64: %w", err)
+	}
+	if len(key) != 32 {
+		return "", nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8]), aead, nil
+}
+
+// CurrentKeyID returns the ID of the key new values are sealed with
+func (k *Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+// Seal encrypts the value of a secret with a new data key under the
+// current key
+func (k *Keyring) Seal(name string, value []byte) (*Sealed, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	valueAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(valueAEAD, value, name)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := seal(k.keys[k.current], dataKey, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sealed{KeyID: k.current, DataKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts the value of a secret with the key it was sealed with
+func (k *Keyring) Open(name string, sealed *Sealed) ([]byte, error) {
+	keyAEAD, ok := k.keys[sealed.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("secret %q is sealed with unknown key %s", name, sealed.KeyID)
+	}
+
+	dataKey, err := open(keyAEAD, sealed.DataKey, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key of secret %q: %w", name, err)
+	}
+
+	valueAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	value, err := open(valueAEAD, sealed.Ciphertext, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret %q: %w", name, err)
+	}
+	return value, nil
+}
+
+// newAEAD returns AES-256-GCM with the key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which it puts in front
+func seal(aead cipher.AEAD, plaintext []byte, name string) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(name)), nil
+}
+
+// open decrypts the output of seal
+func open(aead cipher.AEAD, data []byte, name string) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(name))
+}
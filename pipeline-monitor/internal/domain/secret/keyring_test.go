@@ -0,0 +1,126 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// testKey returns a base64 encoded 32 byte key filled with b
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func newTestKeyring(t *testing.T, current string, previous ...string) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(current, previous)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+func TestKeyringOpen(t *testing.T) {
+	k := newTestKeyring(t, testKey(1))
+
+	sealed, err := k.Seal("db_password", []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	other, err := k.Seal("api_token", []byte("abc123"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	flip := func(b []byte) []byte {
+		b = bytes.Clone(b)
+		b[len(b)-1] ^= 1
+		return b
+	}
+
+	tests := []struct {
+		name   string
+		open   string
+		sealed *Sealed
+		err    string
+	}{
+		{name: "same name", open: "db_password", sealed: sealed},
+		{name: "other name", open: "api_token", sealed: sealed, err: "failed to decrypt data key"},
+		{name: "data key of another secret", open: "db_password", sealed: &Sealed{KeyID: sealed.KeyID, DataKey: other.DataKey, Ciphertext: sealed.Ciphertext}, err: "failed to decrypt data key"},
+		{name: "ciphertext of another secret", open: "db_password", sealed: &Sealed{KeyID: sealed.KeyID, DataKey: sealed.DataKey, Ciphertext: other.Ciphertext}, err: "failed to decrypt secret"},
+		{name: "tampered data key", open: "db_password", sealed: &Sealed{KeyID: sealed.KeyID, DataKey: flip(sealed.DataKey), Ciphertext: sealed.Ciphertext}, err: "failed to decrypt data key"},
+		{name: "tampered ciphertext", open: "db_password", sealed: &Sealed{KeyID: sealed.KeyID, DataKey: sealed.DataKey, Ciphertext: flip(sealed.Ciphertext)}, err: "failed to decrypt secret"},
+		{name: "truncated ciphertext", open: "db_password", sealed: &Sealed{KeyID: sealed.KeyID, DataKey: sealed.DataKey, Ciphertext: sealed.Ciphertext[:4]}, err: "too short"},
+		{name: "unknown key", open: "db_password", sealed: &Sealed{KeyID: "0000000000000000", DataKey: sealed.DataKey, Ciphertext: sealed.Ciphertext}, err: "unknown key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := k.Open(tt.open, tt.sealed)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Open: %v", err)
+				}
+				if string(value) != "hunter2" {
+					t.Errorf("value = %q, want %q", value, "hunter2")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.err)
+			}
+			if value != nil {
+				t.Errorf("value = %q, want none", value)
+			}
+		})
+	}
+}
+
+func TestKeyringOpensWithPreviousKey(t *testing.T) {
+	old := newTestKeyring(t, testKey(1))
+	sealed, err := old.Seal("db_password", []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	rotated := newTestKeyring(t, testKey(2), testKey(1))
+	if rotated.CurrentKeyID() == sealed.KeyID {
+		t.Fatal("rotated keyring kept the old current key")
+	}
+	value, err := rotated.Open("db_password", sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(value) != "hunter2" {
+		t.Errorf("value = %q, want %q", value, "hunter2")
+	}
+
+	// Without the previous key the value cannot be opened
+	if _, err := newTestKeyring(t, testKey(2)).Open("db_password", sealed); err == nil {
+		t.Error("opened a value sealed with a key the keyring does not hold")
+	}
+}
+
+func TestNewKeyringRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		previous []string
+		err      string
+	}{
+		{name: "not base64", current: "not a key!", err: "not base64"},
+		{name: "too short", current: base64.StdEncoding.EncodeToString(make([]byte, 16)), err: "must be 32 bytes"},
+		{name: "empty", current: "", err: "must be 32 bytes"},
+		{name: "invalid previous key", current: testKey(1), previous: []string{"nope"}, err: "invalid previous key 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.current, tt.previous)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
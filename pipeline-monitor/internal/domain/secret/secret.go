@@ -0,0 +1,68 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for names no secret is stored under
+	ErrNotFound = errors.New("secret not found")
+	// ErrNoKey is returned when secrets are used but no key is configured
+	ErrNoKey = errors.New("secret storage is disabled: SECRETS_KEY is not set")
+)
+
+// Secret describes a stored secret, such as the password of a database
+// check. The value is not part of it: values are written once and only
+// ever read back, decrypted, by the monitor, so they never end up in pages
+// or API responses.
+//
+// A secret owned by a team can only be used by the checks of that team's
+// services. Secrets without a team are shared, and only admins can use
+// them in checks, since whoever sets up a check decides where the value
+// is sent.
+type Secret struct {
+	Name        string    `json:"name"`
+	TeamID      string    `json:"team_id,omitempty"`
+	TeamName    string    `json:"team_name,omitempty"`
+	KeyID       string    `json:"key_id,omitempty"`      // the key encryption key the value is sealed with
+	Environment bool      `json:"environment,omitempty"` // set with a SECRET_<NAME> variable rather than stored
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Shared reports whether the secret belongs to no team
+func (s *Secret) Shared() bool {
+	return s.TeamID == ""
+}
+
+// validName matches the names checks can reference secrets by
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// ValidateName checks that a secret name is lower case and short enough
+// to be referenced from a check
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("secret names must be 1-64 lower case letters, digits, '_', '.' or '-'")
+	}
+	return nil
+}
+
+// Repository stores secrets encrypted at rest. List, Get and Value see the
+// shared secrets and those of the teams in the context's scope.
+type Repository interface {
+	List(ctx context.Context) ([]Secret, error)
+	Get(ctx context.Context, name string) (*Secret, error)
+	// Value decrypts a secret for use in a check
+	Value(ctx context.Context, name string) (string, error)
+	// Put creates a secret or replaces its value and owning team, which
+	// is empty for shared secrets
+	Put(ctx context.Context, name, teamID, value string) (*Secret, error)
+	Delete(ctx context.Context, name string) error
+	// Rotate re-encrypts the secrets sealed with a previous key under the
+	// current one and returns how many it re-encrypted
+	Rotate(ctx context.Context) (int, error)
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Target describes what the monitor checks: the URL, the expected ping
+// interval of a heartbeat service, the data source of a SQL check, the
+// command of an exec check or the URL and health service of a gRPC check.
+// Postgres, MySQL and Redis checks show their URL, which holds no
+// credentials, and synthetic checks their number of steps.
+func (s *Service) Target() string {
+	switch s.CheckType {
+	case CheckHeartbeat:
+		return fmt.Sprintf("heartbeat every %s", s.Period())
+	case CheckSQL:
+		if s.SQL != nil {
+			return "sql: " + s.SQL.DataSource
+		}
+	case CheckExec:
+		if s.Exec != nil {
+			return "exec: " + s.Exec.Command
+		}
+	case CheckGRPC:
+		if s.GRPC != nil && s.GRPC.Service != "" {
+			return s.URL + " (" + s.GRPC.Service + ")"
+		}
+	case CheckSynthetic:
+		if s.Synthetic != nil {
+			steps := fmt.Sprintf("%d steps", len(s.Synthetic.Steps))
+			if s.URL == "" {
+				return "synthetic: " + steps
+			}
+			return s.URL + " (" + steps + ")"
+		}
+	}
+	return s.URL
+}
+
+// Period returns the expected interval between heartbeat pings
+func (s *Service) Period() time.Duration {
+	return time.Duration(s.HeartbeatPeriod) * time.Second
+}
+
+// Grace returns how late a heartbeat ping may be before the service counts
+// as down
+func (s *Service) Grace() time.Duration {
+	return time.Duration(s.HeartbeatGrace) * time.Second
+}
+
+// CheckType is how a service is monitored
+type CheckType string
+
+const (
+	CheckHTTP      CheckType = "http"      // the monitor requests the URL
+	CheckHeartbeat CheckType = "heartbeat" // the service pings the monitor
+	CheckSQL       CheckType = "sql"       // the monitor queries a data source
+	CheckExec      CheckType = "exec"      // the monitor runs a Nagios plugin
+	CheckGRPC      CheckType = "grpc"      // the monitor calls the gRPC health service
+	CheckPostgres  CheckType = "postgres"  // the monitor connects to a Postgres server
+	CheckMySQL     CheckType = "mysql"     // the monitor connects to a MySQL server
+	CheckRedis     CheckType = "redis"     // the monitor PINGs a Redis server
+	CheckSynthetic CheckType = "synthetic" // the monitor runs a scenario of HTTP steps
+)
+
+// SendsHTTP reports whether checks of the type send HTTP requests, and so
+// use the transport settings of the service
+func (t CheckType) SendsHTTP() bool {
+	return t == "" || t == CheckHTTP || t == CheckSynthetic
+}
+
+// ValidateCheck checks that the service has the settings its check type
+// needs. An empty check type is treated as CheckHTTP.
+func ValidateCheck(s *Service) error {
+	switch s.CheckType {
+	case "", CheckHTTP:
+		u, err := url.Parse(s.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url must be an absolute http or https URL")
+		}
+		if s.Transport != nil {
+			if err := s.Transport.Validate(); err != nil {
+				return err
+			}
+		}
+		if s.OAuth2 != nil {
+			return s.OAuth2.Validate()
+		}
+	case CheckHeartbeat:
+		if s.HeartbeatPeriod <= 0 {
+			return fmt.Errorf("heartbeat_period must be a positive number of seconds")
+		}
+		if s.HeartbeatGrace < 0 {
+			return fmt.Errorf("heartbeat_grace cannot be negative")
+		}
+	case CheckSQL:
+		if s.SQL == nil {
+			return fmt.Errorf("sql settings are required for sql checks")
+		}
+		return s.SQL.Validate()
+	case CheckExec:
+		if s.Exec == nil {
+			return fmt.Errorf("exec settings are required for exec checks")
+		}
+		return s.Exec.Validate()
+	case CheckGRPC:
+		if err := validateGRPCURL(s.URL); err != nil {
+			return err
+		}
+		if s.GRPC != nil {
+			return s.GRPC.Validate()
+		}
+	case CheckPostgres, CheckMySQL:
+		if err := validateProtocolURL(s.CheckType, s.URL); err != nil {
+			return err
+		}
+		if s.Database != nil {
+			return s.Database.Validate()
+		}
+	case CheckRedis:
+		if err := validateProtocolURL(s.CheckType, s.URL); err != nil {
+			return err
+		}
+		if s.Redis != nil {
+			return s.Redis.Validate()
+		}
+	case CheckSynthetic:
+		if s.URL != "" {
+			u, err := url.Parse(s.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("url must be an absolute http or https URL, or empty")
+			}
+		}
+		if s.Synthetic == nil {
+			return fmt.Errorf("synthetic settings are required for synthetic checks")
+		}
+		if s.Transport != nil {
+			if err := s.Transport.Validate(); err != nil {
+				return err
+			}
+		}
+		return s.Synthetic.Validate(s.URL)
+	default:
+		return fmt.Errorf("check_type must be one of %s, %s, %s, %s, %s, %s, %s, %s or %s",
+			CheckHTTP, CheckHeartbeat, CheckSQL, CheckExec, CheckGRPC, CheckPostgres, CheckMySQL, CheckRedis, CheckSynthetic)
+	}
+	return nil
+}
+
+// SQLCheck runs a read-only query against a named data source and compares
+// the single value it returns to thresholds: a timestamp must be at most
+// MaxAge seconds old, a number must lie between Min and Max. Data sources
+// and their credentials are configured outside of service definitions.
+type SQLCheck struct {
+	DataSource string   `json:"data_source"`
+	Query      string   `json:"query"`
+	MaxAge     int      `json:"max_age,omitempty"`
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+}
+
+// Clone returns a deep copy of the check
+func (c *SQLCheck) Clone() *SQLCheck {
+	clone := *c
+	if c.Min != nil {
+		v := *c.Min
+		clone.Min = &v
+	}
+	if c.Max != nil {
+		v := *c.Max
+		clone.Max = &v
+	}
+	return &clone
+}
+
+// Validate checks that the query and at least one threshold are set
+func (c *SQLCheck) Validate() error {
+	if c.DataSource == "" {
+		return fmt.Errorf("sql.data_source is required")
+	}
+	if strings.TrimSpace(c.Query) == "" {
+		return fmt.Errorf("sql.query is required")
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("sql.max_age cannot be negative")
+	}
+	if c.MaxAge == 0 && c.Min == nil && c.Max == nil {
+		return fmt.Errorf("sql checks need a max_age, min or max threshold")
+	}
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		return fmt.Errorf("sql.min cannot be greater than sql.max")
+	}
+	return nil
+}
+
+// Evaluate compares the value a query returned to the thresholds and
+// describes the first one it violates
+func (c *SQLCheck) Evaluate(value any, now time.Time) error {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return fmt.Errorf("query returned NULL")
+	case time.Time:
+		return c.evaluateAge(v, now)
+	case int64:
+		return c.evaluateNumber(float64(v))
+	case float64:
+		return c.evaluateNumber(v)
+	case bool:
+		if v {
+			return c.evaluateNumber(1)
+		}
+		return c.evaluateNumber(0)
+	case string:
+		if n, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return c.evaluateNumber(n)
+		}
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return c.evaluateAge(t, now)
+		}
+		return fmt.Errorf("query returned %q, which is neither a number nor a timestamp", v)
+	default:
+		return fmt.Errorf("query returned an unsupported %T value", value)
+	}
+}
+
+func (c *SQLCheck) evaluateAge(t time.Time, now time.Time) error {
+	if c.MaxAge == 0 {
+		return fmt.Errorf("query returned a timestamp but no max_age is set")
+	}
+	maxAge := time.Duration(c.MaxAge) * time.Second
+	if age := now.Sub(t); age > maxAge {
+		return fmt.Errorf("latest value is %s old, more than %s", age.Round(time.Second), maxAge)
+	}
+	return nil
+}
+
+func (c *SQLCheck) evaluateNumber(n float64) error {
+	if c.Min == nil && c.Max == nil {
+		return fmt.Errorf("query returned a number but no min or max is set")
+	}
+	if c.Min != nil && n < *c.Min {
+		return fmt.Errorf("value %g is below the minimum of %g", n, *c.Min)
+	}
+	if c.Max != nil && n > *c.Max {
+		return fmt.Errorf("value %g is above the maximum of %g", n, *c.Max)
+	}
+	return nil
+}
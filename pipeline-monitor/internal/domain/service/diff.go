@@ -0,0 +1,79 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// FieldChange describes how a single field of a service changed
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// runtimeFields are updated by the monitor rather than by people, so they
+// are left out of definition diffs
+var runtimeFields = map[string]bool{
+	"status":        true,
+	"last_check":    true,
+	"response_time": true,
+	"updated_at":    true,
+}
+
+// Diff compares the definitions of two services field by field, using their
+// JSON names. Either side may be nil, e.g. for creates and deletes.
+func Diff(before, after *Service) ([]FieldChange, error) {
+	beforeFields, err := definitionFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := definitionFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for k := range beforeFields {
+		keys[k] = true
+	}
+	for k := range afterFields {
+		keys[k] = true
+	}
+
+	changes := []FieldChange{}
+	for k := range keys {
+		if runtimeFields[k] {
+			continue
+		}
+		if !reflect.DeepEqual(beforeFields[k], afterFields[k]) {
+			changes = append(changes, FieldChange{Field: k, Before: beforeFields[k], After: afterFields[k]})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes, nil
+}
+
+// definitionFields decodes a service into a map keyed by JSON field name
+func definitionFields(svc *Service) (map[string]any, error) {
+	fields := make(map[string]any)
+	if svc == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(svc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal service: %w", err)
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode service fields: %w", err)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultExecTimeout is how long a plugin may run when its check sets
+	// no timeout, in seconds
+	DefaultExecTimeout = 10
+	// MaxExecTimeout keeps a slow plugin from overlapping the next check,
+	// in seconds
+	MaxExecTimeout = 30
+
+	// maxMetrics caps how many perfdata values are kept per check
+	maxMetrics = 50
+)
+
+// ExecCheck runs a Nagios-compatible plugin and derives the status from its
+// exit code. Command names an entry of the allow-list configured by the
+// administrator, so service definitions never contain executable paths.
+type ExecCheck struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Timeout int               `json:"timeout,omitempty"` // seconds
+}
+
+// Clone returns a deep copy of the check
+func (c *ExecCheck) Clone() *ExecCheck {
+	clone := *c
+	if c.Args != nil {
+		clone.Args = append([]string(nil), c.Args...)
+	}
+	if c.Env != nil {
+		clone.Env = make(map[string]string, len(c.Env))
+		for k, v := range c.Env {
+			clone.Env[k] = v
+		}
+	}
+	return &clone
+}
+
+// envName matches the variable names plugins may be given
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnv lists variables that make the dynamic loader, a shell or a
+// script interpreter load or run code of the caller's choosing. Plugins are
+// often shell, Perl or Python scripts, so allowing any of these would let
+// everyone who can edit a check run arbitrary commands on the monitor.
+var reservedEnv = map[string]bool{
+	"PATH": true, "IFS": true, "ENV": true, "BASH_ENV": true, "SHELLOPTS": true,
+	"BASHOPTS": true, "PS4": true, "CDPATH": true, "GLOBIGNORE": true,
+	"PROMPT_COMMAND": true, "GCONV_PATH": true, "LOCPATH": true, "NLSPATH": true,
+	"HOSTALIASES": true, "PERL5LIB": true, "PERL5OPT": true, "PERLLIB": true,
+	"PYTHONPATH": true, "PYTHONHOME": true, "PYTHONSTARTUP": true,
+	"RUBYLIB": true, "RUBYOPT": true, "NODE_OPTIONS": true, "NODE_PATH": true,
+}
+
+// reservedEnvPrefixes lists prefixes of variables reserved for the same
+// reason: the dynamic loader's LD_ and DYLD_ settings and bash's exported
+// functions
+var reservedEnvPrefixes = []string{"LD_", "DYLD_", "BASH_FUNC_"}
+
+// reservedEnvName reports whether plugins may not be given a variable
+func reservedEnvName(name string) bool {
+	if reservedEnv[name] {
+		return true
+	}
+	for _, prefix := range reservedEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that a command is named and the timeout and environment
+// are usable
+func (c *ExecCheck) Validate() error {
+	if c.Command == "" {
+		return fmt.Errorf("exec.command is required")
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("exec.timeout cannot be negative")
+	}
+	if c.Timeout > MaxExecTimeout {
+		return fmt.Errorf("exec.timeout cannot be more than %d seconds", MaxExecTimeout)
+	}
+	for name := range c.Env {
+		if !envName.MatchString(name) {
+			return fmt.Errorf("exec.env: %q is not a valid variable name", name)
+		}
+		if reservedEnvName(name) {
+			return fmt.Errorf("exec.env cannot set %s", name)
+		}
+	}
+	return nil
+}
+
+// TimeoutDuration returns how long the plugin may run
+func (c *ExecCheck) TimeoutDuration() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultExecTimeout * time.Second
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// ExitStatus maps the exit code of a Nagios plugin onto a status: OK is
+// healthy, WARNING is a warning, CRITICAL is unhealthy and UNKNOWN, like any
+// other code, is unknown
+func ExitStatus(code int) Status {
+	switch code {
+	case 0:
+		return StatusHealthy
+	case 1:
+		return StatusWarning
+	case 2:
+		return StatusUnhealthy
+	default:
+		return StatusUnknown
+	}
+}
+
+// Metric is a value reported as performance data by a check. Warn and Crit
+// are Nagios threshold ranges such as "10", "5:" or "@10:20".
+type Metric struct {
+	Label string   `json:"label"`
+	Value float64  `json:"value"`
+	Unit  string   `json:"unit,omitempty"`
+	Warn  string   `json:"warn,omitempty"`
+	Crit  string   `json:"crit,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// ParsePluginOutput splits the output of a Nagios plugin into the text of
+// its first line and the performance data after the "|" of the first line
+// and of the long output, which runs to the end of the output
+func ParsePluginOutput(output string) (string, []Metric) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	text, perfdata, _ := strings.Cut(first, "|")
+
+	if _, longPerfdata, ok := strings.Cut(rest, "|"); ok {
+		perfdata += " " + longPerfdata
+	}
+
+	return strings.TrimSpace(text), ParsePerfdata(perfdata)
+}
+
+// perfValue splits a perfdata value into the number and its unit of measure
+var perfValue = regexp.MustCompile(`^([-+]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][-+]?[0-9]+)?)([a-zA-Z%]*)$`)
+
+// ParsePerfdata parses space separated 'label'=value[UOM];[warn];[crit];[min];[max]
+// entries. Malformed entries and undetermined ("U") values are skipped.
+func ParsePerfdata(perfdata string) []Metric {
+	var metrics []Metric
+	for _, entry := range splitPerfdata(perfdata) {
+		if len(metrics) == maxMetrics {
+			break
+		}
+		if metric, ok := parseMetric(entry); ok {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
+
+// splitPerfdata splits perfdata at whitespace outside of quoted labels
+func splitPerfdata(perfdata string) []string {
+	var entries []string
+	var entry strings.Builder
+	quoted := false
+	for _, r := range perfdata {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			entry.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if entry.Len() > 0 {
+				entries = append(entries, entry.String())
+				entry.Reset()
+			}
+		default:
+			entry.WriteRune(r)
+		}
+	}
+	if entry.Len() > 0 {
+		entries = append(entries, entry.String())
+	}
+	return entries
+}
+
+// parseMetric parses a single perfdata entry
+func parseMetric(entry string) (Metric, bool) {
+	i := strings.LastIndex(entry, "=")
+	if i <= 0 {
+		return Metric{}, false
+	}
+
+	label := entry[:i]
+	if len(label) >= 2 && label[0] == '\'' && label[len(label)-1] == '\'' {
+		label = strings.ReplaceAll(label[1:len(label)-1], "''", "'")
+	}
+	if label == "" {
+		return Metric{}, false
+	}
+
+	fields := strings.Split(entry[i+1:], ";")
+	match := perfValue.FindStringSubmatch(fields[0])
+	if match == nil {
+		return Metric{}, false
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return Metric{}, false
+	}
+
+	metric := Metric{Label: label, Value: value, Unit: match[2]}
+	if len(fields) > 1 {
+		metric.Warn = fields[1]
+	}
+	if len(fields) > 2 {
+		metric.Crit = fields[2]
+	}
+	if len(fields) > 3 {
+		metric.Min = parseBound(fields[3])
+	}
+	if len(fields) > 4 {
+		metric.Max = parseBound(fields[4])
+	}
+	return metric, true
+}
+
+// parseBound parses the optional min or max of a perfdata entry
+func parseBound(field string) *float64 {
+	v, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
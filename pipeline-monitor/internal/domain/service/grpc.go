@@ -0,0 +1,51 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// GRPCCheck configures how the grpc.health.v1.Health service of a grpc://
+// URL is called. Service is the name passed to Check; empty asks about the
+// server as a whole. ClientCert names a client certificate configured by
+// the administrator and requires TLS.
+type GRPCCheck struct {
+	Service    string `json:"service,omitempty"`
+	TLS        bool   `json:"tls,omitempty"`
+	ClientCert string `json:"client_cert,omitempty"`
+}
+
+// Clone returns a copy of the check
+func (c *GRPCCheck) Clone() *GRPCCheck {
+	clone := *c
+	return &clone
+}
+
+// Validate checks that a client certificate is only used over TLS
+func (c *GRPCCheck) Validate() error {
+	if c.ClientCert != "" && !c.TLS {
+		return fmt.Errorf("grpc.client_cert requires grpc.tls")
+	}
+	return nil
+}
+
+// validateGRPCURL checks that a URL is grpc://host:port
+func validateGRPCURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "grpc" || u.Hostname() == "" || u.Port() == "" {
+		return fmt.Errorf("url must be a grpc://host:port URL")
+	}
+	if u.Path != "" && u.Path != "/" {
+		return fmt.Errorf("grpc urls cannot have a path; set grpc.service to check a single service")
+	}
+	return nil
+}
+
+// GRPCTarget returns the host:port of a grpc:// URL
+func GRPCTarget(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment is one step of a JSONPath: an object key, or an array
+// index when key is empty
+type jsonPathSegment struct {
+	key   string
+	index int
+}
+
+// parseJSONPath parses the subset of JSONPath synthetic checks support:
+// $ followed by .key, ['key'] and [index] segments, e.g.
+// $.orders[0]['id']
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("json path %q must start with $", path)
+	}
+
+	var segments []jsonPathSegment
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("json path %q has an empty key", path)
+			}
+			segments = append(segments, jsonPathSegment{key: key})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end < 0 {
+				return nil, fmt.Errorf("json path %q has an unterminated ['key']", path)
+			}
+			segments = append(segments, jsonPathSegment{key: rest[2:end]})
+			rest = rest[end+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path %q has an unterminated [index]", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("json path %q has an invalid index %q", path, rest[1:end])
+			}
+			segments = append(segments, jsonPathSegment{index: index})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("json path %q is invalid at %q", path, rest)
+		}
+	}
+	return segments, nil
+}
+
+// lookupJSONPath returns the value at a JSONPath of a JSON document as a
+// string: strings as they are, numbers as written in the document and
+// anything else as compact JSON
+func lookupJSONPath(body []byte, path string) (string, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("response is not JSON: %w", err)
+	}
+
+	for _, segment := range segments {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[segment.key]
+			if segment.key == "" || !ok {
+				return "", fmt.Errorf("%s not found", path)
+			}
+			value = next
+		case []any:
+			if segment.key != "" || segment.index >= len(v) {
+				return "", fmt.Errorf("%s not found", path)
+			}
+			value = v[segment.index]
+		default:
+			return "", fmt.Errorf("%s not found", path)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// OAuth2Check authenticates the requests of an HTTP check with a bearer
+// token obtained through the OAuth2 client credentials grant. Secret names
+// the stored secret holding the client secret.
+type OAuth2Check struct {
+	TokenURL string   `json:"token_url"`
+	ClientID string   `json:"client_id"`
+	Secret   string   `json:"secret"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// Clone returns a deep copy of the check
+func (c *OAuth2Check) Clone() *OAuth2Check {
+	clone := *c
+	if c.Scopes != nil {
+		clone.Scopes = append([]string(nil), c.Scopes...)
+	}
+	return &clone
+}
+
+// Validate checks that the token URL, client ID and secret are set
+func (c *OAuth2Check) Validate() error {
+	u, err := url.Parse(c.TokenURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("oauth2.token_url must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("oauth2.token_url cannot contain credentials; use oauth2.secret")
+	}
+	if c.ClientID == "" {
+		return fmt.Errorf("oauth2.client_id is required")
+	}
+	if c.Secret == "" {
+		return fmt.Errorf("oauth2.secret is required")
+	}
+	return nil
+}
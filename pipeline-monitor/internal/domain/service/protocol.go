@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// DatabaseCheck configures Postgres and MySQL checks, which connect to the
+// server of a postgres:// or mysql:// URL and run SELECT 1. The password is
+// the value of the secret named by PasswordSecret, so neither the URL nor
+// the check holds credentials. MaxLag, when set, is how many seconds a
+// replica may fall behind its primary; servers that are not replicas pass.
+type DatabaseCheck struct {
+	User           string `json:"user,omitempty"`
+	PasswordSecret string `json:"password_secret,omitempty"`
+	MaxLag         int    `json:"max_lag,omitempty"` // seconds
+}
+
+// Clone returns a copy of the check
+func (c *DatabaseCheck) Clone() *DatabaseCheck {
+	clone := *c
+	return &clone
+}
+
+// Validate checks that the lag threshold is usable and that a password is
+// only given with a user
+func (c *DatabaseCheck) Validate() error {
+	if c.MaxLag < 0 {
+		return fmt.Errorf("database.max_lag cannot be negative")
+	}
+	if c.PasswordSecret != "" && c.User == "" {
+		return fmt.Errorf("database.password_secret requires database.user")
+	}
+	return nil
+}
+
+// EvaluateLag compares the replication lag of a replica, in seconds, to
+// MaxLag
+func (c *DatabaseCheck) EvaluateLag(lag float64) error {
+	if c.MaxLag > 0 && lag > float64(c.MaxLag) {
+		return fmt.Errorf("replication lag is %.0fs, more than %ds", lag, c.MaxLag)
+	}
+	return nil
+}
+
+// RedisCheck configures Redis checks, which PING the server of a redis://
+// or rediss:// URL and read INFO. The password is the value of the secret
+// named by PasswordSecret; Username selects an ACL user. MaxMemory, in
+// megabytes, and MaxClients, when set, cap used_memory and
+// connected_clients.
+type RedisCheck struct {
+	Username       string `json:"username,omitempty"`
+	PasswordSecret string `json:"password_secret,omitempty"`
+	MaxMemory      int    `json:"max_memory,omitempty"` // megabytes
+	MaxClients     int    `json:"max_clients,omitempty"`
+}
+
+// Clone returns a copy of the check
+func (c *RedisCheck) Clone() *RedisCheck {
+	clone := *c
+	return &clone
+}
+
+// Validate checks that the thresholds are usable and that a username is
+// only given with a password
+func (c *RedisCheck) Validate() error {
+	if c.MaxMemory < 0 {
+		return fmt.Errorf("redis.max_memory cannot be negative")
+	}
+	if c.MaxClients < 0 {
+		return fmt.Errorf("redis.max_clients cannot be negative")
+	}
+	if c.Username != "" && c.PasswordSecret == "" {
+		return fmt.Errorf("redis.username requires redis.password_secret")
+	}
+	return nil
+}
+
+// Evaluate compares the used memory, in bytes, and the connected clients
+// of a Redis server to the thresholds and describes the first one it
+// violates
+func (c *RedisCheck) Evaluate(usedMemory int64, clients int) error {
+	if c.MaxMemory > 0 && usedMemory > int64(c.MaxMemory)<<20 {
+		return fmt.Errorf("used memory is %dMB, more than %dMB", usedMemory>>20, c.MaxMemory)
+	}
+	if c.MaxClients > 0 && clients > c.MaxClients {
+		return fmt.Errorf("%d clients are connected, more than %d", clients, c.MaxClients)
+	}
+	return nil
+}
+
+// Secrets returns the names of the secrets the check of the service uses
+func (s *Service) Secrets() []string {
+	var names []string
+	switch s.CheckType {
+	case "", CheckHTTP:
+		if s.OAuth2 != nil && s.OAuth2.Secret != "" {
+			names = append(names, s.OAuth2.Secret)
+		}
+	case CheckPostgres, CheckMySQL:
+		if s.Database != nil && s.Database.PasswordSecret != "" {
+			names = append(names, s.Database.PasswordSecret)
+		}
+	case CheckRedis:
+		if s.Redis != nil && s.Redis.PasswordSecret != "" {
+			names = append(names, s.Redis.PasswordSecret)
+		}
+	case CheckSynthetic:
+		if s.Synthetic != nil {
+			names = append(names, s.Synthetic.Secrets()...)
+		}
+	}
+	return names
+}
+
+// protocolSchemes are the URL schemes each protocol check type accepts
+var protocolSchemes = map[CheckType][]string{
+	CheckPostgres: {"postgres", "postgresql"},
+	CheckMySQL:    {"mysql"},
+	CheckRedis:    {"redis", "rediss"},
+}
+
+// validateProtocolURL checks that the URL of a Postgres, MySQL or Redis
+// check has a scheme of its type and a host, and no credentials
+func validateProtocolURL(checkType CheckType, rawURL string) error {
+	schemes := protocolSchemes[checkType]
+	u, err := url.Parse(rawURL)
+	if err != nil || !containsScheme(schemes, u.Scheme) || u.Hostname() == "" {
+		return fmt.Errorf("url must be a %s:// URL", schemes[0])
+	}
+	if u.User != nil {
+		return fmt.Errorf("url cannot contain credentials; set a user and a password secret instead")
+	}
+	return nil
+}
+
+func containsScheme(schemes []string, scheme string) bool {
+	for _, s := range schemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Resolution is the granularity check history is stored and queried at
+type Resolution string
+
+const (
+	ResolutionRaw    Resolution = "raw"
+	ResolutionMinute Resolution = "1m"
+	ResolutionHour   Resolution = "1h"
+	ResolutionDay    Resolution = "1d"
+)
+
+// Resolutions lists the resolutions from finest to coarsest. Each rollup
+// resolution is aggregated from the one before it.
+var Resolutions = []Resolution{ResolutionRaw, ResolutionMinute, ResolutionHour, ResolutionDay}
+
+// Step returns the bucket width of the resolution, or 0 for raw checks
+func (r Resolution) Step() time.Duration {
+	switch r {
+	case ResolutionMinute:
+		return time.Minute
+	case ResolutionHour:
+		return time.Hour
+	case ResolutionDay:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// Source returns the resolution a rollup resolution is aggregated from
+func (r Resolution) Source() Resolution {
+	for i, res := range Resolutions {
+		if res == r && i > 0 {
+			return Resolutions[i-1]
+		}
+	}
+	return ResolutionRaw
+}
+
+// BucketStart returns the start of the bucket containing t. Buckets are
+// aligned to UTC, so daily buckets start at midnight UTC.
+func (r Resolution) BucketStart(t time.Time) time.Time {
+	if step := r.Step(); step > 0 {
+		return t.UTC().Truncate(step)
+	}
+	return t
+}
+
+// maxSeriesBuckets bounds the number of buckets a range query returns, so
+// long ranges are answered from coarser rollups
+const maxSeriesBuckets = 1500
+
+// maxRawSpan is the longest range answered from raw checks
+const maxRawSpan = 6 * time.Hour
+
+// Retention is how long history is kept at each resolution. A zero
+// duration keeps it forever.
+type Retention struct {
+	Raw    time.Duration
+	Minute time.Duration
+	Hour   time.Duration
+	Day    time.Duration
+}
+
+// For returns the retention period of a resolution
+func (r Retention) For(res Resolution) time.Duration {
+	switch res {
+	case ResolutionMinute:
+		return r.Minute
+	case ResolutionHour:
+		return r.Hour
+	case ResolutionDay:
+		return r.Day
+	}
+	return r.Raw
+}
+
+// Resolution picks the finest resolution that still holds data from since
+// and answers the range with a bounded number of buckets
+func (r Retention) Resolution(since, now time.Time) Resolution {
+	span := now.Sub(since)
+	for _, res := range Resolutions {
+		if keep := r.For(res); keep > 0 && since.Before(now.Add(-keep)) {
+			continue
+		}
+		if res == ResolutionRaw {
+			if span <= maxRawSpan {
+				return res
+			}
+			continue
+		}
+		if span/res.Step() <= maxSeriesBuckets {
+			return res
+		}
+	}
+	return ResolutionDay
+}
+
+// Rollup aggregates the checks of one service within one bucket
+type Rollup struct {
+	ServiceID  string     `json:"service_id"`
+	Resolution Resolution `json:"resolution"`
+	Start      time.Time  `json:"start"`
+	Count      int        `json:"count"`
+	Failures   int        `json:"failures"`
+	Min        int        `json:"min"`
+	Max        int        `json:"max"`
+	Sum        int64      `json:"sum"`
+	Sketch     Sketch     `json:"sketch"`
+}
+
+// Add counts a single check into the rollup
+func (r *Rollup) Add(check *HealthCheck) {
+	if r.Count == 0 || check.ResponseTime < r.Min {
+		r.Min = check.ResponseTime
+	}
+	if check.ResponseTime > r.Max {
+		r.Max = check.ResponseTime
+	}
+	r.Count++
+	if !check.Status.IsUp() {
+		r.Failures++
+	}
+	r.Sum += int64(check.ResponseTime)
+
+	if r.Sketch == nil {
+		r.Sketch = make(Sketch)
+	}
+	r.Sketch.Add(check.ResponseTime)
+}
+
+// Merge adds another rollup of the same service into this one
+func (r *Rollup) Merge(other *Rollup) {
+	if other.Count == 0 {
+		return
+	}
+	if r.Count == 0 || other.Min < r.Min {
+		r.Min = other.Min
+	}
+	if other.Max > r.Max {
+		r.Max = other.Max
+	}
+	r.Count += other.Count
+	r.Failures += other.Failures
+	r.Sum += other.Sum
+
+	if r.Sketch == nil {
+		r.Sketch = make(Sketch)
+	}
+	r.Sketch.Merge(other.Sketch)
+}
+
+// Avg returns the mean response time, or 0 if there were no checks
+func (r *Rollup) Avg() int {
+	if r.Count == 0 {
+		return 0
+	}
+	return int(r.Sum / int64(r.Count))
+}
+
+// Uptime returns the checks and healthy checks of the rollup
+func (r *Rollup) Uptime() Uptime {
+	return Uptime{Checks: r.Count, Healthy: r.Count - r.Failures}
+}
+
+// Stats summarises the response times of the rollup. Percentiles are
+// estimated from the sketch.
+func (r *Rollup) Stats() ResponseStats {
+	if r.Count == 0 {
+		return ResponseStats{}
+	}
+	return ResponseStats{
+		Count: r.Count,
+		Min:   r.Min,
+		Max:   r.Max,
+		Avg:   r.Avg(),
+		P50:   r.quantile(0.50),
+		P95:   r.quantile(0.95),
+		P99:   r.quantile(0.99),
+	}
+}
+
+// quantile estimates a quantile, clamped to the exact min and max
+func (r *Rollup) quantile(q float64) int {
+	v := r.Sketch.Quantile(q)
+	if v < r.Min {
+		return r.Min
+	}
+	if v > r.Max {
+		return r.Max
+	}
+	return v
+}
+
+// RollupChecks aggregates checks into buckets of the given resolution
+func RollupChecks(checks []HealthCheck, res Resolution) []Rollup {
+	buckets := make(map[rollupKey]*Rollup)
+	var order []rollupKey
+
+	for i := range checks {
+		key := rollupKey{checks[i].ServiceID, res.BucketStart(checks[i].Timestamp)}
+		r, ok := buckets[key]
+		if !ok {
+			r = &Rollup{ServiceID: key.serviceID, Resolution: res, Start: key.start}
+			buckets[key] = r
+			order = append(order, key)
+		}
+		r.Add(&checks[i])
+	}
+
+	return collectRollups(buckets, order)
+}
+
+// MergeRollups aggregates finer rollups into buckets of the given resolution
+func MergeRollups(rollups []Rollup, res Resolution) []Rollup {
+	buckets := make(map[rollupKey]*Rollup)
+	var order []rollupKey
+
+	for i := range rollups {
+		key := rollupKey{rollups[i].ServiceID, res.BucketStart(rollups[i].Start)}
+		r, ok := buckets[key]
+		if !ok {
+			r = &Rollup{ServiceID: key.serviceID, Resolution: res, Start: key.start}
+			buckets[key] = r
+			order = append(order, key)
+		}
+		r.Merge(&rollups[i])
+	}
+
+	return collectRollups(buckets, order)
+}
+
+type rollupKey struct {
+	serviceID string
+	start     time.Time
+}
+
+func collectRollups(buckets map[rollupKey]*Rollup, order []rollupKey) []Rollup {
+	rollups := make([]Rollup, 0, len(order))
+	for _, key := range order {
+		rollups = append(rollups, *buckets[key])
+	}
+	return rollups
+}
+
+// sketchGamma sets the relative accuracy of Sketch quantiles to about 1%
+var sketchGamma = (1 + 0.01) / (1 - 0.01)
+
+// Sketch is a mergeable histogram of response times with logarithmically
+// sized bins, so quantile estimates are within about 1% of the true value
+// however the data is distributed. Keys are bin indexes; -1 counts zeros.
+type Sketch map[int]int
+
+// Add counts a response time into the sketch
+func (s Sketch) Add(ms int) {
+	s[sketchIndex(ms)]++
+}
+
+// Merge adds the counts of another sketch
+func (s Sketch) Merge(other Sketch) {
+	for index, count := range other {
+		s[index] += count
+	}
+}
+
+// Quantile estimates the q-th quantile (0 to 1), or 0 if the sketch is empty
+func (s Sketch) Quantile(q float64) int {
+	total := 0
+	indexes := make([]int, 0, len(s))
+	for index, count := range s {
+		total += count
+		indexes = append(indexes, index)
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Ints(indexes)
+
+	rank := int(math.Ceil(q * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	seen := 0
+	for _, index := range indexes {
+		seen += s[index]
+		if seen >= rank {
+			return sketchValue(index)
+		}
+	}
+	return sketchValue(indexes[len(indexes)-1])
+}
+
+func sketchIndex(ms int) int {
+	if ms <= 0 {
+		return -1
+	}
+	return int(math.Ceil(math.Log(float64(ms)) / math.Log(sketchGamma)))
+}
+
+func sketchValue(index int) int {
+	if index < 0 {
+		return 0
+	}
+	return int(math.Round(2 * math.Pow(sketchGamma, float64(index)) / (sketchGamma + 1)))
+}
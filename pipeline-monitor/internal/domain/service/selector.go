@@ -0,0 +1,244 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Operator is the comparison made by a label selector requirement
+type Operator string
+
+const (
+	OpEquals       Operator = "="
+	OpNotEquals    Operator = "!="
+	OpIn           Operator = "in"
+	OpNotIn        Operator = "notin"
+	OpExists       Operator = "exists"
+	OpDoesNotExist Operator = "!"
+)
+
+// Requirement is a single clause of a label selector, e.g. "tier in (1,2)"
+type Requirement struct {
+	Key      string   `json:"key"`
+	Operator Operator `json:"operator"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// Selector matches services whose labels satisfy every requirement.
+// An empty selector matches everything.
+type Selector []Requirement
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+	setPattern        = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+// ParseSelector parses a Kubernetes-style label selector such as
+// "env=prod,tier in (1,2),!deprecated". Supported forms are key=value,
+// key==value, key!=value, key in (a,b), key notin (a,b), key and !key.
+func ParseSelector(input string) (Selector, error) {
+	var selector Selector
+
+	for _, clause := range splitTopLevel(input) {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+
+		req, err := parseRequirement(clause)
+		if err != nil {
+			return nil, err
+		}
+		selector = append(selector, req)
+	}
+
+	return selector, nil
+}
+
+// splitTopLevel splits on commas that are not inside parentheses
+func splitTopLevel(input string) []string {
+	var parts []string
+	depth, start := 0, 0
+
+	for i, r := range input {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, input[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, input[start:])
+}
+
+func parseRequirement(clause string) (Requirement, error) {
+	if m := setPattern.FindStringSubmatch(clause); m != nil {
+		req := Requirement{Key: m[1], Operator: Operator(m[2])}
+		for _, value := range strings.Split(m[3], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				req.Values = append(req.Values, value)
+			}
+		}
+		return req, req.validate()
+	}
+
+	for _, op := range []string{"!=", "==", "="} {
+		if key, value, found := strings.Cut(clause, op); found {
+			operator := OpEquals
+			if op == "!=" {
+				operator = OpNotEquals
+			}
+			req := Requirement{
+				Key:      strings.TrimSpace(key),
+				Operator: operator,
+				Values:   []string{strings.TrimSpace(value)},
+			}
+			return req, req.validate()
+		}
+	}
+
+	if key, found := strings.CutPrefix(clause, "!"); found {
+		req := Requirement{Key: strings.TrimSpace(key), Operator: OpDoesNotExist}
+		return req, req.validate()
+	}
+
+	req := Requirement{Key: clause, Operator: OpExists}
+	return req, req.validate()
+}
+
+func (r Requirement) validate() error {
+	if !labelKeyPattern.MatchString(r.Key) {
+		return fmt.Errorf("invalid label key %q", r.Key)
+	}
+	for _, value := range r.Values {
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("invalid value %q for label %q", value, r.Key)
+		}
+	}
+	if (r.Operator == OpIn || r.Operator == OpNotIn) && len(r.Values) == 0 {
+		return fmt.Errorf("label %q needs at least one value", r.Key)
+	}
+	return nil
+}
+
+// Matches reports whether a set of labels satisfies the requirement
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+
+	switch r.Operator {
+	case OpEquals:
+		return ok && value == r.Values[0]
+	case OpNotEquals:
+		return !ok || value != r.Values[0]
+	case OpIn:
+		return ok && contains(r.Values, value)
+	case OpNotIn:
+		return !ok || !contains(r.Values, value)
+	case OpExists:
+		return ok
+	case OpDoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+// Matches reports whether a set of labels satisfies every requirement
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the selector in the syntax accepted by ParseSelector
+func (s Selector) String() string {
+	clauses := make([]string, len(s))
+	for i, req := range s {
+		switch req.Operator {
+		case OpIn, OpNotIn:
+			clauses[i] = fmt.Sprintf("%s %s (%s)", req.Key, req.Operator, strings.Join(req.Values, ","))
+		case OpExists:
+			clauses[i] = req.Key
+		case OpDoesNotExist:
+			clauses[i] = "!" + req.Key
+		default:
+			clauses[i] = req.Key + string(req.Operator) + req.Values[0]
+		}
+	}
+	return strings.Join(clauses, ",")
+}
+
+// ParseLabels parses a comma-separated list of key=value pairs, as entered
+// in the service form
+func ParseLabels(input string) (map[string]string, error) {
+	labels := make(map[string]string)
+
+	for _, pair := range strings.Split(input, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found {
+			return nil, fmt.Errorf("label %q must be written as key=value", pair)
+		}
+		if err := ValidateLabel(key, value); err != nil {
+			return nil, err
+		}
+		labels[key] = value
+	}
+
+	return labels, nil
+}
+
+// ValidateLabel checks that a label key and value use the allowed characters
+func ValidateLabel(key, value string) error {
+	return Requirement{Key: key, Operator: OpEquals, Values: []string{value}}.validate()
+}
+
+// ValidateLabels checks every label of a service
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if err := ValidateLabel(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatLabels formats labels as sorted, comma-separated key=value pairs
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + labels[key]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
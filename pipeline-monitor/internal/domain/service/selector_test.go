@@ -0,0 +1,86 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Selector
+	}{
+		{input: "", want: nil},
+		{input: "env=prod", want: Selector{{Key: "env", Operator: OpEquals, Values: []string{"prod"}}}},
+		{input: "env==prod", want: Selector{{Key: "env", Operator: OpEquals, Values: []string{"prod"}}}},
+		{input: "env != prod", want: Selector{{Key: "env", Operator: OpNotEquals, Values: []string{"prod"}}}},
+		{input: "tier in (1, 2)", want: Selector{{Key: "tier", Operator: OpIn, Values: []string{"1", "2"}}}},
+		{input: "tier notin (3)", want: Selector{{Key: "tier", Operator: OpNotIn, Values: []string{"3"}}}},
+		{input: "team.io/owner", want: Selector{{Key: "team.io/owner", Operator: OpExists}}},
+		{input: "!deprecated", want: Selector{{Key: "deprecated", Operator: OpDoesNotExist}}},
+		{input: "env=", want: Selector{{Key: "env", Operator: OpEquals, Values: []string{""}}}},
+		{
+			input: "env=prod,tier in (1,2),!deprecated",
+			want: Selector{
+				{Key: "env", Operator: OpEquals, Values: []string{"prod"}},
+				{Key: "tier", Operator: OpIn, Values: []string{"1", "2"}},
+				{Key: "deprecated", Operator: OpDoesNotExist},
+			},
+		},
+		{input: " , env=prod, ", want: Selector{{Key: "env", Operator: OpEquals, Values: []string{"prod"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSelector(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSelector(%q): %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSelector(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+
+			// The formatted selector parses back to the same requirements
+			again, err := ParseSelector(got.String())
+			if err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("ParseSelector(%q) = %#v, %v, want %#v", got.String(), again, err, got)
+			}
+		})
+	}
+}
+
+func TestParseSelectorRejectsMalformed(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{input: "=prod", err: "invalid label key"},
+		{input: "!", err: "invalid label key"},
+		{input: "env=prod=eu", err: `invalid value "prod=eu"`},
+		{input: "env=prod west", err: `invalid value "prod west"`},
+		{input: "env=-prod", err: `invalid value "-prod"`},
+		{input: "-env=prod", err: "invalid label key"},
+		{input: "env/=prod", err: "invalid label key"},
+		{input: "tier in ()", err: "needs at least one value"},
+		{input: "tier notin ( , )", err: "needs at least one value"},
+		{input: "tier in (1,2", err: "invalid label key"},
+		{input: "tier in ((1))", err: `invalid value "(1)"`},
+		{input: "tier in (a b)", err: `invalid value "a b"`},
+		{input: "tier)", err: "invalid label key"},
+		{input: "env=prod,!", err: "invalid label key"},
+		{input: "env=prod;drop", err: "invalid value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			selector, err := ParseSelector(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("ParseSelector(%q) = %v, %v, want an error containing %q", tt.input, selector, err, tt.err)
+			}
+			if selector != nil {
+				t.Errorf("ParseSelector(%q) returned %v with its error", tt.input, selector)
+			}
+		})
+	}
+}
@@ -7,16 +7,117 @@ import (
 
 // Service represents a monitored service in our pipeline
 type Service struct {
-	ID           string    `json:"id" db:"id"`
-	Name         string    `json:"name" db:"name"`
-	URL          string    `json:"url" db:"url"`
-	Status       Status    `json:"status" db:"status"`
-	LastCheck    time.Time `json:"last_check" db:"last_check"`
-	ResponseTime int       `json:"response_time" db:"response_time"` // milliseconds
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
-	Description  string    `json:"description" db:"description"`
-	Tags         []string  `json:"tags" db:"tags"`
+	ID           string            `json:"id" db:"id"`
+	Name         string            `json:"name" db:"name"`
+	URL          string            `json:"url" db:"url"`
+	Status       Status            `json:"status" db:"status"`
+	LastCheck    time.Time         `json:"last_check" db:"last_check"`
+	ResponseTime int               `json:"response_time" db:"response_time"` // milliseconds
+	CreatedAt    time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at" db:"updated_at"`
+	Description  string            `json:"description" db:"description"`
+	Tags         []string          `json:"tags" db:"tags"`
+	Labels       map[string]string `json:"labels" db:"labels"`
+	TeamID       string            `json:"team_id" db:"team_id"`
+
+	// CheckType picks how the service is monitored. Heartbeat services are
+	// expected to ping their ping URL every HeartbeatPeriod seconds, with
+	// HeartbeatGrace seconds of slack, and do not need a URL.
+	CheckType       CheckType `json:"check_type" db:"check_type"`
+	HeartbeatPeriod int       `json:"heartbeat_period,omitempty" db:"heartbeat_period"`
+	HeartbeatGrace  int       `json:"heartbeat_grace,omitempty" db:"heartbeat_grace"`
+
+	// OAuth2 authenticates HTTP checks with a client credentials token
+	OAuth2 *OAuth2Check `json:"oauth2,omitempty" db:"oauth2_check"`
+
+	// Transport configures TLS and proxying of HTTP checks
+	Transport *Transport `json:"transport,omitempty" db:"transport"`
+
+	// SQL configures SQL checks
+	SQL *SQLCheck `json:"sql,omitempty" db:"sql_check"`
+
+	// Exec configures exec checks
+	Exec *ExecCheck `json:"exec,omitempty" db:"exec_check"`
+
+	// GRPC configures gRPC health checks of grpc:// URLs
+	GRPC *GRPCCheck `json:"grpc,omitempty" db:"grpc_check"`
+
+	// Database configures Postgres and MySQL checks, Redis configures
+	// Redis checks
+	Database *DatabaseCheck `json:"database,omitempty" db:"database_check"`
+	Redis    *RedisCheck    `json:"redis,omitempty" db:"redis_check"`
+
+	// Synthetic configures multi-step synthetic checks
+	Synthetic *SyntheticCheck `json:"synthetic,omitempty" db:"synthetic_check"`
+
+	// PingToken identifies a heartbeat service in its ping URL
+	PingToken string `json:"ping_token,omitempty" db:"ping_token"`
+
+	// BadgeToken, when set, must be passed to the badge endpoints so that
+	// badges of private services cannot be fetched by ID alone
+	BadgeToken string `json:"badge_token,omitempty" db:"badge_token"`
+}
+
+// Clone returns a deep copy of the service
+func (s *Service) Clone() *Service {
+	clone := *s
+	if s.Tags != nil {
+		clone.Tags = append([]string(nil), s.Tags...)
+	}
+	if s.Labels != nil {
+		clone.Labels = make(map[string]string, len(s.Labels))
+		for k, v := range s.Labels {
+			clone.Labels[k] = v
+		}
+	}
+	if s.OAuth2 != nil {
+		clone.OAuth2 = s.OAuth2.Clone()
+	}
+	if s.Transport != nil {
+		clone.Transport = s.Transport.Clone()
+	}
+	if s.SQL != nil {
+		clone.SQL = s.SQL.Clone()
+	}
+	if s.Exec != nil {
+		clone.Exec = s.Exec.Clone()
+	}
+	if s.GRPC != nil {
+		clone.GRPC = s.GRPC.Clone()
+	}
+	if s.Database != nil {
+		clone.Database = s.Database.Clone()
+	}
+	if s.Redis != nil {
+		clone.Redis = s.Redis.Clone()
+	}
+	if s.Synthetic != nil {
+		clone.Synthetic = s.Synthetic.Clone()
+	}
+	return &clone
+}
+
+// ApplyDefinition copies the user-editable definition of another service
+// (URL, tags, check settings, ...) onto this one, leaving identity and
+// runtime state untouched
+func (s *Service) ApplyDefinition(def *Service) {
+	def = def.Clone()
+	s.Name = def.Name
+	s.URL = def.URL
+	s.Description = def.Description
+	s.Tags = def.Tags
+	s.Labels = def.Labels
+	s.CheckType = def.CheckType
+	s.HeartbeatPeriod = def.HeartbeatPeriod
+	s.HeartbeatGrace = def.HeartbeatGrace
+	s.OAuth2 = def.OAuth2
+	s.Transport = def.Transport
+	s.SQL = def.SQL
+	s.Exec = def.Exec
+	s.GRPC = def.GRPC
+	s.Database = def.Database
+	s.Redis = def.Redis
+	s.Synthetic = def.Synthetic
 }
 
 // Status represents the health status of a service
@@ -27,6 +128,10 @@ const (
 	StatusUnhealthy Status = "unhealthy"
 	StatusUnknown   Status = "unknown"
 	StatusTimeout   Status = "timeout"
+
+	// StatusWarning means the service answers but needs attention soon,
+	// such as a certificate close to expiry. It counts as up.
+	StatusWarning Status = "warning"
 )
 
 // String returns the string representation of status
@@ -39,15 +144,85 @@ func (s Status) IsHealthy() bool {
 	return s == StatusHealthy
 }
 
+// IsUp returns true if the service is serving, including with a warning
+func (s Status) IsUp() bool {
+	return s == StatusHealthy || s == StatusWarning
+}
+
 // Repository defines what our service layer needs from the data layer
 // This is Go's way of dependency inversion - interfaces are defined by consumers
 type Repository interface {
 	GetAll(ctx context.Context) ([]Service, error)
+	Find(ctx context.Context, query Query) (*Page, error)
+	LabelCardinality(ctx context.Context) ([]LabelStats, error)
 	GetByID(ctx context.Context, id string) (*Service, error)
 	Create(ctx context.Context, service *Service) error
 	Update(ctx context.Context, service *Service) error
 	Delete(ctx context.Context, id string) error
 	UpdateStatus(ctx context.Context, id string, status Status, responseTime int) error
+	SetBadgeToken(ctx context.Context, id string, token string) error
+	// GetByPingToken returns the heartbeat service with the given ping token
+	GetByPingToken(ctx context.Context, token string) (*Service, error)
+}
+
+// SortField is a column services can be ordered by
+type SortField string
+
+const (
+	SortByName         SortField = "name"
+	SortByStatus       SortField = "status"
+	SortByResponseTime SortField = "response_time"
+	SortByLastCheck    SortField = "last_check"
+)
+
+// TagMatch controls how multiple tag filters are combined
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any" // services with at least one of the tags
+	TagMatchAll TagMatch = "all" // services with every one of the tags
+)
+
+// Query describes a filtered, sorted page of services
+type Query struct {
+	Search   string    // full-text search on name, description and URL
+	Tags     []string  // tag filter, combined according to TagMatch
+	TagMatch TagMatch  // defaults to TagMatchAny
+	Statuses []Status  // only services in one of these statuses
+	Labels   Selector  // label selector, e.g. env=prod,tier in (1,2)
+	Sort     SortField // defaults to SortByName
+	Desc     bool
+	Cursor   string // opaque cursor from a previous Page.NextCursor
+	Limit    int    // page size, defaults to DefaultPageSize
+}
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Page is a single page of query results
+type Page struct {
+	Services   []Service `json:"services"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// LabelStats summarises how a label key is used across services
+type LabelStats struct {
+	Key      string       `json:"key"`
+	Services int          `json:"services"` // services carrying the key
+	Values   []LabelValue `json:"values"`   // distinct values, most used first
+}
+
+// LabelValue is one value of a label key and how many services use it
+type LabelValue struct {
+	Value    string `json:"value"`
+	Services int    `json:"services"`
+}
+
+// Cardinality returns the number of distinct values of the label key
+func (l LabelStats) Cardinality() int {
+	return len(l.Values)
 }
 
 // HealthCheck represents a single health check result
@@ -57,6 +232,122 @@ type HealthCheck struct {
 	ResponseTime int       `json:"response_time"`
 	Timestamp    time.Time `json:"timestamp"`
 	Error        string    `json:"error,omitempty"`
+	Timings      *Timings  `json:"timings,omitempty"`
+	Metrics      []Metric  `json:"metrics,omitempty"`
+}
+
+// Timings breaks the response time of an HTTP check down into the phases
+// of the request, in milliseconds. Phases the request did not get to are 0.
+type Timings struct {
+	DNS      float64 `json:"dns"`
+	Connect  float64 `json:"connect"`
+	TLS      float64 `json:"tls"`
+	TTFB     float64 `json:"ttfb"`     // from sending the request to the first response byte
+	Transfer float64 `json:"transfer"` // reading the response body
+	// Reused is set when the request went over a kept-alive connection,
+	// so it had no DNS, connect or TLS phases
+	Reused bool `json:"reused,omitempty"`
+}
+
+// Total returns the sum of all phases
+func (t *Timings) Total() float64 {
+	return t.DNS + t.Connect + t.TLS + t.TTFB + t.Transfer
+}
+
+// CheckRepository stores the history of health check results
+type CheckRepository interface {
+	Record(ctx context.Context, check *HealthCheck) error
+	DailyUptime(ctx context.Context, serviceIDs []string, since time.Time) (map[string][]DailyUptime, error)
+	Uptime(ctx context.Context, serviceID string, since time.Time) (Uptime, error)
+	// History returns the checks of a service since the given time, oldest first
+	History(ctx context.Context, serviceID string, since time.Time) ([]HealthCheck, error)
+	// Series returns the history of a service since the given time as
+	// buckets, oldest first, at a resolution picked for the range
+	Series(ctx context.Context, serviceID string, since time.Time) (Resolution, []Rollup, error)
+
+	// LatestRollup returns the start of the newest bucket at a resolution,
+	// or the zero time if nothing was rolled up yet
+	LatestRollup(ctx context.Context, res Resolution) (time.Time, error)
+	// RollUp (re)computes the buckets at a resolution from its source
+	// resolution, starting with the bucket containing from
+	RollUp(ctx context.Context, res Resolution, from time.Time) (int, error)
+	// DeleteBefore removes history at a resolution older than the cutoff
+	DeleteBefore(ctx context.Context, res Resolution, cutoff time.Time) (int64, error)
+	// Transitions returns status changes since the given time, newest first
+	Transitions(ctx context.Context, serviceIDs []string, since time.Time, limit int) ([]Transition, error)
+}
+
+// Transition is a change of a service's status between two checks
+type Transition struct {
+	ServiceID   string    `json:"service_id"`
+	ServiceName string    `json:"service_name"`
+	From        Status    `json:"from"`
+	To          Status    `json:"to"`
+	At          time.Time `json:"at"`
+	Error       string    `json:"error,omitempty"`
+
+	// Since is when the service entered the From status, if known
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// Duration returns how long the service stayed in the From status, or 0
+// if that is unknown
+func (t *Transition) Duration() time.Duration {
+	if t.Since == nil {
+		return 0
+	}
+	return t.At.Sub(*t.Since)
+}
+
+// Uptime counts checks and healthy checks over some period. Checks with a
+// warning count as healthy.
+type Uptime struct {
+	Checks  int `json:"checks"`
+	Healthy int `json:"healthy"`
+}
+
+// Percent returns the share of healthy checks, or 0 if there were none
+func (u Uptime) Percent() float64 {
+	if u.Checks == 0 {
+		return 0
+	}
+	return float64(u.Healthy) / float64(u.Checks) * 100
+}
+
+// DailyUptime summarises the checks of one service on one (UTC) day
+type DailyUptime struct {
+	Day time.Time `json:"day"`
+	Uptime
+}
+
+// UptimeDays returns one entry per day from since until today (UTC),
+// filling days without checks so the result always has the full length
+func UptimeDays(days []DailyUptime, since time.Time, now time.Time) []DailyUptime {
+	byDay := make(map[string]DailyUptime, len(days))
+	for _, d := range days {
+		byDay[d.Day.UTC().Format("2006-01-02")] = d
+	}
+
+	var filled []DailyUptime
+	end := now.UTC().Truncate(24 * time.Hour)
+	for day := since.UTC().Truncate(24 * time.Hour); !day.After(end); day = day.AddDate(0, 0, 1) {
+		d, ok := byDay[day.Format("2006-01-02")]
+		if !ok {
+			d = DailyUptime{Day: day}
+		}
+		filled = append(filled, d)
+	}
+	return filled
+}
+
+// TotalUptime adds up the checks of all days
+func TotalUptime(days []DailyUptime) Uptime {
+	var total Uptime
+	for _, d := range days {
+		total.Checks += d.Checks
+		total.Healthy += d.Healthy
+	}
+	return total
 }
 
 // ServiceManager defines the interface for managing services
@@ -67,3 +358,15 @@ type ServiceManager interface {
 	RemoveService(ctx context.Context, id string) error
 	GetHealthStatus(ctx context.Context, id string) (*HealthCheck, error)
 }
+
+// ResponseStats summarises the response times of a set of checks, in
+// milliseconds
+type ResponseStats struct {
+	Count int `json:"count"`
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Avg   int `json:"avg"`
+	P50   int `json:"p50"`
+	P95   int `json:"p95"`
+	P99   int `json:"p99"`
+}
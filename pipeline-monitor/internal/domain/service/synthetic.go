@@ -0,0 +1,329 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSyntheticTimeout is how long all steps of a synthetic check
+	// may take together when the check sets no timeout, in seconds
+	DefaultSyntheticTimeout = 20
+	// MaxSyntheticTimeout keeps a slow scenario from overlapping the next
+	// check, in seconds
+	MaxSyntheticTimeout = 30
+
+	// maxSyntheticSteps caps how many steps a scenario can have
+	maxSyntheticSteps = 20
+)
+
+// SyntheticCheck runs a scenario of HTTP steps in order, sharing a cookie
+// jar, and fails at the first step whose request fails or whose
+// expectations are not met. Relative step URLs are resolved against the
+// service URL.
+type SyntheticCheck struct {
+	Steps   []SyntheticStep `json:"steps" yaml:"steps"`
+	Timeout int             `json:"timeout,omitempty" yaml:"timeout,omitempty"` // seconds
+}
+
+// SyntheticStep is a single request of a scenario. Its URL, header values
+// and body may use {{name}} to insert a variable extracted by an earlier
+// step, and {{secret.name}} to insert a stored secret. Values are inserted
+// as they are, without escaping.
+type SyntheticStep struct {
+	Name    string            `json:"name" yaml:"name"`
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"` // GET when empty
+	URL     string            `json:"url" yaml:"url"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`
+	Extract []Extraction      `json:"extract,omitempty" yaml:"extract,omitempty"`
+	Expect  StepExpectation   `json:"expect,omitempty" yaml:"expect,omitempty"`
+}
+
+// Extraction stores part of a step's response body in a variable: the
+// value at a JSONPath, or the first group of a regular expression, or its
+// whole match if it has no group
+type Extraction struct {
+	Var      string `json:"var" yaml:"var"`
+	JSONPath string `json:"json_path,omitempty" yaml:"json_path,omitempty"`
+	Regex    string `json:"regex,omitempty" yaml:"regex,omitempty"`
+}
+
+// StepExpectation is what a step's response must satisfy. Without a status
+// any 2xx or 3xx status passes, as for HTTP checks. JSON maps JSONPaths to
+// the values they must have, and MaxTime is in milliseconds.
+type StepExpectation struct {
+	Status   int               `json:"status,omitempty" yaml:"status,omitempty"`
+	Contains string            `json:"contains,omitempty" yaml:"contains,omitempty"`
+	JSON     map[string]string `json:"json,omitempty" yaml:"json,omitempty"`
+	MaxTime  int               `json:"max_time,omitempty" yaml:"max_time,omitempty"`
+}
+
+// Clone returns a deep copy of the check
+func (c *SyntheticCheck) Clone() *SyntheticCheck {
+	clone := *c
+	if c.Steps != nil {
+		clone.Steps = make([]SyntheticStep, len(c.Steps))
+		for i, step := range c.Steps {
+			clone.Steps[i] = step.clone()
+		}
+	}
+	return &clone
+}
+
+func (s SyntheticStep) clone() SyntheticStep {
+	if s.Headers != nil {
+		headers := make(map[string]string, len(s.Headers))
+		for k, v := range s.Headers {
+			headers[k] = v
+		}
+		s.Headers = headers
+	}
+	if s.Extract != nil {
+		s.Extract = append([]Extraction(nil), s.Extract...)
+	}
+	if s.Expect.JSON != nil {
+		expected := make(map[string]string, len(s.Expect.JSON))
+		for k, v := range s.Expect.JSON {
+			expected[k] = v
+		}
+		s.Expect.JSON = expected
+	}
+	return s
+}
+
+var (
+	// stepName matches the names of steps, which label their timings
+	stepName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	// varName matches the names of extracted variables
+	varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// placeholder matches {{name}} and {{secret.name}}
+	placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+	stepMethods = map[string]bool{
+		"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true,
+	}
+)
+
+// Validate checks the steps of the scenario: that their URLs resolve
+// against the base URL to http or https URLs, that extractions and
+// expectations are well-formed, and that steps only use variables earlier
+// steps extract
+func (c *SyntheticCheck) Validate(baseURL string) error {
+	if len(c.Steps) == 0 {
+		return fmt.Errorf("synthetic checks need at least one step")
+	}
+	if len(c.Steps) > maxSyntheticSteps {
+		return fmt.Errorf("synthetic checks can have at most %d steps", maxSyntheticSteps)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("synthetic.timeout cannot be negative")
+	}
+	if c.Timeout > MaxSyntheticTimeout {
+		return fmt.Errorf("synthetic.timeout cannot be more than %d seconds", MaxSyntheticTimeout)
+	}
+
+	names := make(map[string]bool)
+	vars := make(map[string]bool)
+	for i, step := range c.Steps {
+		if !stepName.MatchString(step.Name) {
+			return fmt.Errorf("step %d: name must be 1 to 64 letters, digits, _ or -", i+1)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("step %d: name %q is used by an earlier step", i+1, step.Name)
+		}
+		names[step.Name] = true
+
+		if err := step.validate(baseURL, vars); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i+1, step.Name, err)
+		}
+		for _, e := range step.Extract {
+			vars[e.Var] = true
+		}
+	}
+	return nil
+}
+
+// validate checks a step, given the variables extracted by earlier steps
+func (s *SyntheticStep) validate(baseURL string, vars map[string]bool) error {
+	if s.Method != "" && !stepMethods[s.Method] {
+		return fmt.Errorf("method %q is not supported", s.Method)
+	}
+
+	// Placeholders may stand for any part of the URL, so check its shape
+	// with them filled in
+	filled := placeholder.ReplaceAllString(s.URL, "x")
+	u, err := ResolveStepURL(baseURL, filled)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https URL, or a path relative to the service URL")
+	}
+
+	texts := []string{s.URL, s.Body}
+	for name, value := range s.Headers {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("header names cannot be empty")
+		}
+		texts = append(texts, value)
+	}
+	for _, text := range texts {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			name := match[1]
+			if strings.HasPrefix(name, "secret.") {
+				continue
+			}
+			if !vars[name] {
+				return fmt.Errorf("{{%s}} is not extracted by an earlier step", name)
+			}
+		}
+	}
+
+	for _, e := range s.Extract {
+		if !varName.MatchString(e.Var) {
+			return fmt.Errorf("extract: %q is not a valid variable name", e.Var)
+		}
+		if (e.JSONPath == "") == (e.Regex == "") {
+			return fmt.Errorf("extract %s: set either json_path or regex", e.Var)
+		}
+		if e.JSONPath != "" {
+			if _, err := parseJSONPath(e.JSONPath); err != nil {
+				return fmt.Errorf("extract %s: %w", e.Var, err)
+			}
+		}
+		if e.Regex != "" {
+			if _, err := regexp.Compile(e.Regex); err != nil {
+				return fmt.Errorf("extract %s: invalid regex: %w", e.Var, err)
+			}
+		}
+	}
+
+	if s.Expect.Status != 0 && (s.Expect.Status < 100 || s.Expect.Status > 599) {
+		return fmt.Errorf("expect.status must be an HTTP status code")
+	}
+	if s.Expect.MaxTime < 0 {
+		return fmt.Errorf("expect.max_time cannot be negative")
+	}
+	for path := range s.Expect.JSON {
+		if _, err := parseJSONPath(path); err != nil {
+			return fmt.Errorf("expect.json: %w", err)
+		}
+	}
+	return nil
+}
+
+// TimeoutDuration returns how long all steps may take together
+func (c *SyntheticCheck) TimeoutDuration() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultSyntheticTimeout * time.Second
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// Secrets returns the names of the secrets the steps insert
+func (c *SyntheticCheck) Secrets() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, step := range c.Steps {
+		texts := []string{step.URL, step.Body}
+		for _, value := range step.Headers {
+			texts = append(texts, value)
+		}
+		for _, text := range texts {
+			for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+				if name, ok := strings.CutPrefix(match[1], "secret."); ok && !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// ResolveStepURL resolves the URL of a step, with its placeholders
+// expanded, against the service URL
+func ResolveStepURL(baseURL, rawURL string) (*url.URL, error) {
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if ref.IsAbs() || baseURL == "" {
+		return ref, nil
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return base.ResolveReference(ref), nil
+}
+
+// Expand replaces the placeholders of a step's URL, header value or body
+// with the values lookup returns for their names
+func Expand(text string, lookup func(name string) (string, error)) (string, error) {
+	var firstErr error
+	expanded := placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		value, err := lookup(name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return value
+	})
+	return expanded, firstErr
+}
+
+// Extract returns the value an extraction takes from a response body
+func (e *Extraction) Extract(body []byte) (string, error) {
+	if e.JSONPath != "" {
+		return lookupJSONPath(body, e.JSONPath)
+	}
+
+	re, err := regexp.Compile(e.Regex)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex: %w", err)
+	}
+	match := re.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("regex %q does not match", e.Regex)
+	}
+	if len(match) > 1 {
+		return string(match[1]), nil
+	}
+	return string(match[0]), nil
+}
+
+// Evaluate compares a step's response to its expectations and describes
+// the first one it violates
+func (s *SyntheticStep) Evaluate(status int, body []byte, elapsed time.Duration) error {
+	if s.Expect.Status != 0 && status != s.Expect.Status {
+		return fmt.Errorf("expected status %d, got %d", s.Expect.Status, status)
+	}
+	if s.Expect.Status == 0 && (status < 200 || status >= 400) {
+		return fmt.Errorf("unhealthy status code: %d", status)
+	}
+	if s.Expect.MaxTime > 0 && elapsed > time.Duration(s.Expect.MaxTime)*time.Millisecond {
+		return fmt.Errorf("took %dms, more than %dms", elapsed.Milliseconds(), s.Expect.MaxTime)
+	}
+	if s.Expect.Contains != "" && !strings.Contains(string(body), s.Expect.Contains) {
+		return fmt.Errorf("body does not contain %q", s.Expect.Contains)
+	}
+
+	paths := make([]string, 0, len(s.Expect.JSON))
+	for path := range s.Expect.JSON {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		value, err := lookupJSONPath(body, path)
+		if err != nil {
+			return err
+		}
+		if want := s.Expect.JSON[path]; value != want {
+			return fmt.Errorf("%s is %q, expected %q", path, value, want)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Transport configures how HTTP and synthetic checks connect to a service. ClientCert
+// and CABundle name a client certificate and a CA bundle configured by the
+// administrator; CABundle replaces the system roots. ServerName overrides
+// the TLS server name the check sends and verifies, and Proxy is an
+// http, https or socks5 URL requests go through. SkipVerify turns off
+// certificate verification and is flagged wherever the service is shown.
+type Transport struct {
+	ClientCert string `json:"client_cert,omitempty"`
+	CABundle   string `json:"ca_bundle,omitempty"`
+	SkipVerify bool   `json:"skip_verify,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+	Proxy      string `json:"proxy,omitempty"`
+}
+
+// Clone returns a copy of the transport
+func (t *Transport) Clone() *Transport {
+	clone := *t
+	return &clone
+}
+
+// Validate checks the server name and proxy URL, and that certificates
+// are either verified against a CA bundle or not verified at all
+func (t *Transport) Validate() error {
+	if t.SkipVerify && t.CABundle != "" {
+		return fmt.Errorf("transport.ca_bundle has no effect with transport.skip_verify")
+	}
+	if t.ServerName != "" && strings.ContainsAny(t.ServerName, ":/ ") {
+		return fmt.Errorf("transport.server_name must be a host name without scheme or port")
+	}
+	if t.Proxy != "" {
+		u, err := url.Parse(t.Proxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			return fmt.Errorf("transport.proxy must be an http://, https:// or socks5:// URL")
+		}
+		if u.User != nil {
+			return fmt.Errorf("transport.proxy cannot contain credentials")
+		}
+	}
+	return nil
+}
+
+// ForHost returns the settings to use for requests to hosts other than
+// the service's own, such as its OAuth2 token URL: the same settings
+// without the server name override
+func (t *Transport) ForHost() *Transport {
+	if t == nil || t.ServerName == "" {
+		return t
+	}
+	clone := t.Clone()
+	clone.ServerName = ""
+	return clone
+}
+
+// SkipsVerify reports whether the HTTP requests of the service's checks
+// accept any certificate
+func (s *Service) SkipsVerify() bool {
+	return s.CheckType.SendsHTTP() && s.Transport != nil && s.Transport.SkipVerify
+}
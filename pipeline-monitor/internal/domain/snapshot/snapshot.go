@@ -0,0 +1,79 @@
+package snapshot
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// Redacted replaces the values of headers listed in Policy.RedactHeaders
+const Redacted = "[REDACTED]"
+
+// Policy controls what the monitor keeps of failing checks
+type Policy struct {
+	// Keep is the number of snapshots kept per service; 0 disables them
+	Keep int
+	// BodyBytes caps how much of a response body is stored
+	BodyBytes int
+	// RedactHeaders lists headers whose values are never stored, matched
+	// case-insensitively
+	RedactHeaders []string
+}
+
+// Redact returns a copy of the header with the values of redacted headers
+// replaced
+func (p Policy) Redact(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+	redacted := header.Clone()
+	for _, name := range p.RedactHeaders {
+		key := http.CanonicalHeaderKey(name)
+		if values, ok := redacted[key]; ok {
+			for i := range values {
+				values[i] = Redacted
+			}
+		}
+	}
+	return redacted
+}
+
+// Request is the request a check sent
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+}
+
+// Response is what a service answered, with the body cut to
+// Policy.BodyBytes
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body"`
+	Truncated  bool        `json:"truncated"`
+}
+
+// Snapshot is the request and response of a failing check. Response is
+// nil when the request failed before a response arrived.
+type Snapshot struct {
+	ID         int64          `json:"id"`
+	ServiceID  string         `json:"service_id"`
+	Status     service.Status `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	Request    Request        `json:"request"`
+	Response   *Response      `json:"response,omitempty"`
+	CapturedAt time.Time      `json:"captured_at"`
+}
+
+// Repository defines the interface for snapshot data access
+type Repository interface {
+	// Save stores a snapshot and drops all but the newest keep snapshots
+	// of its service
+	Save(ctx context.Context, snapshot *Snapshot, keep int) error
+	// List returns the snapshots of a service captured between from and to,
+	// newest first. A zero to means up to now.
+	List(ctx context.Context, serviceID string, from, to time.Time) ([]Snapshot, error)
+}
@@ -0,0 +1,111 @@
+package statuspage
+
+import (
+	"context"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// Component groups services under one heading of the public status page,
+// e.g. "API" or "Website"
+type Component struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Position    int       `json:"position"`
+	Entries     []Entry   `json:"entries"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Entry is a service shown on the status page under a friendly name
+type Entry struct {
+	ComponentID string `json:"component_id"`
+	ServiceID   string `json:"service_id"`
+	DisplayName string `json:"display_name"`
+	Position    int    `json:"position"`
+
+	// Current state of the underlying service
+	ServiceName string         `json:"service_name"`
+	Status      service.Status `json:"status"`
+	LastCheck   time.Time      `json:"last_check"`
+}
+
+// State is the customer-facing wording of a status
+type State string
+
+const (
+	StateOperational State = "operational"
+	StateDegraded    State = "degraded"
+	StateOutage      State = "outage"
+	StateUnknown     State = "unknown"
+)
+
+// severity orders states from best to worst
+var severity = map[State]int{
+	StateOperational: 0,
+	StateUnknown:     1,
+	StateDegraded:    2,
+	StateOutage:      3,
+}
+
+// StateOf translates a service status into a public state
+func StateOf(status service.Status) State {
+	switch status {
+	case service.StatusHealthy, service.StatusWarning:
+		return StateOperational
+	case service.StatusTimeout:
+		return StateDegraded
+	case service.StatusUnhealthy:
+		return StateOutage
+	default:
+		return StateUnknown
+	}
+}
+
+// Label returns the text shown for a state
+func (s State) Label() string {
+	switch s {
+	case StateOperational:
+		return "Operational"
+	case StateDegraded:
+		return "Degraded Performance"
+	case StateOutage:
+		return "Major Outage"
+	default:
+		return "Unknown"
+	}
+}
+
+// Worst returns the most severe of the given states
+func Worst(states ...State) State {
+	worst := StateOperational
+	for _, s := range states {
+		if severity[s] > severity[worst] {
+			worst = s
+		}
+	}
+	return worst
+}
+
+// State returns the worst state among the component's services
+func (c *Component) State() State {
+	states := make([]State, len(c.Entries))
+	for i, e := range c.Entries {
+		states[i] = StateOf(e.Status)
+	}
+	return Worst(states...)
+}
+
+// Repository defines the interface for status page configuration
+type Repository interface {
+	// List returns every component with its entries, in display order
+	List(ctx context.Context) ([]Component, error)
+	CreateComponent(ctx context.Context, component *Component) error
+	UpdateComponent(ctx context.Context, component *Component) error
+	DeleteComponent(ctx context.Context, id string) error
+	// SetEntry adds a service to a component or updates its display name
+	// and position
+	SetEntry(ctx context.Context, entry *Entry) error
+	RemoveEntry(ctx context.Context, componentID, serviceID string) error
+}
@@ -0,0 +1,157 @@
+package team
+
+import (
+	"context"
+	"time"
+)
+
+// Team owns a set of services and the people allowed to manage them
+type Team struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Role is a member's level of access within a team
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // read-only access to the team's services
+	RoleEditor Role = "editor" // can create, change and delete services
+	RoleOwner  Role = "owner"  // can also manage the team's members
+)
+
+// rank orders roles from least to most privileged
+var rank = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Roles lists all roles from least to most privileged
+func Roles() []Role {
+	return []Role{RoleViewer, RoleEditor, RoleOwner}
+}
+
+// Valid reports whether the role is one of the known roles
+func (r Role) Valid() bool {
+	return rank[r] > 0
+}
+
+// Includes reports whether the role grants at least the access of other
+func (r Role) Includes(other Role) bool {
+	return rank[r] >= rank[other]
+}
+
+// Membership links a user to a team with a role
+type Membership struct {
+	TeamID    string    `json:"team_id"`
+	TeamName  string    `json:"team_name,omitempty"`
+	Username  string    `json:"username"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Repository defines what team management needs from the data layer
+type Repository interface {
+	GetAll(ctx context.Context) ([]Team, error)
+	GetByID(ctx context.Context, id string) (*Team, error)
+	Create(ctx context.Context, team *Team) error
+	Delete(ctx context.Context, id string) error
+	ListMembers(ctx context.Context, teamID string) ([]Membership, error)
+	ListMemberships(ctx context.Context, username string) ([]Membership, error)
+	SetMember(ctx context.Context, membership *Membership) error
+	RemoveMember(ctx context.Context, teamID, username string) error
+}
+
+// Anonymous is the username of requests that reach the application
+// without an identity from the auth proxy. It never has a role.
+const Anonymous = "anonymous"
+
+// Caller is the authenticated user behind a request
+type Caller struct {
+	Username    string
+	Admin       bool
+	Memberships []Membership
+	// ActiveTeamID is the team picked in the team switcher. When empty,
+	// members see all of their teams and admins see every team.
+	ActiveTeamID string
+}
+
+// Role returns the caller's role in a team, or "" if they are not a member
+func (c *Caller) Role(teamID string) Role {
+	for _, m := range c.Memberships {
+		if m.TeamID == teamID {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// Can reports whether the caller has at least the given role in a team.
+// Admins can do anything in every team.
+func (c *Caller) Can(teamID string, role Role) bool {
+	if c.Admin {
+		return true
+	}
+	return teamID != "" && c.Role(teamID).Includes(role)
+}
+
+// Scope returns the set of teams whose data the caller currently sees
+func (c *Caller) Scope() Scope {
+	if c.ActiveTeamID != "" {
+		return Scope{TeamIDs: []string{c.ActiveTeamID}}
+	}
+	if c.Admin {
+		return Scope{All: true}
+	}
+
+	teamIDs := make([]string, 0, len(c.Memberships))
+	for _, m := range c.Memberships {
+		teamIDs = append(teamIDs, m.TeamID)
+	}
+	return Scope{TeamIDs: teamIDs}
+}
+
+// Scope restricts queries to the data owned by a set of teams
+type Scope struct {
+	All     bool
+	TeamIDs []string
+}
+
+type callerKey struct{}
+
+// WithCaller returns a context carrying the caller
+func WithCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// WithoutCaller returns ctx with the caller removed, for public endpoints
+// that must see every service regardless of who is asking
+func WithoutCaller(ctx context.Context) context.Context {
+	return WithCaller(ctx, nil)
+}
+
+// WithTeam returns ctx scoped to the data of a single team, for work the
+// application does on behalf of one of its services, such as checking it.
+// An empty team ID sees no team's data.
+func WithTeam(ctx context.Context, teamID string) context.Context {
+	return WithCaller(ctx, &Caller{ActiveTeamID: teamID})
+}
+
+// CallerFromContext returns the caller stored in ctx, or nil when the
+// context does not come from a user request (e.g. the monitor)
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerKey{}).(*Caller)
+	return caller
+}
+
+// ScopeFromContext returns the team scope for ctx. Contexts without a
+// caller belong to the application itself and are not restricted.
+func ScopeFromContext(ctx context.Context) Scope {
+	caller := CallerFromContext(ctx)
+	if caller == nil {
+		return Scope{All: true}
+	}
+	return caller.Scope()
+}
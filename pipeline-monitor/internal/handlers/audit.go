@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pipeline-monitor/internal/domain/audit"
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAuditLimit caps how many entries the audit page and API return
+const defaultAuditLimit = 200
+
+// AuditLog renders the audit log page
+func (h *Handlers) AuditLog(c *gin.Context) {
+	filter := parseAuditFilter(c)
+
+	entries, err := h.auditRepo.List(c.Request.Context(), filter)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load audit log",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "audit/list.html", gin.H{
+		"title":   "Audit Log",
+		"entries": entries,
+		"filter":  filter,
+		"sources": []audit.Source{audit.SourceUI, audit.SourceAPI},
+		"actions": []audit.Action{
+			audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete, audit.ActionRevert,
+		},
+	})
+}
+
+// APIListAudit returns audit entries as JSON
+func (h *Handlers) APIListAudit(c *gin.Context) {
+	filter := parseAuditFilter(c)
+
+	entries, err := h.auditRepo.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch audit log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// parseAuditFilter reads audit filters from the query string.
+// Dates are accepted either as RFC 3339 timestamps or as YYYY-MM-DD, and
+// both ends of the range are inclusive.
+func parseAuditFilter(c *gin.Context) audit.Filter {
+	filter := audit.Filter{
+		Actor:     c.Query("actor"),
+		Source:    audit.Source(c.Query("source")),
+		Action:    audit.Action(c.Query("action")),
+		ServiceID: c.Query("service_id"),
+		Since:     parseTimeParam(c.Query("since")),
+		Until:     parseUntilParam(c.Query("until")),
+		Limit:     defaultAuditLimit,
+	}
+
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+
+	return filter
+}
+
+// parseTimeParam parses a timestamp or date query parameter, returning the
+// zero time when it is empty or malformed
+func parseTimeParam(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// parseUntilParam parses the end of a time range like parseTimeParam, but
+// reads a bare date as the last moment of that day so that the day itself
+// is included
+func parseUntilParam(value string) time.Time {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+	return parseTimeParam(value)
+}
+
+// actor identifies the caller for audit entries and revisions
+func (h *Handlers) actor(c *gin.Context) string {
+	return h.caller(c).Username
+}
+
+// recordAudit stores an audit entry for a service change. Failures are
+// logged rather than returned so that auditing never blocks the change itself.
+func (h *Handlers) recordAudit(c *gin.Context, source audit.Source, action audit.Action, before, after *service.Service) {
+	entry, err := audit.NewEntry(h.actor(c), source, action, before, after)
+	if err != nil {
+		log.Printf("Failed to build audit entry: %v", err)
+		return
+	}
+
+	if err := h.auditRepo.Record(c.Request.Context(), entry); err != nil {
+		log.Printf("Failed to record audit entry for service %s: %v", entry.ServiceID, err)
+	}
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/audit"
+	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/team"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusColors are the colors behind the CSS classes returned by StatusClass,
+// as configured for Tailwind in base.html
+var statusColors = map[string]string{
+	"status-healthy":   "#10b981",
+	"status-unhealthy": "#ef4444",
+	"status-timeout":   "#f59e0b",
+	"status-warning":   "#f97316",
+	"status-unknown":   "#6b7280",
+}
+
+// StatusClass returns the CSS class for a service status
+func StatusClass(status any) string {
+	switch fmt.Sprint(status) {
+	case "healthy":
+		return "status-healthy"
+	case "unhealthy":
+		return "status-unhealthy"
+	case "timeout":
+		return "status-timeout"
+	case "warning":
+		return "status-warning"
+	default:
+		return "status-unknown"
+	}
+}
+
+const (
+	maxUptimeWindow   = 365 * 24 * time.Hour
+	uptimeBadgeMaxAge = 5 * time.Minute
+)
+
+// badgeTemplate draws a flat, shields.io style badge
+var badgeTemplate = template.Must(template.New("badge").Parse(
+	`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Message}}">` +
+		`<title>{{.Label}}: {{.Message}}</title>` +
+		`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+		`<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>` +
+		`<g clip-path="url(#r)">` +
+		`<rect width="{{.LabelWidth}}" height="20" fill="#555"/>` +
+		`<rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/>` +
+		`<rect width="{{.Width}}" height="20" fill="url(#s)"/>` +
+		`</g>` +
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` +
+		`<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text>` +
+		`<text x="{{.LabelX}}" y="14">{{.Label}}</text>` +
+		`<text x="{{.MessageX}}" y="15" fill="#010101" fill-opacity=".3">{{.Message}}</text>` +
+		`<text x="{{.MessageX}}" y="14">{{.Message}}</text>` +
+		`</g></svg>`,
+))
+
+// badge holds the text and geometry of a rendered badge
+type badge struct {
+	Label, Message, Color    string
+	LabelWidth, MessageWidth int
+	Width, LabelX, MessageX  int
+}
+
+// newBadge lays out a badge, estimating text widths for Verdana 11px
+func newBadge(label, message, color string) badge {
+	b := badge{Label: label, Message: message, Color: color}
+	b.LabelWidth = textWidth(label) + 10
+	b.MessageWidth = textWidth(message) + 10
+	b.Width = b.LabelWidth + b.MessageWidth
+	b.LabelX = b.LabelWidth / 2
+	b.MessageX = b.LabelWidth + b.MessageWidth/2
+	return b
+}
+
+// textWidth approximates the rendered width of text in pixels
+func textWidth(text string) int {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("ijlt.,:;|!'() ", r):
+			width += 3.5
+		case strings.ContainsRune("mwMW%", r):
+			width += 10
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 6.5
+		}
+	}
+	return int(width + 0.5)
+}
+
+// StatusBadge returns an SVG badge with the current status of a service
+func (h *Handlers) StatusBadge(c *gin.Context) {
+	svc, ok := h.badgeService(c, "status")
+	if !ok {
+		return
+	}
+
+	color := statusColors[StatusClass(svc.Status)]
+	maxAge := time.Duration(h.config.CheckInterval) * time.Second
+	h.writeBadge(c, http.StatusOK, newBadge(c.DefaultQuery("label", "status"), string(svc.Status), color), maxAge)
+}
+
+// UptimeBadge returns an SVG badge with the uptime of a service over a
+// window such as ?window=30d (also accepts hours and weeks, e.g. 24h or 2w)
+func (h *Handlers) UptimeBadge(c *gin.Context) {
+	window, err := parseWindow(c.DefaultQuery("window", "30d"))
+	if err != nil {
+		h.writeBadge(c, http.StatusBadRequest, newBadge("uptime", "invalid window", statusColors["status-unknown"]), 0)
+		return
+	}
+
+	label := c.DefaultQuery("label", "uptime "+c.DefaultQuery("window", "30d"))
+
+	svc, ok := h.badgeService(c, label)
+	if !ok {
+		return
+	}
+
+	uptime, err := h.checkRepo.Uptime(team.WithoutCaller(c.Request.Context()), svc.ID, time.Now().Add(-window))
+	if err != nil {
+		h.writeBadge(c, http.StatusInternalServerError, newBadge(label, "error", statusColors["status-unknown"]), 0)
+		return
+	}
+
+	message, color := "no data", statusColors["status-unknown"]
+	if uptime.Checks > 0 {
+		percent := uptime.Percent()
+		message = strconv.FormatFloat(percent, 'f', 2, 64) + "%"
+		switch {
+		case percent >= 99:
+			color = statusColors["status-healthy"]
+		case percent >= 95:
+			color = statusColors["status-timeout"]
+		default:
+			color = statusColors["status-unhealthy"]
+		}
+	}
+
+	h.writeBadge(c, http.StatusOK, newBadge(label, message, color), uptimeBadgeMaxAge)
+}
+
+// GenerateBadgeToken creates a new badge token for a service, replacing any
+// previous one, so that its badges can only be fetched with the token
+func (h *Handlers) GenerateBadgeToken(c *gin.Context) {
+	token, err := newToken()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to generate badge token",
+		})
+		return
+	}
+
+	if _, err := h.setBadgeToken(c, audit.SourceUI, token); err != nil {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/services/"+c.Param("id"))
+}
+
+// RemoveBadgeToken makes the badges of a service public again
+func (h *Handlers) RemoveBadgeToken(c *gin.Context) {
+	if _, err := h.setBadgeToken(c, audit.SourceUI, ""); err != nil {
+		if c.GetHeader("HX-Request") == "true" {
+			c.Header("HX-Trigger", "error")
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if c.GetHeader("HX-Request") == "true" {
+		c.Header("HX-Redirect", "/services/"+c.Param("id"))
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/services/"+c.Param("id"))
+}
+
+// APIGenerateBadgeToken creates a new badge token via JSON API
+func (h *Handlers) APIGenerateBadgeToken(c *gin.Context) {
+	token, err := newToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate badge token",
+		})
+		return
+	}
+
+	svc, err := h.setBadgeToken(c, audit.SourceAPI, token)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"badge_token": token,
+		"status_url":  badgeURL(svc, "status.svg"),
+		"uptime_url":  badgeURL(svc, "uptime.svg"),
+	})
+}
+
+// APIRemoveBadgeToken makes the badges of a service public via JSON API
+func (h *Handlers) APIRemoveBadgeToken(c *gin.Context) {
+	if _, err := h.setBadgeToken(c, audit.SourceAPI, ""); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Badge token removed successfully",
+	})
+}
+
+// setBadgeToken checks the caller may edit the service, stores the token
+// and records the change in the audit log
+func (h *Handlers) setBadgeToken(c *gin.Context, source audit.Source, token string) (*service.Service, error) {
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	if !h.canEditService(c, svc.TeamID) {
+		return nil, fmt.Errorf("you cannot change services owned by this team")
+	}
+
+	before := svc.Clone()
+	if err := h.serviceRepo.SetBadgeToken(c.Request.Context(), svc.ID, token); err != nil {
+		return nil, fmt.Errorf("failed to update badge token: %w", err)
+	}
+	svc.BadgeToken = token
+
+	h.recordAudit(c, source, audit.ActionUpdate, before, svc)
+	return svc, nil
+}
+
+// badgeService looks up the service of a badge request without team
+// scoping, since badges are fetched anonymously. Unknown services and
+// wrong tokens get the same "not found" badge so that nothing leaks.
+func (h *Handlers) badgeService(c *gin.Context, label string) (*service.Service, bool) {
+	svc, err := h.serviceRepo.GetByID(team.WithoutCaller(c.Request.Context()), c.Param("id"))
+	if err == nil && svc.BadgeToken != "" &&
+		subtle.ConstantTimeCompare([]byte(svc.BadgeToken), []byte(c.Query("token"))) != 1 {
+		err = fmt.Errorf("invalid badge token")
+	}
+
+	if err != nil {
+		h.writeBadge(c, http.StatusNotFound, newBadge(label, "not found", statusColors["status-unknown"]), 0)
+		return nil, false
+	}
+
+	return svc, true
+}
+
+// writeBadge renders a badge with caching headers. Identical badges share
+// an ETag so clients can revalidate cheaply.
+func (h *Handlers) writeBadge(c *gin.Context, status int, b badge, maxAge time.Duration) {
+	var buf bytes.Buffer
+	if err := badgeTemplate.Execute(&buf, b); err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha1.Sum(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	if maxAge > 0 {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+	c.Header("ETag", etag)
+
+	if status == http.StatusOK && c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(status, "image/svg+xml; charset=utf-8", buf.Bytes())
+}
+
+// badgeURL returns the path of a badge, including the token if one is set
+func badgeURL(svc *service.Service, name string) string {
+	url := "/badge/" + svc.ID + "/" + name
+	if svc.BadgeToken != "" {
+		url += "?token=" + svc.BadgeToken
+	}
+	return url
+}
+
+// requestBaseURL returns the scheme and host the request was made to, for
+// absolute links such as badge embeds
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// newToken returns a random token safe to put in a URL
+func newToken() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// parseWindow parses a duration written as a number of hours, days or
+// weeks, e.g. "24h", "30d" or "2w"
+func parseWindow(value string) (time.Duration, error) {
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid window %q", value)
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid window %q", value)
+	}
+
+	var unit time.Duration
+	switch value[len(value)-1] {
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("invalid window %q", value)
+	}
+
+	window := time.Duration(n) * unit
+	if window > maxUptimeWindow {
+		return 0, fmt.Errorf("window %q is longer than %d days", value, int(maxUptimeWindow.Hours()/24))
+	}
+	return window, nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/certificate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// expiringWithinDays returns how far ahead to list expiring certificates:
+// the ?days= query parameter, or the first alert threshold
+func expiringWithinDays(c *gin.Context) int {
+	if days, err := strconv.Atoi(c.Query("days")); err == nil && days > 0 {
+		return days
+	}
+	return certificate.AlertDays[0]
+}
+
+// ExpiringCertificatesPartial renders the dashboard widget of certificates
+// that expire soon or fail validation
+func (h *Handlers) ExpiringCertificatesPartial(c *gin.Context) {
+	now := time.Now()
+	chains, err := h.certRepo.ListExpiring(c.Request.Context(), now.AddDate(0, 0, expiringWithinDays(c)))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/expiring-certificates.html", gin.H{
+			"error": "Failed to fetch certificates",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "partials/expiring-certificates.html", gin.H{
+		"certificates": chains,
+		"now":          now,
+	})
+}
+
+// APIListCertificates returns the certificate chains that expire within
+// ?days= days (30 by default) or fail validation
+func (h *Handlers) APIListCertificates(c *gin.Context) {
+	days := expiringWithinDays(c)
+	chains, err := h.certRepo.ListExpiring(c.Request.Context(), time.Now().AddDate(0, 0, days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch certificates",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"certificates": chains,
+		"count":        len(chains),
+		"days":         days,
+	})
+}
+
+// CertificatesAtomFeed serves certificate expiry alerts as an Atom feed
+func (h *Handlers) CertificatesAtomFeed(c *gin.Context) {
+	h.serveFeed(c, "certificates", formatAtom)
+}
+
+// CertificatesRSSFeed serves certificate expiry alerts as an RSS feed
+func (h *Handlers) CertificatesRSSFeed(c *gin.Context) {
+	h.serveFeed(c, "certificates", formatRSS)
+}
+
+func certificateAlertEntry(a *certificate.Alert, baseURL string) feedEntry {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Service: %s\n", a.ServiceName)
+	fmt.Fprintf(&summary, "Host: %s\n", a.Host)
+	fmt.Fprintf(&summary, "Subject: %s\n", a.Subject)
+	fmt.Fprintf(&summary, "Expires: %s\n", a.NotAfter.UTC().Format(time.RFC3339))
+
+	return feedEntry{
+		ID:        fmt.Sprintf("urn:pipeline-monitor:certificate-alert:%d", a.ID),
+		Title:     fmt.Sprintf("Certificate of %s expires within %s", a.ServiceName, pluralDays(a.Days)),
+		Link:      baseURL + "/services/" + a.ServiceID,
+		Published: a.CreatedAt,
+		Updated:   a.CreatedAt,
+		Summary:   summary.String(),
+	}
+}
+
+func pluralDays(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/event"
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Chart dimensions in SVG user units. The SVG scales to its container.
+const (
+	chartWidth       = 720
+	chartHeight      = 180
+	chartTop         = 8  // room for the top axis label
+	chartLeft        = 48 // room for the response time axis labels
+	chartStatusTop   = chartHeight + 12
+	chartStatusSize  = 12
+	chartTotalHeight = chartStatusTop + chartStatusSize + 20
+)
+
+// chartRange is a time range the detail page can chart
+type chartRange struct {
+	Name    string
+	Label   string
+	Span    time.Duration
+	Buckets int
+}
+
+// chartRanges are the selectable ranges, in display order
+var chartRanges = []chartRange{
+	{Name: "1h", Label: "Last hour", Span: time.Hour, Buckets: 60},
+	{Name: "1d", Label: "Last day", Span: 24 * time.Hour, Buckets: 96},
+	{Name: "1w", Label: "Last week", Span: 7 * 24 * time.Hour, Buckets: 84},
+}
+
+// chartRect is a status bar segment
+type chartRect struct {
+	X     float64
+	Width float64
+	Color string
+	Title string
+}
+
+// chartTick is a horizontal grid line with its label
+type chartTick struct {
+	Y     float64
+	Label string
+}
+
+// chartMarker is a vertical line marking an event
+type chartMarker struct {
+	X      float64
+	Color  string
+	Dashed bool // tag-wide events
+	Title  string
+}
+
+// markerColors are the marker colors of event kinds
+var markerColors = map[event.Kind]string{
+	event.KindDeploy:       "#8b5cf6",
+	event.KindConfigChange: "#f59e0b",
+	event.KindCustom:       "#6b7280",
+}
+
+// chart is everything the service chart partial draws
+type chart struct {
+	Width       int
+	Height      int
+	Left        int
+	StatusTop   int
+	StatusSize  int
+	TotalHeight int
+	Lines       []string // polyline point lists, split where data is missing
+	Ticks       []chartTick
+	Status      []chartRect
+	Markers     []chartMarker
+	StartLabel  string
+	EndLabel    string
+}
+
+// ServiceChartPartial renders response time and status charts of a service
+// over the range picked with ?range= (1h, 1d or 1w)
+func (h *Handlers) ServiceChartPartial(c *gin.Context) {
+	id := c.Param("id")
+
+	rng, ok := findChartRange(c.DefaultQuery("range", "1d"))
+	if !ok {
+		c.HTML(http.StatusBadRequest, "partials/service-chart.html", gin.H{
+			"error": "Unknown range, use 1h, 1d or 1w",
+		})
+		return
+	}
+
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "partials/service-chart.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	end := time.Now()
+	start := end.Add(-rng.Span)
+
+	resolution, rollups, err := h.checkRepo.Series(c.Request.Context(), id, start)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/service-chart.html", gin.H{
+			"error": "Failed to load check history",
+		})
+		return
+	}
+
+	events, err := h.eventRepo.ListForService(c.Request.Context(), id, svc.Tags, start, end)
+	if err != nil {
+		log.Printf("Failed to load events of service %s: %v", id, err)
+	}
+
+	var total service.Rollup
+	for i := range rollups {
+		total.Merge(&rollups[i])
+	}
+
+	ch := buildChart(bucketRollups(rollups, start, end, rng.Buckets), start, rng)
+	ch.Markers = chartMarkers(events, start, rng)
+
+	c.HTML(http.StatusOK, "partials/service-chart.html", gin.H{
+		"serviceID":  id,
+		"ranges":     chartRanges,
+		"range":      rng,
+		"resolution": resolution,
+		"stats":      total.Stats(),
+		"chart":      ch,
+		"events":     events,
+	})
+}
+
+// findChartRange looks up a range by name
+func findChartRange(name string) (chartRange, bool) {
+	for _, rng := range chartRanges {
+		if rng.Name == name {
+			return rng, true
+		}
+	}
+	return chartRange{}, false
+}
+
+// bucketRollups merges rollups into n equal buckets between start and end
+func bucketRollups(rollups []service.Rollup, start, end time.Time, n int) []service.Rollup {
+	width := end.Sub(start) / time.Duration(n)
+	buckets := make([]service.Rollup, n)
+
+	for i := range rollups {
+		index := int(rollups[i].Start.Sub(start) / width)
+		if index < 0 {
+			// the first rollup may start before the range
+			index = 0
+		}
+		if index >= n {
+			continue
+		}
+		buckets[index].Merge(&rollups[i])
+	}
+
+	return buckets
+}
+
+// buildChart lays out the average response time line and the status bar
+func buildChart(buckets []service.Rollup, start time.Time, rng chartRange) chart {
+	ch := chart{
+		Width:       chartWidth,
+		Height:      chartHeight,
+		Left:        chartLeft,
+		StatusTop:   chartStatusTop,
+		StatusSize:  chartStatusSize,
+		TotalHeight: chartTotalHeight,
+	}
+
+	peak := 0
+	for i := range buckets {
+		if avg := buckets[i].Avg(); avg > peak {
+			peak = avg
+		}
+	}
+	scale := chartScale(peak)
+
+	y := func(value int) float64 {
+		return chartHeight - float64((chartHeight-chartTop)*value)/float64(scale)
+	}
+	for i := 0; i <= 4; i++ {
+		value := scale * i / 4
+		ch.Ticks = append(ch.Ticks, chartTick{Y: y(value), Label: formatMillis(value)})
+	}
+
+	width := rng.Span / time.Duration(len(buckets))
+	step := float64(chartWidth-chartLeft) / float64(len(buckets))
+	var points []string
+	for i := range buckets {
+		b := &buckets[i]
+		x := float64(chartLeft) + step*float64(i)
+
+		if b.Count == 0 {
+			if len(points) > 0 {
+				ch.Lines = append(ch.Lines, strings.Join(points, " "))
+				points = nil
+			}
+			continue
+		}
+
+		avg := b.Avg()
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x+step/2, y(avg)))
+
+		// Partly failing buckets use the timeout color, as on the badges
+		status := service.StatusHealthy
+		switch {
+		case b.Failures == b.Count:
+			status = service.StatusUnhealthy
+		case b.Failures > 0:
+			status = service.StatusTimeout
+		}
+
+		ch.Status = append(ch.Status, chartRect{
+			X:     x,
+			Width: step,
+			Color: statusColors[StatusClass(status)],
+			Title: fmt.Sprintf("%s: %d of %d checks failed, avg %s, max %s",
+				start.Add(width*time.Duration(i)).Format("Jan 2 15:04"),
+				b.Failures, b.Count, formatMillis(avg), formatMillis(b.Max)),
+		})
+	}
+	if len(points) > 0 {
+		ch.Lines = append(ch.Lines, strings.Join(points, " "))
+	}
+
+	layout := "15:04"
+	if rng.Span > 24*time.Hour {
+		layout = "Jan 2 15:04"
+	}
+	ch.StartLabel = start.Format(layout)
+	ch.EndLabel = start.Add(rng.Span).Format(layout)
+
+	return ch
+}
+
+// chartMarkers places events on the time axis of the chart
+func chartMarkers(events []event.Event, start time.Time, rng chartRange) []chartMarker {
+	markers := make([]chartMarker, 0, len(events))
+	for _, e := range events {
+		offset := float64(e.OccurredAt.Sub(start)) / float64(rng.Span)
+		if offset < 0 || offset > 1 {
+			continue
+		}
+
+		title := fmt.Sprintf("%s: %s", e.OccurredAt.Format("Jan 2 15:04"), e.Title)
+		if e.Author != "" {
+			title += " by " + e.Author
+		}
+		if e.Tag != "" {
+			title += " (all services tagged " + e.Tag + ")"
+		}
+
+		markers = append(markers, chartMarker{
+			X:      float64(chartLeft) + offset*float64(chartWidth-chartLeft),
+			Color:  markerColors[e.Kind],
+			Dashed: e.Tag != "",
+			Title:  title,
+		})
+	}
+	return markers
+}
+
+// chartScale rounds the peak response time up to a value that divides into
+// readable axis labels
+func chartScale(peak int) int {
+	for _, scale := range []int{100, 200, 500, 1000, 2000, 5000, 10000, 20000, 30000, 60000} {
+		if peak <= scale {
+			return scale
+		}
+	}
+	return (peak/60000 + 1) * 60000
+}
+
+// formatMillis formats a response time like the formatResponseTime template
+// function
+func formatMillis(ms int) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
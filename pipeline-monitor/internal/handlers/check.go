@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"pipeline-monitor/internal/domain/secret"
+	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/team"
+)
+
+// prepareCheck validates the check settings of a service and gives
+// heartbeat services a ping token if they have none yet
+func (h *Handlers) prepareCheck(ctx context.Context, svc *service.Service) error {
+	if svc.CheckType == "" {
+		svc.CheckType = service.CheckHTTP
+	}
+	if err := service.ValidateCheck(svc); err != nil {
+		return err
+	}
+
+	caller := team.CallerFromContext(ctx)
+
+	if svc.CheckType == service.CheckSQL {
+		if _, ok := h.config.DataSources[svc.SQL.DataSource]; !ok {
+			return fmt.Errorf("unknown data source %q", svc.SQL.DataSource)
+		}
+		if !isAdmin(caller) {
+			return fmt.Errorf("data source %q is shared, and only admins can use data sources in checks", svc.SQL.DataSource)
+		}
+	}
+
+	if svc.CheckType == service.CheckExec {
+		if _, ok := h.config.ExecCommands[svc.Exec.Command]; !ok {
+			return fmt.Errorf("command %q is not on the allow-list", svc.Exec.Command)
+		}
+		if !isAdmin(caller) {
+			return fmt.Errorf("command %q runs on the monitor host, and only admins can use commands in checks", svc.Exec.Command)
+		}
+	}
+
+	if svc.CheckType == service.CheckGRPC && svc.GRPC != nil && svc.GRPC.ClientCert != "" {
+		if err := h.checkClientCert(caller, svc.GRPC.ClientCert); err != nil {
+			return err
+		}
+	}
+
+	if svc.CheckType.SendsHTTP() && svc.Transport != nil {
+		if name := svc.Transport.ClientCert; name != "" {
+			if err := h.checkClientCert(caller, name); err != nil {
+				return err
+			}
+		}
+		if name := svc.Transport.CABundle; name != "" {
+			if _, ok := h.config.CABundles[name]; !ok {
+				return fmt.Errorf("unknown CA bundle %q", name)
+			}
+			if !isAdmin(caller) {
+				return fmt.Errorf("CA bundle %q is shared, and only admins can use CA bundles in checks", name)
+			}
+		}
+	}
+
+	// Whoever sets up a check decides where its secrets are sent, so a
+	// check may only use the secrets of its service's team, and shared
+	// secrets only when an admin sets it up
+	for _, name := range svc.Secrets() {
+		s, err := h.secretRepo.Get(ctx, name)
+		if errors.Is(err, secret.ErrNotFound) {
+			return fmt.Errorf("unknown secret %q", name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up secret %q: %w", name, err)
+		}
+		if s.Shared() {
+			if !isAdmin(caller) {
+				return fmt.Errorf("secret %q is shared, and only admins can use shared secrets in checks", name)
+			}
+		} else if s.TeamID != svc.TeamID {
+			return fmt.Errorf("secret %q belongs to another team", name)
+		}
+	}
+
+	return assignPingToken(svc)
+}
+
+// checkClientCert returns an error unless the client certificate is
+// configured and the caller may present it
+func (h *Handlers) checkClientCert(caller *team.Caller, name string) error {
+	if _, ok := h.config.ClientCerts[name]; !ok {
+		return fmt.Errorf("unknown client certificate %q", name)
+	}
+	if !isAdmin(caller) {
+		return fmt.Errorf("client certificate %q is shared, and only admins can use client certificates in checks", name)
+	}
+	return nil
+}
+
+// isAdmin reports whether the caller is an admin. What the monitor host
+// is configured with, such as data sources, is shared by every team, so
+// like shared secrets only admins can set up checks that use it.
+func isAdmin(caller *team.Caller) bool {
+	return caller != nil && caller.Admin
+}
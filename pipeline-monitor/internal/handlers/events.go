@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/event"
+	"pipeline-monitor/internal/domain/incident"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timelineLookback is how long before an incident started its timeline
+// shows events, so the deploy that caused it is on it
+const timelineLookback = time.Hour
+
+// eventRequest is the body of the event endpoints
+type eventRequest struct {
+	Tag        string     `json:"tag"`
+	Kind       event.Kind `json:"kind" binding:"required"`
+	Title      string     `json:"title"`
+	Version    string     `json:"version"`
+	Author     string     `json:"author"`
+	Link       string     `json:"link"`
+	OccurredAt *time.Time `json:"occurred_at"`
+
+	// CommittedAt is when the deployed change was committed, for the lead
+	// time of deploys
+	CommittedAt *time.Time `json:"committed_at"`
+}
+
+// newEvent builds an event from a request, defaulting the author to the
+// caller and the time to now
+func (h *Handlers) newEvent(c *gin.Context, req eventRequest) *event.Event {
+	e := &event.Event{
+		Tag:         req.Tag,
+		Kind:        req.Kind,
+		Title:       req.Title,
+		Version:     req.Version,
+		Author:      req.Author,
+		Link:        req.Link,
+		OccurredAt:  time.Now(),
+		CommittedAt: req.CommittedAt,
+	}
+	if e.Author == "" {
+		e.Author = h.actor(c)
+	}
+	if req.OccurredAt != nil {
+		e.OccurredAt = *req.OccurredAt
+	}
+	return e
+}
+
+// APICreateServiceEvent records a deploy, config change or custom event of
+// a service
+func (h *Handlers) APICreateServiceEvent(c *gin.Context) {
+	var req eventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	if !h.canEditService(c, svc.TeamID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You cannot add events to services owned by this team",
+		})
+		return
+	}
+
+	req.Tag = ""
+	e := h.newEvent(c, req)
+	e.ServiceID = svc.ID
+	h.createEvent(c, e)
+}
+
+// APICreateTagEvent records an event of every service carrying a tag, such
+// as a platform upgrade. Only admins can post tag-wide events.
+func (h *Handlers) APICreateTagEvent(c *gin.Context) {
+	var req eventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !h.caller(c).Admin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only admins can add tag-wide events",
+		})
+		return
+	}
+
+	h.createEvent(c, h.newEvent(c, req))
+}
+
+// createEvent validates and stores an event
+func (h *Handlers) createEvent(c *gin.Context, e *event.Event) {
+	if err := e.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.eventRepo.Create(c.Request.Context(), e); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create event",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, e)
+}
+
+// APIListServiceEvents returns the events of a service, including the
+// tag-wide events of its tags, oldest first. Filter with ?since= and
+// ?until=.
+func (h *Handlers) APIListServiceEvents(c *gin.Context) {
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	events, err := h.eventRepo.ListForService(c.Request.Context(), svc.ID, svc.Tags,
+		parseTimeParam(c.Query("since")), parseUntilParam(c.Query("until")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch events",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// APIListTagEvents returns the tag-wide events of ?tag=, oldest first.
+// Filter with ?since= and ?until=.
+func (h *Handlers) APIListTagEvents(c *gin.Context) {
+	tag := c.Query("tag")
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "tag is required",
+		})
+		return
+	}
+
+	events, err := h.eventRepo.ListForTag(c.Request.Context(), tag,
+		parseTimeParam(c.Query("since")), parseUntilParam(c.Query("until")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch events",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// APIDeleteEvent removes an event. Service events can be removed by
+// editors of the service, tag-wide events by admins.
+func (h *Handlers) APIDeleteEvent(c *gin.Context) {
+	e, err := h.eventRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Event not found",
+		})
+		return
+	}
+
+	allowed := h.caller(c).Admin
+	if e.ServiceID != "" {
+		svc, err := h.serviceRepo.GetByID(c.Request.Context(), e.ServiceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Event not found",
+			})
+			return
+		}
+		allowed = h.canEditService(c, svc.TeamID)
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You cannot remove this event",
+		})
+		return
+	}
+
+	if err := h.eventRepo.Delete(c.Request.Context(), e.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete event",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// timelineEntry is a line of the incident timeline
+type timelineEntry struct {
+	At     time.Time
+	Kind   string // incident_started, incident_resolved or an event kind
+	Title  string
+	Detail string
+	Link   string
+	Tag    string
+}
+
+// incidentTimeline lists what happened around an incident: the events of
+// its service from shortly before it started until it was resolved, and
+// the incident itself
+func (h *Handlers) incidentTimeline(c *gin.Context, inc *incident.Incident, tags []string) []timelineEntry {
+	to := time.Now()
+	if inc.ResolvedAt != nil {
+		to = *inc.ResolvedAt
+	}
+
+	entries := []timelineEntry{{
+		At:     inc.StartedAt,
+		Kind:   "incident_started",
+		Title:  "Incident started",
+		Detail: inc.Cause,
+	}}
+	if inc.ResolvedAt != nil {
+		entries = append(entries, timelineEntry{
+			At:    *inc.ResolvedAt,
+			Kind:  "incident_resolved",
+			Title: "Incident resolved",
+		})
+	}
+
+	events, err := h.eventRepo.ListForService(c.Request.Context(), inc.ServiceID, tags, inc.StartedAt.Add(-timelineLookback), to)
+	if err != nil {
+		log.Printf("Failed to load events of incident %s: %v", inc.ID, err)
+	}
+	for _, e := range events {
+		detail := e.Version
+		if e.Author != "" {
+			detail = strings.TrimSpace(detail + " by " + e.Author)
+		}
+		entries = append(entries, timelineEntry{
+			At:     e.OccurredAt,
+			Kind:   string(e.Kind),
+			Title:  e.Title,
+			Detail: detail,
+			Link:   e.Link,
+			Tag:    e.Tag,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].At.Before(entries[j].At)
+	})
+	return entries
+}
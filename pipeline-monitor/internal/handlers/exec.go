@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsWindow is how far back the range of each metric reaches
+const metricsWindow = time.Hour
+
+// execCheckForm holds the exec check fields of the service form. Arguments
+// and environment variables are entered one per line.
+type execCheckForm struct {
+	ExecCommand string `form:"exec_command"`
+	ExecArgs    string `form:"exec_args"`
+	ExecEnv     string `form:"exec_env"`
+	ExecTimeout int    `form:"exec_timeout"`
+}
+
+// execCheck builds the exec check of a service from the form. Services with
+// other check types get none.
+func (f execCheckForm) execCheck(checkType string) (*service.ExecCheck, error) {
+	if service.CheckType(checkType) != service.CheckExec {
+		return nil, nil
+	}
+
+	check := &service.ExecCheck{
+		Command: f.ExecCommand,
+		Timeout: f.ExecTimeout,
+	}
+
+	for _, line := range strings.Split(f.ExecArgs, "\n") {
+		if arg := strings.TrimRight(line, "\r"); strings.TrimSpace(arg) != "" {
+			check.Args = append(check.Args, arg)
+		}
+	}
+
+	for _, line := range strings.Split(f.ExecEnv, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("environment variable %q must be written as NAME=value", line)
+		}
+		if check.Env == nil {
+			check.Env = make(map[string]string)
+		}
+		check.Env[strings.TrimSpace(name)] = value
+	}
+
+	return check, nil
+}
+
+// execCommandNames returns the allow-listed commands exec checks can run
+func (h *Handlers) execCommandNames() []string {
+	names := make([]string, 0, len(h.config.ExecCommands))
+	for name := range h.config.ExecCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// metricRow is the latest value of a metric and its range over the last
+// hour
+type metricRow struct {
+	service.Metric
+	Low, High float64
+}
+
+// ServiceMetricsPartial renders the performance data of the latest check
+// of a service that reported any, with the range of each metric over the
+// last hour
+func (h *Handlers) ServiceMetricsPartial(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), id); err != nil {
+		c.HTML(http.StatusNotFound, "partials/service-metrics.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	checks, err := h.checkRepo.History(c.Request.Context(), id, time.Now().Add(-metricsWindow))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/service-metrics.html", gin.H{
+			"error": "Failed to load check history",
+		})
+		return
+	}
+
+	var latest *service.HealthCheck
+	low := make(map[string]float64)
+	high := make(map[string]float64)
+	for i := range checks {
+		for _, m := range checks[i].Metrics {
+			if v, ok := low[m.Label]; !ok || m.Value < v {
+				low[m.Label] = m.Value
+			}
+			if v, ok := high[m.Label]; !ok || m.Value > v {
+				high[m.Label] = m.Value
+			}
+		}
+		if len(checks[i].Metrics) > 0 {
+			latest = &checks[i]
+		}
+	}
+
+	if latest == nil {
+		c.HTML(http.StatusOK, "partials/service-metrics.html", gin.H{})
+		return
+	}
+
+	rows := make([]metricRow, 0, len(latest.Metrics))
+	for _, m := range latest.Metrics {
+		rows = append(rows, metricRow{Metric: m, Low: low[m.Label], High: high[m.Label]})
+	}
+
+	c.HTML(http.StatusOK, "partials/service-metrics.html", gin.H{
+		"metrics":   rows,
+		"checkedAt": latest.Timestamp,
+	})
+}
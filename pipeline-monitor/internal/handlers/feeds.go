@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/incident"
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// feedWindow is how far back feeds look for incidents and transitions
+	feedWindow = 30 * 24 * time.Hour
+
+	// feedLimit is the maximum number of entries in a feed
+	feedLimit = 100
+)
+
+// feedFormat is the syndication format a feed is served in
+type feedFormat string
+
+const (
+	formatAtom feedFormat = "atom"
+	formatRSS  feedFormat = "rss"
+)
+
+// feed is a format-neutral feed, rendered as Atom or RSS
+type feed struct {
+	Title   string
+	Link    string // HTML page the feed is about
+	Self    string // URL of the feed itself
+	Updated time.Time
+	Entries []feedEntry
+}
+
+// feedEntry is a single incident or status transition in a feed
+type feedEntry struct {
+	ID        string
+	Title     string
+	Link      string
+	Published time.Time
+	Updated   time.Time
+	Summary   string
+}
+
+// IncidentsAtomFeed serves incidents as an Atom feed
+func (h *Handlers) IncidentsAtomFeed(c *gin.Context) {
+	h.serveFeed(c, "incidents", formatAtom)
+}
+
+// IncidentsRSSFeed serves incidents as an RSS feed
+func (h *Handlers) IncidentsRSSFeed(c *gin.Context) {
+	h.serveFeed(c, "incidents", formatRSS)
+}
+
+// StatusAtomFeed serves status transitions as an Atom feed
+func (h *Handlers) StatusAtomFeed(c *gin.Context) {
+	h.serveFeed(c, "status", formatAtom)
+}
+
+// StatusRSSFeed serves status transitions as an RSS feed
+func (h *Handlers) StatusRSSFeed(c *gin.Context) {
+	h.serveFeed(c, "status", formatRSS)
+}
+
+// serveFeed builds the incidents, certificates or status feed for the
+// services picked by the service_id and tag query parameters (all visible
+// services if none) and writes it with conditional GET support
+func (h *Handlers) serveFeed(c *gin.Context, kind string, format feedFormat) {
+	ctx := c.Request.Context()
+
+	services, err := h.serviceRepo.GetAll(ctx)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to fetch services")
+		return
+	}
+
+	serviceIDs, scope := feedServices(services, c.QueryArray("service_id"), c.QueryArray("tag"))
+
+	baseURL := requestBaseURL(c)
+	f := feed{
+		Link: baseURL + "/services",
+		Self: baseURL + c.Request.URL.RequestURI(),
+	}
+	if len(serviceIDs) == 1 {
+		f.Link = baseURL + "/services/" + serviceIDs[0]
+	}
+
+	since := time.Now().Add(-feedWindow)
+	switch kind {
+	case "incidents":
+		f.Title = "Incidents" + scope
+		if len(serviceIDs) > 0 {
+			incidents, err := h.incidentRepo.List(ctx, incident.Filter{
+				ServiceIDs: serviceIDs,
+				Since:      since,
+				Limit:      feedLimit,
+			})
+			if err != nil {
+				c.String(http.StatusInternalServerError, "Failed to fetch incidents")
+				return
+			}
+			for i := range incidents {
+				f.Entries = append(f.Entries, incidentEntry(&incidents[i], baseURL))
+			}
+		}
+	case "certificates":
+		f.Title = "Certificate alerts" + scope
+		if len(serviceIDs) > 0 {
+			alerts, err := h.certRepo.ListAlerts(ctx, serviceIDs, since, feedLimit)
+			if err != nil {
+				c.String(http.StatusInternalServerError, "Failed to fetch certificate alerts")
+				return
+			}
+			for i := range alerts {
+				f.Entries = append(f.Entries, certificateAlertEntry(&alerts[i], baseURL))
+			}
+		}
+	default:
+		f.Title = "Status changes" + scope
+		if len(serviceIDs) > 0 {
+			transitions, err := h.checkRepo.Transitions(ctx, serviceIDs, since, feedLimit)
+			if err != nil {
+				c.String(http.StatusInternalServerError, "Failed to fetch status changes")
+				return
+			}
+			for i := range transitions {
+				f.Entries = append(f.Entries, transitionEntry(&transitions[i], baseURL))
+			}
+		}
+	}
+
+	for _, entry := range f.Entries {
+		if entry.Updated.After(f.Updated) {
+			f.Updated = entry.Updated
+		}
+	}
+
+	var body []byte
+	var contentType string
+	if format == formatRSS {
+		body, err = f.rss()
+		contentType = "application/rss+xml; charset=utf-8"
+	} else {
+		body, err = f.atom()
+		contentType = "application/atom+xml; charset=utf-8"
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render feed")
+		return
+	}
+
+	writeConditional(c, contentType, body, f.Updated)
+}
+
+// feedServices picks the services a feed covers: those named by ID or
+// carrying one of the tags, or all of them if neither is given. It also
+// returns a suffix describing the selection for the feed title.
+func feedServices(services []service.Service, ids, tags []string) ([]string, string) {
+	wantIDs := make(map[string]bool)
+	for _, id := range ids {
+		wantIDs[id] = true
+	}
+	wantTags := make(map[string]bool)
+	for _, tag := range tags {
+		wantTags[tag] = true
+	}
+
+	var selected []string
+	var names []string
+	for _, svc := range services {
+		match := len(ids) == 0 && len(tags) == 0 || wantIDs[svc.ID]
+		for _, tag := range svc.Tags {
+			if wantTags[tag] {
+				match = true
+			}
+		}
+		if match {
+			selected = append(selected, svc.ID)
+			names = append(names, svc.Name)
+		}
+	}
+
+	switch {
+	case len(tags) > 0 && len(ids) == 0:
+		return selected, " tagged " + strings.Join(tags, ", ")
+	case len(ids) > 0 && len(names) > 0:
+		return selected, " for " + strings.Join(names, ", ")
+	}
+	return selected, ""
+}
+
+// incidentEntry describes an incident as a feed entry
+func incidentEntry(inc *incident.Incident, baseURL string) feedEntry {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Service: %s\n", inc.ServiceName)
+	fmt.Fprintf(&summary, "Started: %s\n", inc.StartedAt.UTC().Format(time.RFC3339))
+	if inc.ResolvedAt != nil {
+		fmt.Fprintf(&summary, "Resolved: %s\n", inc.ResolvedAt.UTC().Format(time.RFC3339))
+		fmt.Fprintf(&summary, "Duration: %s\n", formatFeedDuration(inc.Duration()))
+	} else {
+		summary.WriteString("Resolved: not yet\n")
+	}
+	if inc.Cause != "" {
+		fmt.Fprintf(&summary, "Error: %s\n", inc.Cause)
+	}
+	if inc.Message != "" {
+		fmt.Fprintf(&summary, "\n%s\n", inc.Message)
+	}
+
+	title := inc.ServiceName + ": " + inc.Title
+	if inc.Active() {
+		title += " (ongoing)"
+	} else {
+		title += " (resolved)"
+	}
+
+	return feedEntry{
+		ID:        "urn:pipeline-monitor:incident:" + inc.ID,
+		Title:     title,
+		Link:      baseURL + "/services/" + inc.ServiceID,
+		Published: inc.StartedAt,
+		Updated:   inc.UpdatedAt,
+		Summary:   summary.String(),
+	}
+}
+
+// transitionEntry describes a status transition as a feed entry
+func transitionEntry(t *service.Transition, baseURL string) feedEntry {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Service: %s\n", t.ServiceName)
+	fmt.Fprintf(&summary, "Changed: %s\n", t.At.UTC().Format(time.RFC3339))
+	if t.Since != nil {
+		fmt.Fprintf(&summary, "Was %s for %s\n", t.From, formatFeedDuration(t.Duration()))
+	}
+	if t.Error != "" {
+		fmt.Fprintf(&summary, "Error: %s\n", t.Error)
+	}
+
+	return feedEntry{
+		ID:        fmt.Sprintf("urn:pipeline-monitor:transition:%s:%d", t.ServiceID, t.At.UnixNano()),
+		Title:     fmt.Sprintf("%s is %s (was %s)", t.ServiceName, t.To, t.From),
+		Link:      baseURL + "/services/" + t.ServiceID,
+		Published: t.At,
+		Updated:   t.At,
+		Summary:   summary.String(),
+	}
+}
+
+// formatFeedDuration rounds a duration to whole seconds for display
+func formatFeedDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID        string   `xml:"id"`
+	Title     string   `xml:"title"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+	Summary   atomText `xml:"summary"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  string      `xml:"author>name"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atom renders the feed as Atom 1.0
+func (f *feed) atom() ([]byte, error) {
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+
+	doc := atomFeed{
+		ID:      f.Self,
+		Title:   f.Title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Author:  "Pipeline Monitor",
+		Links: []atomLink{
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, entry := range f.Entries {
+		doc.Entries = append(doc.Entries, atomEntry{
+			ID:        entry.ID,
+			Title:     entry.Title,
+			Link:      atomLink{Href: entry.Link, Rel: "alternate"},
+			Published: entry.Published.UTC().Format(time.RFC3339),
+			Updated:   entry.Updated.UTC().Format(time.RFC3339),
+			Summary:   atomText{Type: "text", Body: entry.Summary},
+		})
+	}
+
+	return marshalFeed(doc)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          atomLink  `xml:"http://www.w3.org/2005/Atom link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// rss renders the feed as RSS 2.0
+func (f *feed) rss() ([]byte, error) {
+	doc := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Title + " reported by Pipeline Monitor",
+			Self:        atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, entry := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			GUID:        rssGUID{Value: entry.ID},
+			PubDate:     entry.Published.UTC().Format(time.RFC1123Z),
+			Description: entry.Summary,
+		})
+	}
+
+	return marshalFeed(doc)
+}
+
+// marshalFeed encodes a feed document with an XML declaration
+func marshalFeed(doc any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeConditional writes a response body with ETag and Last-Modified
+// headers, answering 304 Not Modified when the client's copy is current
+func writeConditional(c *gin.Context, contentType string, body []byte, modified time.Time) {
+	sum := sha1.Sum(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if match == "*" || strings.Contains(match, etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	} else if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !modified.IsZero() {
+		if !modified.Truncate(time.Second).After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
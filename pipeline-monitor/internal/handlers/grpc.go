@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// grpcCheckForm holds the gRPC check fields of the service form
+type grpcCheckForm struct {
+	GRPCService    string `form:"grpc_service"`
+	GRPCTLS        bool   `form:"grpc_tls"`
+	GRPCClientCert string `form:"grpc_client_cert"`
+}
+
+// grpcCheck builds the gRPC check of a service from the form. Services
+// with other check types, and gRPC checks left at the defaults of
+// plaintext and the whole server, get none.
+func (f grpcCheckForm) grpcCheck(checkType string) *service.GRPCCheck {
+	if service.CheckType(checkType) != service.CheckGRPC {
+		return nil
+	}
+
+	check := &service.GRPCCheck{
+		Service:    strings.TrimSpace(f.GRPCService),
+		TLS:        f.GRPCTLS,
+		ClientCert: f.GRPCClientCert,
+	}
+	if *check == (service.GRPCCheck{}) {
+		return nil
+	}
+	return check
+}
+
+// clientCertNames returns the configured client certificates checks can
+// authenticate with
+func (h *Handlers) clientCertNames() []string {
+	names := make([]string, 0, len(h.config.ClientCerts))
+	for name := range h.config.ClientCerts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -3,10 +3,23 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
+	"pipeline-monitor/internal/config"
+	"pipeline-monitor/internal/domain/audit"
+	"pipeline-monitor/internal/domain/certificate"
+	"pipeline-monitor/internal/domain/event"
+	"pipeline-monitor/internal/domain/heartbeat"
+	"pipeline-monitor/internal/domain/incident"
+	"pipeline-monitor/internal/domain/pipeline"
+	"pipeline-monitor/internal/domain/revision"
+	"pipeline-monitor/internal/domain/secret"
 	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/snapshot"
+	"pipeline-monitor/internal/domain/statuspage"
+	"pipeline-monitor/internal/domain/team"
 	"pipeline-monitor/internal/infrastructure/monitor"
 
 	"github.com/gin-gonic/gin"
@@ -15,15 +28,61 @@ import (
 
 // Handlers contains all HTTP handlers for the application
 type Handlers struct {
-	serviceRepo service.Repository
-	monitor     *monitor.ServiceMonitor
+	config         *config.Config
+	serviceRepo    service.Repository
+	auditRepo      audit.Repository
+	revisionRepo   revision.Repository
+	teamRepo       team.Repository
+	checkRepo      service.CheckRepository
+	incidentRepo   incident.Repository
+	statusPageRepo statuspage.Repository
+	certRepo       certificate.Repository
+	snapshotRepo   snapshot.Repository
+	pingRepo       heartbeat.Repository
+	pipelineRepo   pipeline.Repository
+	eventRepo      event.Repository
+	secretRepo     secret.Repository
+	monitor        *monitor.ServiceMonitor
+}
+
+// Dependencies are the configuration, repositories and monitor the
+// handlers work with
+type Dependencies struct {
+	Config         *config.Config
+	ServiceRepo    service.Repository
+	AuditRepo      audit.Repository
+	RevisionRepo   revision.Repository
+	TeamRepo       team.Repository
+	CheckRepo      service.CheckRepository
+	IncidentRepo   incident.Repository
+	StatusPageRepo statuspage.Repository
+	CertRepo       certificate.Repository
+	SnapshotRepo   snapshot.Repository
+	PingRepo       heartbeat.Repository
+	PipelineRepo   pipeline.Repository
+	EventRepo      event.Repository
+	SecretRepo     secret.Repository
+	Monitor        *monitor.ServiceMonitor
 }
 
 // New creates a new handlers instance
-func New(repo service.Repository, monitor *monitor.ServiceMonitor) *Handlers {
+func New(deps Dependencies) *Handlers {
 	return &Handlers{
-		serviceRepo: repo,
-		monitor:     monitor,
+		config:         deps.Config,
+		serviceRepo:    deps.ServiceRepo,
+		auditRepo:      deps.AuditRepo,
+		revisionRepo:   deps.RevisionRepo,
+		teamRepo:       deps.TeamRepo,
+		checkRepo:      deps.CheckRepo,
+		incidentRepo:   deps.IncidentRepo,
+		statusPageRepo: deps.StatusPageRepo,
+		certRepo:       deps.CertRepo,
+		snapshotRepo:   deps.SnapshotRepo,
+		pingRepo:       deps.PingRepo,
+		pipelineRepo:   deps.PipelineRepo,
+		eventRepo:      deps.EventRepo,
+		secretRepo:     deps.SecretRepo,
+		monitor:        deps.Monitor,
 	}
 }
 
@@ -64,63 +123,96 @@ func (h *Handlers) ListServices(c *gin.Context) {
 	c.HTML(http.StatusOK, "services/list.html", gin.H{
 		"title":    "Services",
 		"services": services,
+		"selector": c.Query("selector"),
 	})
 }
 
 // NewServiceForm shows the form to create a new service
 func (h *Handlers) NewServiceForm(c *gin.Context) {
-	c.HTML(http.StatusOK, "services/form.html", gin.H{
-		"title":   "Add New Service",
-		"service": &service.Service{}, // Empty service for new form
-		"isEdit":  false,
-	})
+	// Empty service for new form
+	svc := &service.Service{TeamID: h.owningTeam(c, "")}
+	c.HTML(http.StatusOK, "services/form.html", h.serviceFormData(c, svc, ""))
 }
 
 // CreateService handles service creation
 func (h *Handlers) CreateService(c *gin.Context) {
 	var req struct {
-		Name        string   `form:"name" binding:"required"`
-		URL         string   `form:"url" binding:"required,url"`
-		Description string   `form:"description"`
-		Tags        []string `form:"tags"`
-	}
-
-	if err := c.ShouldBind(&req); err != nil {
-		c.HTML(http.StatusBadRequest, "services/form.html", gin.H{
-			"title": "Add New Service",
-			"error": "Invalid form data: " + err.Error(),
-			"service": &service.Service{
-				Name:        req.Name,
-				URL:         req.URL,
-				Description: req.Description,
-				Tags:        req.Tags,
-			},
-			"isEdit": false,
-		})
-		return
+		Name            string   `form:"name" binding:"required"`
+		URL             string   `form:"url"`
+		Description     string   `form:"description"`
+		Tags            []string `form:"tags"`
+		Labels          string   `form:"labels"`
+		TeamID          string   `form:"team_id"`
+		CheckType       string   `form:"check_type"`
+		HeartbeatPeriod int      `form:"heartbeat_period"`
+		HeartbeatGrace  int      `form:"heartbeat_grace"`
+		sqlCheckForm
+		execCheckForm
+		grpcCheckForm
+		protocolCheckForm
+		oauth2CheckForm
+		transportForm
+		syntheticCheckForm
+	}
+
+	err := c.ShouldBind(&req)
+	labels, labelsErr := service.ParseLabels(req.Labels)
+	if err == nil && labelsErr != nil {
+		err = labelsErr
 	}
 
 	newService := &service.Service{
-		ID:          uuid.New().String(),
-		Name:        req.Name,
-		URL:         req.URL,
-		Description: req.Description,
-		Tags:        req.Tags,
-		Status:      service.StatusUnknown,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              uuid.New().String(),
+		Name:            req.Name,
+		URL:             req.URL,
+		Description:     req.Description,
+		Tags:            req.Tags,
+		Labels:          labels,
+		TeamID:          h.owningTeam(c, req.TeamID),
+		CheckType:       service.CheckType(req.CheckType),
+		HeartbeatPeriod: req.HeartbeatPeriod,
+		HeartbeatGrace:  req.HeartbeatGrace,
+		Status:          service.StatusUnknown,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if err == nil {
+		newService.SQL, err = req.sqlCheck(req.CheckType)
+	}
+	if err == nil {
+		newService.Exec, err = req.execCheck(req.CheckType)
+	}
+	if err == nil {
+		newService.Synthetic, err = req.syntheticCheck(req.CheckType)
+	}
+	newService.GRPC = req.grpcCheck(req.CheckType)
+	newService.Database = req.databaseCheck(req.CheckType)
+	newService.Redis = req.redisCheck(req.CheckType)
+	newService.OAuth2 = req.oauth2Check(req.CheckType)
+	newService.Transport = req.transport(req.CheckType)
+	if err == nil {
+		err = h.prepareCheck(c.Request.Context(), newService)
+	}
+	if err != nil {
+		data := h.serviceFormData(c, newService, "Invalid form data: "+err.Error())
+		data["labels"] = req.Labels
+		c.HTML(http.StatusBadRequest, "services/form.html", data)
+		return
+	}
+
+	if !h.canEditService(c, newService.TeamID) {
+		c.HTML(http.StatusForbidden, "services/form.html", h.serviceFormData(c, newService, "You cannot add services to this team"))
+		return
 	}
 
 	if err := h.serviceRepo.Create(c.Request.Context(), newService); err != nil {
-		c.HTML(http.StatusInternalServerError, "services/form.html", gin.H{
-			"title":   "Add New Service",
-			"error":   "Failed to create service: " + err.Error(),
-			"service": newService,
-			"isEdit":  false,
-		})
+		c.HTML(http.StatusInternalServerError, "services/form.html", h.serviceFormData(c, newService, "Failed to create service: "+err.Error()))
 		return
 	}
 
+	h.recordAudit(c, audit.SourceUI, audit.ActionCreate, nil, newService)
+	h.recordRevision(c, newService)
+
 	// Check if this is an HTMX request
 	if c.GetHeader("HX-Request") == "true" {
 		// Return updated services table
@@ -146,9 +238,20 @@ func (h *Handlers) GetService(c *gin.Context) {
 		return
 	}
 
+	chain, err := h.certRepo.Get(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("Failed to load certificate chain of service %s: %v", id, err)
+	}
+
 	c.HTML(http.StatusOK, "services/detail.html", gin.H{
-		"title":   "Service: " + svc.Name,
-		"service": svc,
+		"title":       "Service: " + svc.Name,
+		"service":     svc,
+		"certificate": chain,
+		"pingURL":     pingURL(requestBaseURL(c), svc),
+		"baseURL":     requestBaseURL(c),
+		"statusURL":   badgeURL(svc, "status.svg"),
+		"uptimeURL":   badgeURL(svc, "uptime.svg"),
+		"canEdit":     h.canEditService(c, svc.TeamID),
 	})
 }
 
@@ -163,11 +266,38 @@ func (h *Handlers) EditServiceForm(c *gin.Context) {
 		return
 	}
 
-	c.HTML(http.StatusOK, "services/form.html", gin.H{
-		"title":   "Edit Service: " + svc.Name,
-		"service": svc,
-		"isEdit":  true,
-	})
+	c.HTML(http.StatusOK, "services/form.html", h.serviceFormData(c, svc, ""))
+}
+
+// serviceFormData returns what the service form renders with: the
+// service, the error to show (if any), and the teams, data sources,
+// commands, certificates and secrets it offers. Routes with a service ID
+// edit that service; the others add one.
+func (h *Handlers) serviceFormData(c *gin.Context, svc *service.Service, errMsg string) gin.H {
+	isEdit := c.Param("id") != ""
+	title := "Add New Service"
+	if isEdit {
+		title = "Edit Service"
+		if svc != nil {
+			title += ": " + svc.Name
+		}
+	}
+
+	data := gin.H{
+		"title":        title,
+		"service":      svc,
+		"teams":        h.editableTeams(c),
+		"dataSources":  h.dataSourceNames(),
+		"execCommands": h.execCommandNames(),
+		"clientCerts":  h.clientCertNames(),
+		"caBundles":    h.caBundleNames(),
+		"secrets":      h.secretNames(c),
+		"isEdit":       isEdit,
+	}
+	if errMsg != "" {
+		data["error"] = errMsg
+	}
+	return data
 }
 
 // UpdateService handles service updates
@@ -175,20 +305,34 @@ func (h *Handlers) UpdateService(c *gin.Context) {
 	id := c.Param("id")
 
 	var req struct {
-		Name        string   `form:"name" binding:"required"`
-		URL         string   `form:"url" binding:"required,url"`
-		Description string   `form:"description"`
-		Tags        []string `form:"tags"`
+		Name            string   `form:"name" binding:"required"`
+		URL             string   `form:"url"`
+		Description     string   `form:"description"`
+		Tags            []string `form:"tags"`
+		Labels          string   `form:"labels"`
+		TeamID          string   `form:"team_id"`
+		CheckType       string   `form:"check_type"`
+		HeartbeatPeriod int      `form:"heartbeat_period"`
+		HeartbeatGrace  int      `form:"heartbeat_grace"`
+		sqlCheckForm
+		execCheckForm
+		grpcCheckForm
+		protocolCheckForm
+		oauth2CheckForm
+		transportForm
+		syntheticCheckForm
+	}
+
+	err := c.ShouldBind(&req)
+	labels, labelsErr := service.ParseLabels(req.Labels)
+	if err == nil && labelsErr != nil {
+		err = labelsErr
 	}
-
-	if err := c.ShouldBind(&req); err != nil {
+	if err != nil {
 		svc, _ := h.serviceRepo.GetByID(c.Request.Context(), id)
-		c.HTML(http.StatusBadRequest, "services/form.html", gin.H{
-			"title":   "Edit Service",
-			"error":   "Invalid form data: " + err.Error(),
-			"service": svc,
-			"isEdit":  true,
-		})
+		data := h.serviceFormData(c, svc, "Invalid form data: "+err.Error())
+		data["labels"] = req.Labels
+		c.HTML(http.StatusBadRequest, "services/form.html", data)
 		return
 	}
 
@@ -201,23 +345,57 @@ func (h *Handlers) UpdateService(c *gin.Context) {
 		return
 	}
 
+	before := svc.Clone()
+
 	// Update fields
 	svc.Name = req.Name
 	svc.URL = req.URL
 	svc.Description = req.Description
 	svc.Tags = req.Tags
+	svc.Labels = labels
+	if req.TeamID != "" {
+		svc.TeamID = req.TeamID
+	}
+	svc.CheckType = service.CheckType(req.CheckType)
+	svc.HeartbeatPeriod = req.HeartbeatPeriod
+	svc.HeartbeatGrace = req.HeartbeatGrace
 	svc.UpdatedAt = time.Now()
 
+	svc.SQL, err = req.sqlCheck(req.CheckType)
+	if err == nil {
+		svc.Exec, err = req.execCheck(req.CheckType)
+	}
+	if err == nil {
+		svc.Synthetic, err = req.syntheticCheck(req.CheckType)
+	}
+	svc.GRPC = req.grpcCheck(req.CheckType)
+	svc.Database = req.databaseCheck(req.CheckType)
+	svc.Redis = req.redisCheck(req.CheckType)
+	svc.OAuth2 = req.oauth2Check(req.CheckType)
+	svc.Transport = req.transport(req.CheckType)
+	if err == nil {
+		err = h.prepareCheck(c.Request.Context(), svc)
+	}
+	if err != nil {
+		data := h.serviceFormData(c, svc, "Invalid form data: "+err.Error())
+		data["labels"] = req.Labels
+		c.HTML(http.StatusBadRequest, "services/form.html", data)
+		return
+	}
+
+	if !h.canEditService(c, before.TeamID) || !h.canEditService(c, svc.TeamID) {
+		c.HTML(http.StatusForbidden, "services/form.html", h.serviceFormData(c, svc, "You cannot change services owned by this team"))
+		return
+	}
+
 	if err := h.serviceRepo.Update(c.Request.Context(), svc); err != nil {
-		c.HTML(http.StatusInternalServerError, "services/form.html", gin.H{
-			"title":   "Edit Service",
-			"error":   "Failed to update service: " + err.Error(),
-			"service": svc,
-			"isEdit":  true,
-		})
+		c.HTML(http.StatusInternalServerError, "services/form.html", h.serviceFormData(c, svc, "Failed to update service: "+err.Error()))
 		return
 	}
 
+	h.recordAudit(c, audit.SourceUI, audit.ActionUpdate, before, svc)
+	h.recordRevision(c, svc)
+
 	// Check if this is an HTMX request
 	if c.GetHeader("HX-Request") == "true" {
 		// Return updated service row
@@ -234,6 +412,19 @@ func (h *Handlers) UpdateService(c *gin.Context) {
 func (h *Handlers) DeleteService(c *gin.Context) {
 	id := c.Param("id")
 
+	before, _ := h.serviceRepo.GetByID(c.Request.Context(), id)
+	if before != nil && !h.canEditService(c, before.TeamID) {
+		if c.GetHeader("HX-Request") == "true" {
+			c.Header("HX-Trigger", "error")
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "You cannot delete services owned by this team",
+		})
+		return
+	}
+
 	err := h.serviceRepo.Delete(c.Request.Context(), id)
 	if err != nil {
 		if c.GetHeader("HX-Request") == "true" {
@@ -247,6 +438,10 @@ func (h *Handlers) DeleteService(c *gin.Context) {
 		return
 	}
 
+	if before != nil {
+		h.recordAudit(c, audit.SourceUI, audit.ActionDelete, before, nil)
+	}
+
 	// For HTMX requests, return empty content (the row will be removed)
 	if c.GetHeader("HX-Request") == "true" {
 		c.Status(http.StatusOK)
@@ -275,9 +470,18 @@ func (h *Handlers) ServiceStatusPartial(c *gin.Context) {
 	})
 }
 
-// ServicesTablePartial returns the complete services table
+// ServicesTablePartial returns a filtered page of the services table.
+// Requests for a later page only return the additional rows.
 func (h *Handlers) ServicesTablePartial(c *gin.Context) {
-	services, err := h.serviceRepo.GetAll(c.Request.Context())
+	query, err := parseServiceQuery(c)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "partials/services-table.html", gin.H{
+			"error": "Invalid label selector: " + err.Error(),
+		})
+		return
+	}
+
+	page, err := h.serviceRepo.Find(c.Request.Context(), query)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "partials/services-table.html", gin.H{
 			"error": "Failed to load services",
@@ -286,7 +490,9 @@ func (h *Handlers) ServicesTablePartial(c *gin.Context) {
 	}
 
 	c.HTML(http.StatusOK, "partials/services-table.html", gin.H{
-		"services": services,
+		"services":   page.Services,
+		"nextURL":    nextPageURL(c, page.NextCursor),
+		"appendOnly": query.Cursor != "",
 	})
 }
 
@@ -310,19 +516,28 @@ func (h *Handlers) DashboardStatsPartial(c *gin.Context) {
 
 // API Handlers (JSON responses for external consumption)
 
-// APIListServices returns services as JSON
+// APIListServices returns a filtered page of services as JSON
 func (h *Handlers) APIListServices(c *gin.Context) {
-	services, err := h.serviceRepo.GetAll(c.Request.Context())
+	query, err := parseServiceQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch services",
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid label selector: " + err.Error(),
+		})
+		return
+	}
+
+	page, err := h.serviceRepo.Find(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to fetch services: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"services": services,
-		"count":    len(services),
+		"services":    page.Services,
+		"count":       len(page.Services),
+		"next_cursor": page.NextCursor,
 	})
 }
 
@@ -350,11 +565,35 @@ func (h *Handlers) APICreateService(c *gin.Context) {
 		return
 	}
 
+	if err := service.ValidateLabels(req.Labels); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	req.PingToken = ""
+	req.TeamID = h.owningTeam(c, req.TeamID)
+	if err := h.prepareCheck(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	req.ID = uuid.New().String()
+	req.BadgeToken = ""
 	req.Status = service.StatusUnknown
 	req.CreatedAt = time.Now()
 	req.UpdatedAt = time.Now()
 
+	if !h.canEditService(c, req.TeamID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You cannot add services to this team",
+		})
+		return
+	}
+
 	if err := h.serviceRepo.Create(c.Request.Context(), &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create service: " + err.Error(),
@@ -362,6 +601,9 @@ func (h *Handlers) APICreateService(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, audit.SourceAPI, audit.ActionCreate, nil, &req)
+	h.recordRevision(c, &req)
+
 	c.JSON(http.StatusCreated, req)
 }
 
@@ -377,6 +619,11 @@ func (h *Handlers) APIUpdateService(c *gin.Context) {
 		return
 	}
 
+	before := svc.Clone()
+
+	// Decode labels into a fresh map so that a request replaces them
+	// rather than merging into the stored ones
+	svc.Labels = nil
 	if err := c.ShouldBindJSON(svc); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid JSON: " + err.Error(),
@@ -384,8 +631,35 @@ func (h *Handlers) APIUpdateService(c *gin.Context) {
 		return
 	}
 
+	if err := service.ValidateLabels(svc.Labels); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	svc.ID = before.ID
+	svc.BadgeToken = before.BadgeToken
+	svc.PingToken = before.PingToken
+	if svc.Labels == nil {
+		svc.Labels = before.Labels
+	}
 	svc.UpdatedAt = time.Now()
 
+	if err := h.prepareCheck(c.Request.Context(), svc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !h.canEditService(c, before.TeamID) || !h.canEditService(c, svc.TeamID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You cannot change services owned by this team",
+		})
+		return
+	}
+
 	if err := h.serviceRepo.Update(c.Request.Context(), svc); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update service: " + err.Error(),
@@ -393,6 +667,9 @@ func (h *Handlers) APIUpdateService(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, audit.SourceAPI, audit.ActionUpdate, before, svc)
+	h.recordRevision(c, svc)
+
 	c.JSON(http.StatusOK, svc)
 }
 
@@ -400,6 +677,14 @@ func (h *Handlers) APIUpdateService(c *gin.Context) {
 func (h *Handlers) APIDeleteService(c *gin.Context) {
 	id := c.Param("id")
 
+	before, _ := h.serviceRepo.GetByID(c.Request.Context(), id)
+	if before != nil && !h.canEditService(c, before.TeamID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You cannot delete services owned by this team",
+		})
+		return
+	}
+
 	if err := h.serviceRepo.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete service: " + err.Error(),
@@ -407,6 +692,10 @@ func (h *Handlers) APIDeleteService(c *gin.Context) {
 		return
 	}
 
+	if before != nil {
+		h.recordAudit(c, audit.SourceAPI, audit.ActionDelete, before, nil)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Service deleted successfully",
 	})
@@ -443,6 +732,11 @@ func (h *Handlers) ServiceUpdatesSSE(c *gin.Context) {
 				return
 			}
 
+			// Only forward updates for services the caller can see
+			if _, err := h.serviceRepo.GetByID(c.Request.Context(), update.ServiceID); err != nil {
+				continue
+			}
+
 			// Send update as SSE
 			data := fmt.Sprintf("data: {\"type\":\"service_update\",\"service_id\":\"%s\",\"status\":\"%s\",\"response_time\":%d}\n\n",
 				update.ServiceID, update.Status, update.ResponseTime)
@@ -466,6 +760,7 @@ func (h *Handlers) getStatusCounts(ctx context.Context) (map[string]int, error)
 		"healthy":   0,
 		"unhealthy": 0,
 		"timeout":   0,
+		"warning":   0,
 		"unknown":   0,
 	}
 
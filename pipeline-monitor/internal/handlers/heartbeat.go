@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/heartbeat"
+	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/team"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recentPings is how many pings the service page and the API show
+const recentPings = 50
+
+// PingSuccess records a successful run of a heartbeat service
+func (h *Handlers) PingSuccess(c *gin.Context) {
+	h.recordPing(c, heartbeat.KindSuccess)
+}
+
+// PingStart records the start of a run of a heartbeat service
+func (h *Handlers) PingStart(c *gin.Context) {
+	h.recordPing(c, heartbeat.KindStart)
+}
+
+// PingFail records a failed run of a heartbeat service
+func (h *Handlers) PingFail(c *gin.Context) {
+	h.recordPing(c, heartbeat.KindFail)
+}
+
+// recordPing stores a ping of the heartbeat service named by the token in
+// the URL, keeping the request body (if any) as the log of the run, and
+// re-evaluates the service straight away. Pings are served without auth;
+// the token is the credential.
+func (h *Handlers) recordPing(c *gin.Context, kind heartbeat.Kind) {
+	ctx := team.WithoutCaller(c.Request.Context())
+
+	svc, err := h.serviceRepo.GetByPingToken(ctx, c.Param("token"))
+	if err != nil {
+		c.String(http.StatusNotFound, "not found")
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, heartbeat.MaxPayload))
+	if err != nil {
+		c.String(http.StatusBadRequest, "failed to read payload")
+		return
+	}
+
+	ping := &heartbeat.Ping{
+		ServiceID:  svc.ID,
+		Kind:       kind,
+		Payload:    strings.ToValidUTF8(string(payload), "�"),
+		RemoteAddr: c.ClientIP(),
+		ReceivedAt: time.Now(),
+	}
+	if err := h.pingRepo.Record(ctx, ping); err != nil {
+		c.String(http.StatusInternalServerError, "failed to record ping")
+		return
+	}
+
+	if kind != heartbeat.KindStart {
+		h.monitor.CheckNow(*svc)
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// ServicePingsPartial renders the latest pings of a heartbeat service
+func (h *Handlers) ServicePingsPartial(c *gin.Context) {
+	id := c.Param("id")
+
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "partials/service-pings.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	pings, err := h.pingRepo.List(c.Request.Context(), id, recentPings)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/service-pings.html", gin.H{
+			"error": "Failed to load pings",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "partials/service-pings.html", gin.H{
+		"service": svc,
+		"pings":   pings,
+	})
+}
+
+// APIListPings returns the latest pings of a heartbeat service as JSON,
+// newest first
+func (h *Handlers) APIListPings(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	pings, err := h.pingRepo.List(c.Request.Context(), id, recentPings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch pings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pings": pings,
+		"count": len(pings),
+	})
+}
+
+// assignPingToken gives a heartbeat service a ping token if it has none yet
+func assignPingToken(svc *service.Service) error {
+	if svc.CheckType != service.CheckHeartbeat || svc.PingToken != "" {
+		return nil
+	}
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	svc.PingToken = token
+	return nil
+}
+
+// pingURL returns the ping URL of a heartbeat service
+func pingURL(baseURL string, svc *service.Service) string {
+	if svc.PingToken == "" {
+		return ""
+	}
+	return baseURL + "/ping/" + svc.PingToken
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"pipeline-monitor/internal/domain/incident"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShowIncident shows an incident with the responses captured while it
+// was open
+func (h *Handlers) ShowIncident(c *gin.Context) {
+	inc, err := h.incidentRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Incident not found",
+		})
+		return
+	}
+
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), inc.ServiceID)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "incidents/detail.html", gin.H{
+		"title":    "Incident: " + inc.Title,
+		"incident": inc,
+		"service":  svc,
+		"timeline": h.incidentTimeline(c, inc, svc.Tags),
+	})
+}
+
+// CreateIncident opens an incident by hand, e.g. for planned maintenance or
+// a problem the health checks cannot see
+func (h *Handlers) CreateIncident(c *gin.Context) {
+	var req struct {
+		ServiceID string `form:"service_id" binding:"required"`
+		Title     string `form:"title" binding:"required"`
+		Message   string `form:"message"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "A service and a title are required",
+		})
+		return
+	}
+
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), req.ServiceID)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	if !h.canEditService(c, svc.TeamID) {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "You cannot open incidents for services owned by this team",
+		})
+		return
+	}
+
+	inc := &incident.Incident{
+		ServiceID: svc.ID,
+		Title:     req.Title,
+		Message:   req.Message,
+		StartedAt: time.Now(),
+	}
+	if err := h.incidentRepo.Create(c.Request.Context(), inc); err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to open incident (is one already open for this service?): " + err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/status-page")
+}
+
+// UpdateIncident changes the title and the public message of an incident
+func (h *Handlers) UpdateIncident(c *gin.Context) {
+	inc, ok := h.editableIncident(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Title   string `form:"title" binding:"required"`
+		Message string `form:"message"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "An incident title is required",
+		})
+		return
+	}
+
+	inc.Title = req.Title
+	inc.Message = req.Message
+	if err := h.incidentRepo.Update(c.Request.Context(), inc); err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to update incident: " + err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/status-page")
+}
+
+// ResolveIncident closes an incident by hand
+func (h *Handlers) ResolveIncident(c *gin.Context) {
+	inc, ok := h.editableIncident(c)
+	if !ok {
+		return
+	}
+
+	if err := h.incidentRepo.Resolve(c.Request.Context(), inc.ID, time.Now()); err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to resolve incident: " + err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/status-page")
+}
+
+// APIListIncidents returns incidents as JSON, newest first. Pass
+// active=true for open incidents only and service_id to pick services.
+func (h *Handlers) APIListIncidents(c *gin.Context) {
+	filter := incident.Filter{
+		ActiveOnly: c.Query("active") == "true",
+		Limit:      defaultAuditLimit,
+	}
+	if ids := c.QueryArray("service_id"); len(ids) > 0 {
+		filter.ServiceIDs = ids
+	}
+
+	incidents, err := h.incidentRepo.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch incidents",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"incidents": incidents,
+		"count":     len(incidents),
+	})
+}
+
+// editableIncident loads the incident named in the URL and checks the
+// caller may edit its service, rendering an error page otherwise
+func (h *Handlers) editableIncident(c *gin.Context) (*incident.Incident, bool) {
+	inc, err := h.incidentRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Incident not found",
+		})
+		return nil, false
+	}
+
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), inc.ServiceID)
+	if err != nil || !h.canEditService(c, svc.TeamID) {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "You cannot change incidents of services owned by this team",
+		})
+		return nil, false
+	}
+
+	return inc, true
+}
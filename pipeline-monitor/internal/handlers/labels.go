@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/audit"
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bulk actions accepted by APIBulkServices
+const (
+	BulkDelete  = "delete"
+	BulkLabel   = "label"
+	BulkUnlabel = "unlabel"
+)
+
+// BulkRequest applies one action to every service matching a selector
+type BulkRequest struct {
+	Selector string            `json:"selector"`
+	Action   string            `json:"action"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Keys     []string          `json:"keys,omitempty"`
+	DryRun   bool              `json:"dry_run"`
+}
+
+// BulkSkip explains why a matching service was left untouched
+type BulkSkip struct {
+	ServiceID string `json:"service_id"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+}
+
+// ListLabels renders the label cardinality overview
+func (h *Handlers) ListLabels(c *gin.Context) {
+	stats, err := h.serviceRepo.LabelCardinality(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load labels",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "labels/list.html", gin.H{
+		"title":  "Labels",
+		"labels": stats,
+	})
+}
+
+// APIListLabels returns every label key with its values and the number of
+// services using each of them
+func (h *Handlers) APIListLabels(c *gin.Context) {
+	stats, err := h.serviceRepo.LabelCardinality(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch labels",
+		})
+		return
+	}
+
+	if stats == nil {
+		stats = []service.LabelStats{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"labels": stats,
+		"count":  len(stats),
+	})
+}
+
+// APIBulkServices deletes, labels or unlabels every service matching a
+// label selector. Services the caller cannot edit are skipped and reported.
+func (h *Handlers) APIBulkServices(c *gin.Context) {
+	var req BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid JSON: " + err.Error(),
+		})
+		return
+	}
+
+	selector, err := service.ParseSelector(req.Selector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid label selector: " + err.Error(),
+		})
+		return
+	}
+	if len(selector) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A non-empty selector is required for bulk operations",
+		})
+		return
+	}
+
+	switch req.Action {
+	case BulkDelete:
+	case BulkLabel:
+		if len(req.Labels) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "labels are required for the label action"})
+			return
+		}
+		if err := service.ValidateLabels(req.Labels); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	case BulkUnlabel:
+		if len(req.Keys) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "keys are required for the unlabel action"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("action must be one of %s, %s or %s", BulkDelete, BulkLabel, BulkUnlabel),
+		})
+		return
+	}
+
+	services, err := h.serviceRepo.GetAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch services",
+		})
+		return
+	}
+
+	matched := []string{}
+	updated := []string{}
+	skipped := []BulkSkip{}
+
+	for i := range services {
+		svc := &services[i]
+		if !selector.Matches(svc.Labels) {
+			continue
+		}
+		matched = append(matched, svc.ID)
+
+		if !h.canEditService(c, svc.TeamID) {
+			skipped = append(skipped, BulkSkip{svc.ID, svc.Name, "you cannot change services owned by this team"})
+			continue
+		}
+		if req.DryRun {
+			continue
+		}
+
+		if err := h.applyBulkAction(c, svc, &req); err != nil {
+			skipped = append(skipped, BulkSkip{svc.ID, svc.Name, err.Error()})
+			continue
+		}
+		updated = append(updated, svc.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"selector": selector.String(),
+		"action":   req.Action,
+		"dry_run":  req.DryRun,
+		"matched":  matched,
+		"updated":  updated,
+		"skipped":  skipped,
+	})
+}
+
+// applyBulkAction applies a bulk action to one service and records it
+func (h *Handlers) applyBulkAction(c *gin.Context, svc *service.Service, req *BulkRequest) error {
+	ctx := c.Request.Context()
+
+	if req.Action == BulkDelete {
+		if err := h.serviceRepo.Delete(ctx, svc.ID); err != nil {
+			return err
+		}
+		h.recordAudit(c, audit.SourceAPI, audit.ActionDelete, svc, nil)
+		return nil
+	}
+
+	before := svc.Clone()
+	if svc.Labels == nil {
+		svc.Labels = make(map[string]string)
+	}
+	for key, value := range req.Labels {
+		svc.Labels[key] = value
+	}
+	for _, key := range req.Keys {
+		delete(svc.Labels, key)
+	}
+	svc.UpdatedAt = time.Now()
+
+	if err := h.serviceRepo.Update(ctx, svc); err != nil {
+		return err
+	}
+
+	h.recordAudit(c, audit.SourceAPI, audit.ActionUpdate, before, svc)
+	h.recordRevision(c, svc)
+	return nil
+}
+
+// invalidMetricChars matches characters not allowed in Prometheus label names
+var invalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// labelValueEscaper escapes the characters the Prometheus text format does
+// not allow unescaped in label values
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Metrics exposes service status and response times in the Prometheus text
+// format. Service labels become metric labels prefixed with "label_", and
+// the optional selector parameter limits which services are exported.
+func (h *Handlers) Metrics(c *gin.Context) {
+	selector, err := service.ParseSelector(c.Query("selector"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "invalid label selector: %s\n", err.Error())
+		return
+	}
+
+	services, err := h.serviceRepo.GetAll(c.Request.Context())
+	if err != nil {
+		c.String(http.StatusInternalServerError, "failed to fetch services\n")
+		return
+	}
+
+	var up, responseTime strings.Builder
+	up.WriteString("# HELP pipeline_monitor_service_up Whether the service is up, including with a warning.\n")
+	up.WriteString("# TYPE pipeline_monitor_service_up gauge\n")
+	responseTime.WriteString("# HELP pipeline_monitor_service_response_time_ms Response time of the last health check in milliseconds.\n")
+	responseTime.WriteString("# TYPE pipeline_monitor_service_response_time_ms gauge\n")
+
+	for i := range services {
+		svc := &services[i]
+		if !selector.Matches(svc.Labels) {
+			continue
+		}
+
+		labels := metricLabels(svc)
+		value := 0
+		if svc.Status.IsUp() {
+			value = 1
+		}
+		fmt.Fprintf(&up, "pipeline_monitor_service_up{%s} %d\n", labels, value)
+		fmt.Fprintf(&responseTime, "pipeline_monitor_service_response_time_ms{%s} %d\n", labels, svc.ResponseTime)
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8",
+		[]byte(up.String()+responseTime.String()))
+}
+
+// metricLabels formats the Prometheus label set of a service. Label keys
+// that sanitize to the same name as an earlier key, in sorted order, are
+// left out, as a label may only appear once.
+func metricLabels(svc *service.Service) string {
+	pairs := []string{
+		metricLabel("service_id", svc.ID),
+		metricLabel("service_name", svc.Name),
+		metricLabel("status", string(svc.Status)),
+	}
+
+	keys := make([]string, 0, len(svc.Labels))
+	for key := range svc.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		name := "label_" + invalidMetricChars.ReplaceAllString(key, "_")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		pairs = append(pairs, metricLabel(name, svc.Labels[key]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// metricLabel formats a single name="value" label pair
+func metricLabel(name, value string) string {
+	return name + `="` + labelValueEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"strings"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// oauth2CheckForm holds the OAuth2 fields of the service form. Scopes are
+// separated by spaces or commas.
+type oauth2CheckForm struct {
+	OAuth2TokenURL string `form:"oauth2_token_url"`
+	OAuth2ClientID string `form:"oauth2_client_id"`
+	OAuth2Secret   string `form:"oauth2_secret"`
+	OAuth2Scopes   string `form:"oauth2_scopes"`
+}
+
+// oauth2Check builds the OAuth2 settings of an HTTP service from the form.
+// Services with other check types, and HTTP checks without a token URL,
+// get none.
+func (f oauth2CheckForm) oauth2Check(checkType string) *service.OAuth2Check {
+	if t := service.CheckType(checkType); t != "" && t != service.CheckHTTP {
+		return nil
+	}
+	if strings.TrimSpace(f.OAuth2TokenURL) == "" {
+		return nil
+	}
+
+	check := &service.OAuth2Check{
+		TokenURL: strings.TrimSpace(f.OAuth2TokenURL),
+		ClientID: strings.TrimSpace(f.OAuth2ClientID),
+		Secret:   f.OAuth2Secret,
+	}
+	for _, scope := range strings.FieldsFunc(f.OAuth2Scopes, func(r rune) bool { return r == ',' || r == ' ' }) {
+		check.Scopes = append(check.Scopes, scope)
+	}
+	return check
+}
@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"pipeline-monitor/internal/domain/pipeline"
+	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/team"
+	"pipeline-monitor/internal/infrastructure/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxWebhookPayload caps the size of webhook deliveries, in bytes
+	maxWebhookPayload = 5 << 20
+
+	// trendRuns is how many of the latest runs pipeline trends cover
+	trendRuns = 20
+
+	// maxPipelineRuns caps how many runs a pipeline page or API call lists
+	maxPipelineRuns = 200
+)
+
+// GitHubWebhook ingests GitHub Actions workflow_run and workflow_job
+// deliveries, signed with GITHUB_WEBHOOK_SECRET
+func (h *Handlers) GitHubWebhook(c *gin.Context) {
+	if h.config.GitHubWebhookSecret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "GitHub webhooks are not configured",
+		})
+		return
+	}
+
+	body, ok := readWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := webhook.VerifyGitHub(h.config.GitHubWebhookSecret, body, c.GetHeader("X-Hub-Signature-256")); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	event, err := webhook.ParseGitHub(c.GetHeader("X-GitHub-Event"), body)
+	h.recordWebhook(c, event, err)
+}
+
+// GitLabWebhook ingests GitLab pipeline events, authenticated with the
+// secret token GITLAB_WEBHOOK_TOKEN
+func (h *Handlers) GitLabWebhook(c *gin.Context) {
+	if h.config.GitLabWebhookToken == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "GitLab webhooks are not configured",
+		})
+		return
+	}
+
+	if err := webhook.VerifyGitLab(h.config.GitLabWebhookToken, c.GetHeader("X-Gitlab-Token")); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	body, ok := readWebhook(c)
+	if !ok {
+		return
+	}
+
+	event, err := webhook.ParseGitLab(body)
+	h.recordWebhook(c, event, err)
+}
+
+// readWebhook reads the body of a webhook delivery
+func readWebhook(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookPayload+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read payload",
+		})
+		return nil, false
+	}
+	if len(body) > maxWebhookPayload {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Payload too large",
+		})
+		return nil, false
+	}
+	return body, true
+}
+
+// recordWebhook stores the run update of a verified delivery. Webhooks are
+// served without auth; the signature is the credential.
+func (h *Handlers) recordWebhook(c *gin.Context, event *webhook.Event, err error) {
+	if errors.Is(err, webhook.ErrUnsupportedEvent) {
+		c.JSON(http.StatusAccepted, gin.H{
+			"status": "ignored",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx := team.WithoutCaller(c.Request.Context())
+	if err := h.pipelineRepo.Record(ctx, &event.Pipeline, &event.Run); err != nil {
+		log.Printf("Failed to record %s pipeline run: %v", event.Pipeline.Provider, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to record pipeline run",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pipeline_id": event.Pipeline.ID,
+		"run":         event.Run,
+	})
+}
+
+// ListPipelines renders all pipelines with their latest runs and trends
+func (h *Handlers) ListPipelines(c *gin.Context) {
+	pipelines, err := h.pipelineRepo.List(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load pipelines",
+		})
+		return
+	}
+
+	summaries, err := h.summarize(c.Request.Context(), pipelines)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load pipeline runs",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "pipelines/list.html", gin.H{
+		"title":     "Pipelines",
+		"pipelines": summaries,
+		"baseURL":   requestBaseURL(c),
+	})
+}
+
+// ShowPipeline renders the runs of a pipeline with their stages
+func (h *Handlers) ShowPipeline(c *gin.Context) {
+	p, err := h.pipelineRepo.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Pipeline not found",
+		})
+		return
+	}
+
+	runs, err := h.pipelineRepo.Runs(c.Request.Context(), p.ID, maxPipelineRuns)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load pipeline runs",
+		})
+		return
+	}
+
+	// Services the caller may link the pipeline to
+	var services []service.Service
+	if p.ServiceID == "" || h.canEditLinkedService(c, p.ServiceID) {
+		all, err := h.serviceRepo.GetAll(c.Request.Context())
+		if err != nil {
+			log.Printf("Failed to load services for pipeline %s: %v", p.ID, err)
+		}
+		for _, svc := range all {
+			if h.canEditService(c, svc.TeamID) {
+				services = append(services, svc)
+			}
+		}
+	}
+
+	c.HTML(http.StatusOK, "pipelines/detail.html", gin.H{
+		"title":    "Pipeline: " + p.Name,
+		"pipeline": p,
+		"runs":     runs,
+		"trend":    pipeline.NewTrend(latest(runs)),
+		"services": services,
+	})
+}
+
+// LinkPipeline links a pipeline to the service it deploys from the form
+// on the pipeline page
+func (h *Handlers) LinkPipeline(c *gin.Context) {
+	id := c.Param("id")
+
+	if status, message := h.linkPipeline(c, id, c.PostForm("service_id")); message != "" {
+		c.HTML(status, "error.html", gin.H{
+			"error": message,
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/pipelines/"+id)
+}
+
+// ServicePipelinesPartial renders the pipelines that deploy a service
+func (h *Handlers) ServicePipelinesPartial(c *gin.Context) {
+	pipelines, err := h.pipelineRepo.ListByService(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/service-pipelines.html", gin.H{
+			"error": "Failed to load pipelines",
+		})
+		return
+	}
+
+	summaries, err := h.summarize(c.Request.Context(), pipelines)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/service-pipelines.html", gin.H{
+			"error": "Failed to load pipeline runs",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "partials/service-pipelines.html", gin.H{
+		"pipelines": summaries,
+	})
+}
+
+// APIListPipelines returns pipelines with their latest runs and trends as
+// JSON, optionally only those of ?service_id=
+func (h *Handlers) APIListPipelines(c *gin.Context) {
+	var pipelines []pipeline.Pipeline
+	var err error
+	if serviceID := c.Query("service_id"); serviceID != "" {
+		pipelines, err = h.pipelineRepo.ListByService(c.Request.Context(), serviceID)
+	} else {
+		pipelines, err = h.pipelineRepo.List(c.Request.Context())
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch pipelines",
+		})
+		return
+	}
+
+	summaries, err := h.summarize(c.Request.Context(), pipelines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch pipeline runs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pipelines": summaries,
+		"count":     len(summaries),
+	})
+}
+
+// APIListPipelineRuns returns the latest runs of a pipeline as JSON,
+// newest first. ?limit= defaults to the trend window.
+func (h *Handlers) APIListPipelineRuns(c *gin.Context) {
+	p, err := h.pipelineRepo.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Pipeline not found",
+		})
+		return
+	}
+
+	limit := trendRuns
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxPipelineRuns {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit must be between 1 and " + strconv.Itoa(maxPipelineRuns),
+			})
+			return
+		}
+	}
+
+	runs, err := h.pipelineRepo.Runs(c.Request.Context(), p.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch pipeline runs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pipeline": p,
+		"runs":     runs,
+		"trend":    pipeline.NewTrend(runs),
+	})
+}
+
+// APILinkPipeline links a pipeline to the service it deploys, or unlinks
+// it when service_id is empty
+func (h *Handlers) APILinkPipeline(c *gin.Context) {
+	var req struct {
+		ServiceID string `json:"service_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	id := c.Param("id")
+	if status, message := h.linkPipeline(c, id, req.ServiceID); message != "" {
+		c.JSON(status, gin.H{
+			"error": message,
+		})
+		return
+	}
+
+	p, err := h.pipelineRepo.Get(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Pipeline not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// linkPipeline links a pipeline to a service. The caller must be able to
+// edit both the service it was linked to and the new one. On failure it
+// returns the response status and error message.
+func (h *Handlers) linkPipeline(c *gin.Context, id, serviceID string) (int, string) {
+	p, err := h.pipelineRepo.Get(c.Request.Context(), id)
+	if err != nil {
+		return http.StatusNotFound, "Pipeline not found"
+	}
+
+	if p.ServiceID != "" && !h.canEditLinkedService(c, p.ServiceID) {
+		return http.StatusForbidden, "You cannot change pipelines of this service"
+	}
+	if serviceID != "" && !h.canEditLinkedService(c, serviceID) {
+		return http.StatusForbidden, "You cannot link pipelines to this service"
+	}
+
+	if err := h.pipelineRepo.SetService(c.Request.Context(), id, serviceID); err != nil {
+		return http.StatusInternalServerError, "Failed to link pipeline"
+	}
+	return http.StatusOK, ""
+}
+
+// canEditLinkedService reports whether the caller can see and edit a
+// service
+func (h *Handlers) canEditLinkedService(c *gin.Context, serviceID string) bool {
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), serviceID)
+	return err == nil && h.canEditService(c, svc.TeamID)
+}
+
+// summarize loads the latest runs of pipelines and their trends
+func (h *Handlers) summarize(ctx context.Context, pipelines []pipeline.Pipeline) ([]pipeline.Summary, error) {
+	summaries := make([]pipeline.Summary, 0, len(pipelines))
+	for _, p := range pipelines {
+		runs, err := h.pipelineRepo.Runs(ctx, p.ID, trendRuns)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, pipeline.Summary{
+			Pipeline: p,
+			Runs:     runs,
+			Trend:    pipeline.NewTrend(runs),
+		})
+	}
+	return summaries, nil
+}
+
+// latest returns the runs in the trend window
+func latest(runs []pipeline.Run) []pipeline.Run {
+	if len(runs) > trendRuns {
+		return runs[:trendRuns]
+	}
+	return runs
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"strings"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// protocolCheckForm holds the Postgres, MySQL and Redis check fields of
+// the service form
+type protocolCheckForm struct {
+	DatabaseUser           string `form:"database_user"`
+	DatabasePasswordSecret string `form:"database_password_secret"`
+	DatabaseMaxLag         int    `form:"database_max_lag"`
+	RedisUsername          string `form:"redis_username"`
+	RedisPasswordSecret    string `form:"redis_password_secret"`
+	RedisMaxMemory         int    `form:"redis_max_memory"`
+	RedisMaxClients        int    `form:"redis_max_clients"`
+}
+
+// databaseCheck builds the database check of a Postgres or MySQL service
+// from the form. Services with other check types, and checks without a
+// user or threshold, get none.
+func (f protocolCheckForm) databaseCheck(checkType string) *service.DatabaseCheck {
+	if t := service.CheckType(checkType); t != service.CheckPostgres && t != service.CheckMySQL {
+		return nil
+	}
+
+	check := &service.DatabaseCheck{
+		User:           strings.TrimSpace(f.DatabaseUser),
+		PasswordSecret: f.DatabasePasswordSecret,
+		MaxLag:         f.DatabaseMaxLag,
+	}
+	if *check == (service.DatabaseCheck{}) {
+		return nil
+	}
+	return check
+}
+
+// redisCheck builds the Redis check of a service from the form. Services
+// with other check types, and checks without a password or threshold, get
+// none.
+func (f protocolCheckForm) redisCheck(checkType string) *service.RedisCheck {
+	if service.CheckType(checkType) != service.CheckRedis {
+		return nil
+	}
+
+	check := &service.RedisCheck{
+		Username:       strings.TrimSpace(f.RedisUsername),
+		PasswordSecret: f.RedisPasswordSecret,
+		MaxMemory:      f.RedisMaxMemory,
+		MaxClients:     f.RedisMaxClients,
+	}
+	if *check == (service.RedisCheck{}) {
+		return nil
+	}
+	return check
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseServiceQuery reads search, filter, sort and pagination parameters
+// shared by the services table and the JSON API:
+//
+//	q          full-text search on name, description and URL
+//	tag        tag filter, repeatable or comma-separated
+//	tag_match  "any" (default) or "all"
+//	status     status filter, repeatable
+//	selector   label selector, e.g. "env=prod,tier in (1,2),!deprecated"
+//	sort       name (default), status, response_time or last_check
+//	order      "asc" (default) or "desc"
+//	cursor     next_cursor from the previous page
+//	limit      page size
+func parseServiceQuery(c *gin.Context) (service.Query, error) {
+	query := service.Query{
+		Search:   strings.TrimSpace(c.Query("q")),
+		TagMatch: service.TagMatch(c.DefaultQuery("tag_match", string(service.TagMatchAny))),
+		Sort:     service.SortField(c.DefaultQuery("sort", string(service.SortByName))),
+		Desc:     c.Query("order") == "desc",
+		Cursor:   c.Query("cursor"),
+	}
+
+	for _, value := range c.QueryArray("tag") {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				query.Tags = append(query.Tags, tag)
+			}
+		}
+	}
+
+	for _, status := range c.QueryArray("status") {
+		if status != "" {
+			query.Statuses = append(query.Statuses, service.Status(status))
+		}
+	}
+
+	selector, err := service.ParseSelector(c.Query("selector"))
+	if err != nil {
+		return query, err
+	}
+	query.Labels = selector
+
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// nextPageURL returns the current request URL pointing at the given cursor
+func nextPageURL(c *gin.Context, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+
+	params := c.Request.URL.Query()
+	params.Set("cursor", cursor)
+	return c.Request.URL.Path + "?" + params.Encode()
+}
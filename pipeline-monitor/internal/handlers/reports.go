@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"pipeline-monitor/internal/domain/dora"
+	"pipeline-monitor/internal/domain/event"
+	"pipeline-monitor/internal/domain/incident"
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// doraGroups are the ways the DORA report can break down its metrics
+var doraGroups = []string{"service", "tag", "team"}
+
+// doraWindows are the periods offered on the reports page; the API accepts
+// any window parseWindow does
+var doraWindows = []string{"7d", "30d", "90d", "180d", "365d"}
+
+// doraRow holds the metrics of one service, tag or team
+type doraRow struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Services int    `json:"services"`
+	dora.Metrics
+}
+
+// doraReport holds the DORA metrics of the visible services over a period
+type doraReport struct {
+	Window  string            `json:"window"`
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Group   string            `json:"group"`
+	Overall dora.Metrics      `json:"overall"`
+	Rows    []doraRow         `json:"groups"`
+	Rules   map[string]string `json:"rules"`
+}
+
+// DORAReport renders the DORA metrics report page
+func (h *Handlers) DORAReport(c *gin.Context) {
+	report, status, message := h.doraReport(c)
+	if status != http.StatusOK {
+		c.HTML(status, "error.html", gin.H{
+			"error": message,
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "reports/dora.html", gin.H{
+		"title":   "DORA Metrics",
+		"report":  report,
+		"groups":  doraGroups,
+		"windows": doraWindows,
+	})
+}
+
+// APIDORAReport returns the DORA metrics of the visible services over
+// ?window= (default 30d), broken down by ?group= (service, tag or team),
+// together with the rules used to calculate them
+func (h *Handlers) APIDORAReport(c *gin.Context) {
+	report, status, message := h.doraReport(c)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{
+			"error": message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// doraReport calculates the report asked for by the query string. On
+// failure it returns the HTTP status and message to respond with.
+func (h *Handlers) doraReport(c *gin.Context) (*doraReport, int, string) {
+	ctx := c.Request.Context()
+
+	windowParam := c.DefaultQuery("window", "30d")
+	window, err := parseWindow(windowParam)
+	if err != nil {
+		return nil, http.StatusBadRequest, err.Error()
+	}
+
+	group := c.DefaultQuery("group", "service")
+	if !containsString(doraGroups, group) {
+		return nil, http.StatusBadRequest, fmt.Sprintf("invalid group %q, expected service, tag or team", group)
+	}
+
+	to := time.Now()
+	from := to.Add(-window)
+
+	services, err := h.serviceRepo.GetAll(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to load services"
+	}
+	ids := make([]string, 0, len(services))
+	for _, svc := range services {
+		ids = append(ids, svc.ID)
+	}
+
+	deploys, err := h.eventRepo.ListByKind(ctx, event.KindDeploy, ids, from, time.Time{})
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to load deploy events"
+	}
+	incidents, err := h.incidentRepo.List(ctx, incident.Filter{ServiceIDs: ids, Since: from})
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to load incidents"
+	}
+
+	report := &doraReport{
+		Window:  windowParam,
+		From:    from,
+		To:      to,
+		Group:   group,
+		Overall: dora.Compute(from, to, deploys, incidents),
+		Rows:    []doraRow{},
+		Rules:   dora.Rules,
+	}
+
+	teamNames := make(map[string]string)
+	if group == "team" {
+		teams, err := h.visibleTeams(c)
+		if err != nil {
+			return nil, http.StatusInternalServerError, "Failed to load teams"
+		}
+		for _, t := range teams {
+			teamNames[t.ID] = t.Name
+		}
+	}
+
+	members := make(map[string][]string)
+	names := make(map[string]string)
+	for _, svc := range services {
+		for key, name := range doraKeys(svc, group, teamNames) {
+			members[key] = append(members[key], svc.ID)
+			names[key] = name
+		}
+	}
+
+	for key, serviceIDs := range members {
+		report.Rows = append(report.Rows, doraRow{
+			Key:      key,
+			Name:     names[key],
+			Services: len(serviceIDs),
+			Metrics:  dora.Compute(from, to, deploysOf(deploys, serviceIDs), incidentsOf(incidents, serviceIDs)),
+		})
+	}
+	sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Name < report.Rows[j].Name })
+
+	return report, http.StatusOK, ""
+}
+
+// doraKeys returns the keys and names of the rows a service belongs to:
+// its own, one per tag, or its team's
+func doraKeys(svc service.Service, group string, teamNames map[string]string) map[string]string {
+	switch group {
+	case "tag":
+		keys := make(map[string]string, len(svc.Tags))
+		for _, tag := range svc.Tags {
+			keys[tag] = tag
+		}
+		return keys
+	case "team":
+		name, ok := teamNames[svc.TeamID]
+		if !ok {
+			name = "No team"
+		}
+		return map[string]string{svc.TeamID: name}
+	default:
+		return map[string]string{svc.ID: svc.Name}
+	}
+}
+
+// deploysOf returns the events of the given services
+func deploysOf(events []event.Event, serviceIDs []string) []event.Event {
+	var matched []event.Event
+	for _, e := range events {
+		if containsString(serviceIDs, e.ServiceID) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// incidentsOf returns the incidents of the given services
+func incidentsOf(incidents []incident.Incident, serviceIDs []string) []incident.Incident {
+	var matched []incident.Incident
+	for _, inc := range incidents {
+		if containsString(serviceIDs, inc.ServiceID) {
+			matched = append(matched, inc)
+		}
+	}
+	return matched
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pipeline-monitor/internal/domain/audit"
+	"pipeline-monitor/internal/domain/revision"
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceRevisionsPartial returns the revision list for a service
+func (h *Handlers) ServiceRevisionsPartial(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), id); err != nil {
+		c.HTML(http.StatusNotFound, "partials/service-revisions.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	revisions, err := h.revisionRepo.ListByService(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/service-revisions.html", gin.H{
+			"error": "Failed to load revisions",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "partials/service-revisions.html", gin.H{
+		"serviceID": id,
+		"revisions": revisions,
+	})
+}
+
+// CompareRevisionsPartial returns the diff between two revisions of a service
+func (h *Handlers) CompareRevisionsPartial(c *gin.Context) {
+	comparison, err := h.compareRevisions(c)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "partials/revision-diff.html", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "partials/revision-diff.html", gin.H{
+		"comparison": comparison,
+	})
+}
+
+// RevertService restores a service to one of its earlier revisions
+func (h *Handlers) RevertService(c *gin.Context) {
+	svc, err := h.revertService(c, audit.SourceUI)
+	if err != nil {
+		if c.GetHeader("HX-Request") == "true" {
+			c.Header("HX-Trigger", "error")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to revert service: " + err.Error(),
+		})
+		return
+	}
+
+	// Reload the detail page so the reverted definition is shown
+	if c.GetHeader("HX-Request") == "true" {
+		c.Header("HX-Redirect", "/services/"+svc.ID)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/services/"+svc.ID)
+}
+
+// APIListRevisions returns the revisions of a service as JSON
+func (h *Handlers) APIListRevisions(c *gin.Context) {
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	revisions, err := h.revisionRepo.ListByService(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch revisions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+		"count":     len(revisions),
+	})
+}
+
+// APICompareRevisions returns the diff between two revisions as JSON
+func (h *Handlers) APICompareRevisions(c *gin.Context) {
+	comparison, err := h.compareRevisions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
+
+// APIRevertService restores a service to an earlier revision via JSON API
+func (h *Handlers) APIRevertService(c *gin.Context) {
+	svc, err := h.revertService(c, audit.SourceAPI)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revert service: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, svc)
+}
+
+// compareRevisions loads the revisions named by the from and to query
+// parameters and diffs them
+func (h *Handlers) compareRevisions(c *gin.Context) (*revision.Comparison, error) {
+	id := c.Param("id")
+
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), id); err != nil {
+		return nil, err
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid from revision: %q", c.Query("from"))
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid to revision: %q", c.Query("to"))
+	}
+
+	fromRev, err := h.revisionRepo.Get(c.Request.Context(), id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := h.revisionRepo.Get(c.Request.Context(), id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return revision.Compare(fromRev, toRev)
+}
+
+// revertService applies the definition stored in the :version revision to
+// the :id service and records the change
+func (h *Handlers) revertService(c *gin.Context, source audit.Source) (*service.Service, error) {
+	id := c.Param("id")
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision: %q", c.Param("version"))
+	}
+
+	rev, err := h.revisionRepo.Get(c.Request.Context(), id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !h.canEditService(c, svc.TeamID) {
+		return nil, fmt.Errorf("you cannot change services owned by this team")
+	}
+
+	before := svc.Clone()
+	svc.ApplyDefinition(&rev.Service)
+	svc.UpdatedAt = time.Now()
+
+	if err := h.prepareCheck(c.Request.Context(), svc); err != nil {
+		return nil, err
+	}
+
+	if err := h.serviceRepo.Update(c.Request.Context(), svc); err != nil {
+		return nil, err
+	}
+
+	h.recordAudit(c, source, audit.ActionRevert, before, svc)
+	h.recordRevision(c, svc)
+
+	return svc, nil
+}
+
+// recordRevision stores the current definition of a service as a new
+// revision. Like auditing, failures are logged and never block the change.
+func (h *Handlers) recordRevision(c *gin.Context, svc *service.Service) {
+	if err := h.revisionRepo.Record(c.Request.Context(), revision.New(svc, h.actor(c))); err != nil {
+		log.Printf("Failed to record revision for service %s: %v", svc.ID, err)
+	}
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pipeline-monitor/internal/domain/secret"
+	"pipeline-monitor/internal/domain/team"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSecrets renders the secrets page. Everyone can see the shared
+// secrets and those of their teams so they can reference them from checks;
+// only admins can set or delete them, and nobody can read a value back.
+func (h *Handlers) ListSecrets(c *gin.Context) {
+	secrets, err := h.secretRepo.List(c.Request.Context())
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load secrets",
+		})
+		return
+	}
+
+	teams, err := h.visibleTeams(c)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load teams",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "secrets/list.html", gin.H{
+		"title":         "Secrets",
+		"secrets":       secrets,
+		"teams":         teams,
+		"caller":        h.caller(c),
+		"keyConfigured": h.config.SecretsKey != "",
+	})
+}
+
+// PutSecret handles the form setting a secret. Only admins can set
+// secrets.
+func (h *Handlers) PutSecret(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Only admins can set secrets",
+		})
+		return
+	}
+
+	var req struct {
+		Name   string `form:"name" binding:"required"`
+		TeamID string `form:"team_id"`
+		Value  string `form:"value" binding:"required"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "A name and a value are required",
+		})
+		return
+	}
+
+	if _, status, msg := h.putSecret(c.Request.Context(), req.Name, req.TeamID, req.Value); status != http.StatusOK {
+		c.HTML(status, "error.html", gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/secrets")
+}
+
+// DeleteSecret deletes a secret that no check uses anymore
+func (h *Handlers) DeleteSecret(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	if status, msg := h.deleteSecret(c.Request.Context(), c.Param("name")); status != http.StatusOK {
+		if c.GetHeader("HX-Request") == "true" {
+			c.Header("HX-Trigger", "error")
+			c.String(status, msg)
+			return
+		}
+		c.HTML(status, "error.html", gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	if c.GetHeader("HX-Request") == "true" {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/secrets")
+}
+
+// APIListSecrets returns the shared secrets and those of the caller's
+// teams as JSON, without their values
+func (h *Handlers) APIListSecrets(c *gin.Context) {
+	secrets, err := h.secretRepo.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch secrets",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secrets": secrets,
+		"count":   len(secrets),
+	})
+}
+
+// APIPutSecret creates or replaces a secret via JSON API. A secret without
+// a team_id is shared. The response describes the secret but never
+// contains the value.
+func (h *Handlers) APIPutSecret(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only admins can set secrets",
+		})
+		return
+	}
+
+	var req struct {
+		TeamID string `json:"team_id"`
+		Value  string `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Value == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A value is required",
+		})
+		return
+	}
+
+	s, status, msg := h.putSecret(c.Request.Context(), c.Param("name"), req.TeamID, req.Value)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
+
+// APIDeleteSecret deletes a secret via JSON API
+func (h *Handlers) APIDeleteSecret(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only admins can delete secrets",
+		})
+		return
+	}
+
+	if status, msg := h.deleteSecret(c.Request.Context(), c.Param("name")); status != http.StatusOK {
+		c.JSON(status, gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Secret deleted successfully",
+	})
+}
+
+// putSecret validates and stores a secret
+func (h *Handlers) putSecret(ctx context.Context, name, teamID, value string) (*secret.Secret, int, string) {
+	if err := secret.ValidateName(name); err != nil {
+		return nil, http.StatusBadRequest, err.Error()
+	}
+	if teamID != "" {
+		if _, err := h.teamRepo.GetByID(ctx, teamID); err != nil {
+			return nil, http.StatusBadRequest, "Team not found"
+		}
+	}
+
+	s, err := h.secretRepo.Put(ctx, name, teamID, value)
+	if errors.Is(err, secret.ErrNoKey) {
+		return nil, http.StatusServiceUnavailable, err.Error()
+	}
+	if errors.Is(err, secret.ErrEnvironment) {
+		return nil, http.StatusConflict, err.Error()
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to store secret: " + err.Error()
+	}
+
+	return s, http.StatusOK, ""
+}
+
+// deleteSecret deletes a secret unless a service still uses it. Services
+// of every team are considered, not just those visible to the caller.
+func (h *Handlers) deleteSecret(ctx context.Context, name string) (int, string) {
+	services, err := h.serviceRepo.GetAll(team.WithoutCaller(ctx))
+	if err != nil {
+		return http.StatusInternalServerError, "Failed to load services"
+	}
+
+	var users []string
+	for _, svc := range services {
+		for _, used := range svc.Secrets() {
+			if used == name {
+				users = append(users, svc.Name)
+			}
+		}
+	}
+	if len(users) > 0 {
+		return http.StatusConflict, "Secret is used by " + strings.Join(users, ", ")
+	}
+
+	err = h.secretRepo.Delete(ctx, name)
+	if errors.Is(err, secret.ErrNotFound) {
+		return http.StatusNotFound, "Secret not found"
+	}
+	if errors.Is(err, secret.ErrEnvironment) {
+		return http.StatusConflict, err.Error()
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "Failed to delete secret: " + err.Error()
+	}
+
+	return http.StatusOK, ""
+}
+
+// secretNames returns the secrets checks can use as passwords
+func (h *Handlers) secretNames(c *gin.Context) []string {
+	secrets, err := h.secretRepo.List(c.Request.Context())
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		names = append(names, s.Name)
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceSnapshotsPartial renders the snapshots kept of failing checks of a
+// service, newest first
+func (h *Handlers) ServiceSnapshotsPartial(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), id); err != nil {
+		c.HTML(http.StatusNotFound, "partials/response-snapshots.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	snapshots, err := h.snapshotRepo.List(c.Request.Context(), id, time.Time{}, time.Time{})
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/response-snapshots.html", gin.H{
+			"error": "Failed to load response snapshots",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "partials/response-snapshots.html", gin.H{
+		"snapshots": snapshots,
+	})
+}
+
+// IncidentSnapshotsPartial renders the snapshots captured while an
+// incident was open
+func (h *Handlers) IncidentSnapshotsPartial(c *gin.Context) {
+	inc, err := h.incidentRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusNotFound, "partials/response-snapshots.html", gin.H{
+			"error": "Incident not found",
+		})
+		return
+	}
+
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), inc.ServiceID); err != nil {
+		c.HTML(http.StatusNotFound, "partials/response-snapshots.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	var to time.Time
+	if inc.ResolvedAt != nil {
+		to = *inc.ResolvedAt
+	}
+	snapshots, err := h.snapshotRepo.List(c.Request.Context(), inc.ServiceID, inc.StartedAt, to)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/response-snapshots.html", gin.H{
+			"error": "Failed to load response snapshots",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "partials/response-snapshots.html", gin.H{
+		"snapshots": snapshots,
+	})
+}
+
+// APIListSnapshots returns the snapshots kept of failing checks of a
+// service as JSON, newest first
+func (h *Handlers) APIListSnapshots(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	snapshots, err := h.snapshotRepo.List(c.Request.Context(), id, time.Time{}, time.Time{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch response snapshots",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshots": snapshots,
+		"count":     len(snapshots),
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// sqlCheckForm holds the SQL check fields of the service form
+type sqlCheckForm struct {
+	SQLDataSource string `form:"sql_data_source"`
+	SQLQuery      string `form:"sql_query"`
+	SQLMaxAge     int    `form:"sql_max_age"`
+	SQLMin        string `form:"sql_min"`
+	SQLMax        string `form:"sql_max"`
+}
+
+// sqlCheck builds the SQL check of a service from the form. Services with
+// other check types get none.
+func (f sqlCheckForm) sqlCheck(checkType string) (*service.SQLCheck, error) {
+	if service.CheckType(checkType) != service.CheckSQL {
+		return nil, nil
+	}
+
+	lo, err := parseThreshold("sql_min", f.SQLMin)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := parseThreshold("sql_max", f.SQLMax)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.SQLCheck{
+		DataSource: f.SQLDataSource,
+		Query:      strings.TrimSpace(f.SQLQuery),
+		MaxAge:     f.SQLMaxAge,
+		Min:        lo,
+		Max:        hi,
+	}, nil
+}
+
+// parseThreshold parses an optional numeric form field
+func parseThreshold(field, value string) (*float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", field)
+	}
+	return &f, nil
+}
+
+// dataSourceNames returns the configured data sources SQL checks can use
+func (h *Handlers) dataSourceNames() []string {
+	names := make([]string, 0, len(h.config.DataSources))
+	for name := range h.config.DataSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
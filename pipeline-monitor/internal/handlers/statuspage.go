@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"pipeline-monitor/internal/domain/incident"
+	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/statuspage"
+	"pipeline-monitor/internal/domain/team"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uptimeDays is the length of the uptime history on the status page
+const uptimeDays = 90
+
+// publicComponent is a component as shown on the public status page
+type publicComponent struct {
+	statuspage.Component
+	State    statuspage.State
+	Services []publicEntry
+}
+
+// publicEntry is a service as shown on the public status page
+type publicEntry struct {
+	statuspage.Entry
+	State  statuspage.State
+	Days   []service.DailyUptime
+	Uptime service.Uptime
+}
+
+// publicIncident is an active incident as shown on the public status
+// page. It is named after the status page entry and leaves out the
+// incident's title and cause, which name the service behind it.
+type publicIncident struct {
+	DisplayName string
+	State       statuspage.State
+	Message     string
+	StartedAt   time.Time
+}
+
+// PublicStatusPage renders the customer-facing status page. It is read-only
+// and served without authentication, so it only shows what admins put on it.
+func (h *Handlers) PublicStatusPage(c *gin.Context) {
+	ctx := team.WithoutCaller(c.Request.Context())
+
+	components, err := h.statusPageRepo.List(ctx)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Status information is temporarily unavailable")
+		return
+	}
+
+	displayNames := make(map[string]string)
+	states := make(map[string]statuspage.State)
+	var serviceIDs []string
+	for _, component := range components {
+		for _, entry := range component.Entries {
+			if _, ok := displayNames[entry.ServiceID]; !ok {
+				serviceIDs = append(serviceIDs, entry.ServiceID)
+			}
+			displayNames[entry.ServiceID] = entry.DisplayName
+			states[entry.ServiceID] = statuspage.StateOf(entry.Status)
+		}
+	}
+
+	now := time.Now()
+	since := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -(uptimeDays - 1))
+
+	uptime, err := h.checkRepo.DailyUptime(ctx, serviceIDs, since)
+	if err != nil {
+		uptime = map[string][]service.DailyUptime{}
+	}
+
+	overall := statuspage.StateOperational
+	view := make([]publicComponent, len(components))
+	for i, component := range components {
+		view[i] = publicComponent{Component: component, State: component.State()}
+		for _, entry := range component.Entries {
+			days := service.UptimeDays(uptime[entry.ServiceID], since, now)
+			view[i].Services = append(view[i].Services, publicEntry{
+				Entry:  entry,
+				State:  statuspage.StateOf(entry.Status),
+				Days:   days,
+				Uptime: service.TotalUptime(days),
+			})
+		}
+		overall = statuspage.Worst(overall, view[i].State)
+	}
+
+	var incidents []publicIncident
+	if len(serviceIDs) > 0 {
+		active, err := h.incidentRepo.List(ctx, incident.Filter{ServiceIDs: serviceIDs, ActiveOnly: true})
+		if err == nil {
+			for _, inc := range active {
+				incidents = append(incidents, publicIncident{
+					DisplayName: displayNames[inc.ServiceID],
+					State:       states[inc.ServiceID],
+					Message:     inc.Message,
+					StartedAt:   inc.StartedAt,
+				})
+			}
+		}
+	}
+
+	c.HTML(http.StatusOK, "status/public.html", gin.H{
+		"title":       h.config.StatusPageTitle,
+		"overall":     overall,
+		"components":  view,
+		"incidents":   incidents,
+		"uptimeDays":  uptimeDays,
+		"generatedAt": now,
+	})
+}
+
+// StatusPageAdmin renders the page where admins configure the public
+// status page and write incident messages
+func (h *Handlers) StatusPageAdmin(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Only admins can configure the status page",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	components, err := h.statusPageRepo.List(ctx)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load status page components",
+		})
+		return
+	}
+
+	services, err := h.serviceRepo.GetAll(ctx)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load services",
+		})
+		return
+	}
+
+	incidents, err := h.incidentRepo.List(ctx, incident.Filter{ActiveOnly: true})
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load incidents",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "statuspage/admin.html", gin.H{
+		"title":      "Status Page",
+		"publicPath": h.config.StatusPagePath,
+		"components": components,
+		"services":   services,
+		"incidents":  incidents,
+	})
+}
+
+// CreateStatusComponent adds a component to the status page
+func (h *Handlers) CreateStatusComponent(c *gin.Context) {
+	component, ok := h.bindStatusComponent(c)
+	if !ok {
+		return
+	}
+
+	if err := h.statusPageRepo.CreateComponent(c.Request.Context(), component); err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to create component: " + err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/status-page")
+}
+
+// UpdateStatusComponent renames, describes or moves a component
+func (h *Handlers) UpdateStatusComponent(c *gin.Context) {
+	component, ok := h.bindStatusComponent(c)
+	if !ok {
+		return
+	}
+	component.ID = c.Param("id")
+
+	if err := h.statusPageRepo.UpdateComponent(c.Request.Context(), component); err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to update component: " + err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/status-page")
+}
+
+// DeleteStatusComponent removes a component from the status page
+func (h *Handlers) DeleteStatusComponent(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	if err := h.statusPageRepo.DeleteComponent(c.Request.Context(), c.Param("id")); err != nil {
+		if c.GetHeader("HX-Request") == "true" {
+			c.Header("HX-Trigger", "error")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to delete component: " + err.Error(),
+		})
+		return
+	}
+
+	// For HTMX requests, return empty content (the component will be removed)
+	if c.GetHeader("HX-Request") == "true" {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/status-page")
+}
+
+// SetStatusEntry adds a service to a component under a friendly name, or
+// changes the name and position of a service already on it
+func (h *Handlers) SetStatusEntry(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Only admins can configure the status page",
+		})
+		return
+	}
+
+	var req struct {
+		ServiceID   string `form:"service_id" binding:"required"`
+		DisplayName string `form:"display_name"`
+		Position    int    `form:"position"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "Invalid form data: " + err.Error(),
+		})
+		return
+	}
+
+	svc, err := h.serviceRepo.GetByID(c.Request.Context(), req.ServiceID)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	entry := &statuspage.Entry{
+		ComponentID: c.Param("id"),
+		ServiceID:   svc.ID,
+		DisplayName: req.DisplayName,
+		Position:    req.Position,
+	}
+	if entry.DisplayName == "" {
+		entry.DisplayName = svc.Name
+	}
+
+	if err := h.statusPageRepo.SetEntry(c.Request.Context(), entry); err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to add service: " + err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/status-page")
+}
+
+// RemoveStatusEntry takes a service off a component
+func (h *Handlers) RemoveStatusEntry(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	err := h.statusPageRepo.RemoveEntry(c.Request.Context(), c.Param("id"), c.Param("serviceID"))
+	if err != nil {
+		if c.GetHeader("HX-Request") == "true" {
+			c.Header("HX-Trigger", "error")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to remove service: " + err.Error(),
+		})
+		return
+	}
+
+	// For HTMX requests, return empty content (the row will be removed)
+	if c.GetHeader("HX-Request") == "true" {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/status-page")
+}
+
+// bindStatusComponent checks the caller is an admin and reads the component
+// form, rendering an error page and returning false on failure
+func (h *Handlers) bindStatusComponent(c *gin.Context) (*statuspage.Component, bool) {
+	if !h.caller(c).Admin {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Only admins can configure the status page",
+		})
+		return nil, false
+	}
+
+	var req struct {
+		Name        string `form:"name" binding:"required"`
+		Description string `form:"description"`
+		Position    int    `form:"position"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "A component name is required",
+		})
+		return nil, false
+	}
+
+	return &statuspage.Component{Name: req.Name, Description: req.Description, Position: req.Position}, true
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"pipeline-monitor/internal/domain/service"
+
+	"gopkg.in/yaml.v3"
+)
+
+// syntheticCheckForm holds the scenario of a synthetic check, written as
+// YAML in the service form
+type syntheticCheckForm struct {
+	SyntheticScenario string `form:"synthetic_scenario"`
+}
+
+// syntheticCheck parses the scenario of a synthetic service from the form.
+// Services with other check types get none.
+func (f syntheticCheckForm) syntheticCheck(checkType string) (*service.SyntheticCheck, error) {
+	if service.CheckType(checkType) != service.CheckSynthetic {
+		return nil, nil
+	}
+	if strings.TrimSpace(f.SyntheticScenario) == "" {
+		return nil, nil
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(f.SyntheticScenario))
+	decoder.KnownFields(true)
+	var check service.SyntheticCheck
+	if err := decoder.Decode(&check); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	return &check, nil
+}
@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"pipeline-monitor/internal/domain/team"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActiveTeamCookie holds the team picked in the team switcher
+const ActiveTeamCookie = "team"
+
+// ListTeams renders the teams page
+func (h *Handlers) ListTeams(c *gin.Context) {
+	teams, err := h.visibleTeams(c)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load teams",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "teams/list.html", gin.H{
+		"title":  "Teams",
+		"teams":  teams,
+		"caller": h.caller(c),
+	})
+}
+
+// CreateTeam handles team creation. Only admins can create teams.
+func (h *Handlers) CreateTeam(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Only admins can create teams",
+		})
+		return
+	}
+
+	var req struct {
+		Name string `form:"name" binding:"required"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "Invalid form data: " + err.Error(),
+		})
+		return
+	}
+
+	t := &team.Team{Name: req.Name}
+	if err := h.teamRepo.Create(c.Request.Context(), t); err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to create team: " + err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/teams/"+t.ID)
+}
+
+// GetTeam renders a team and its members
+func (h *Handlers) GetTeam(c *gin.Context) {
+	caller := h.caller(c)
+	id := c.Param("id")
+
+	if !caller.Can(id, team.RoleViewer) {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Team not found",
+		})
+		return
+	}
+
+	t, err := h.teamRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Team not found",
+		})
+		return
+	}
+
+	members, err := h.teamRepo.ListMembers(c.Request.Context(), id)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load team members",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "teams/detail.html", gin.H{
+		"title":     "Team: " + t.Name,
+		"team":      t,
+		"members":   members,
+		"roles":     team.Roles(),
+		"canManage": caller.Can(id, team.RoleOwner),
+	})
+}
+
+// SetTeamMember adds a member to a team or changes their role
+func (h *Handlers) SetTeamMember(c *gin.Context) {
+	id := c.Param("id")
+
+	if !h.caller(c).Can(id, team.RoleOwner) {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Only team owners can manage members",
+		})
+		return
+	}
+
+	var req struct {
+		Username string `form:"username" binding:"required"`
+		Role     string `form:"role" binding:"required"`
+	}
+	if err := c.ShouldBind(&req); err != nil || !team.Role(req.Role).Valid() {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "A username and a valid role are required",
+		})
+		return
+	}
+	if req.Username == team.Anonymous {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "The anonymous user cannot join teams",
+		})
+		return
+	}
+
+	membership := &team.Membership{TeamID: id, Username: req.Username, Role: team.Role(req.Role)}
+	if err := h.teamRepo.SetMember(c.Request.Context(), membership); err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to add member: " + err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/teams/"+id)
+}
+
+// RemoveTeamMember removes a member from a team
+func (h *Handlers) RemoveTeamMember(c *gin.Context) {
+	id := c.Param("id")
+
+	if !h.caller(c).Can(id, team.RoleOwner) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	if err := h.teamRepo.RemoveMember(c.Request.Context(), id, c.Param("username")); err != nil {
+		if c.GetHeader("HX-Request") == "true" {
+			c.Header("HX-Trigger", "error")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to remove member: " + err.Error(),
+		})
+		return
+	}
+
+	// For HTMX requests, return empty content (the row will be removed)
+	if c.GetHeader("HX-Request") == "true" {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/teams/"+id)
+}
+
+// SwitchTeam stores the team picked in the team switcher. An empty team
+// returns to the view of all the caller's teams.
+func (h *Handlers) SwitchTeam(c *gin.Context) {
+	teamID := c.PostForm("team_id")
+
+	if teamID != "" && !h.caller(c).Can(teamID, team.RoleViewer) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	maxAge := int((365 * 24 * time.Hour).Seconds())
+	if teamID == "" {
+		maxAge = -1
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ActiveTeamCookie, teamID, maxAge, "/", "", false, true)
+
+	if c.GetHeader("HX-Request") == "true" {
+		c.Header("HX-Refresh", "true")
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// TeamSwitcherPartial returns the team switcher shown in the navigation bar
+func (h *Handlers) TeamSwitcherPartial(c *gin.Context) {
+	caller := h.caller(c)
+
+	teams, err := h.visibleTeams(c)
+	if err != nil {
+		teams = nil
+	}
+
+	c.HTML(http.StatusOK, "partials/team-switcher.html", gin.H{
+		"caller": caller,
+		"teams":  teams,
+	})
+}
+
+// APIListTeams returns the teams visible to the caller as JSON
+func (h *Handlers) APIListTeams(c *gin.Context) {
+	teams, err := h.visibleTeams(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch teams",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"teams": teams,
+		"count": len(teams),
+	})
+}
+
+// APICreateTeam creates a team via JSON API
+func (h *Handlers) APICreateTeam(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only admins can create teams",
+		})
+		return
+	}
+
+	var req team.Team
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A team name is required",
+		})
+		return
+	}
+
+	req.ID = ""
+	if err := h.teamRepo.Create(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create team: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, req)
+}
+
+// APIDeleteTeam deletes a team via JSON API. Its services become unowned.
+func (h *Handlers) APIDeleteTeam(c *gin.Context) {
+	if !h.caller(c).Admin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only admins can delete teams",
+		})
+		return
+	}
+
+	if err := h.teamRepo.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete team: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team deleted successfully",
+	})
+}
+
+// APIListTeamMembers returns the members of a team as JSON
+func (h *Handlers) APIListTeamMembers(c *gin.Context) {
+	id := c.Param("id")
+
+	if !h.caller(c).Can(id, team.RoleViewer) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Team not found",
+		})
+		return
+	}
+
+	members, err := h.teamRepo.ListMembers(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch team members",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"members": members,
+		"count":   len(members),
+	})
+}
+
+// APISetTeamMember adds or updates a team member via JSON API
+func (h *Handlers) APISetTeamMember(c *gin.Context) {
+	id := c.Param("id")
+
+	if !h.caller(c).Can(id, team.RoleOwner) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only team owners can manage members",
+		})
+		return
+	}
+
+	var req struct {
+		Role team.Role `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !req.Role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A valid role is required",
+		})
+		return
+	}
+	if c.Param("username") == team.Anonymous {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The anonymous user cannot join teams",
+		})
+		return
+	}
+
+	membership := &team.Membership{TeamID: id, Username: c.Param("username"), Role: req.Role}
+	if err := h.teamRepo.SetMember(c.Request.Context(), membership); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to set team member: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, membership)
+}
+
+// APIRemoveTeamMember removes a team member via JSON API
+func (h *Handlers) APIRemoveTeamMember(c *gin.Context) {
+	id := c.Param("id")
+
+	if !h.caller(c).Can(id, team.RoleOwner) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only team owners can manage members",
+		})
+		return
+	}
+
+	if err := h.teamRepo.RemoveMember(c.Request.Context(), id, c.Param("username")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove team member: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team member removed successfully",
+	})
+}
+
+// caller returns the caller attached to the request by the identity
+// middleware, falling back to an anonymous user with no teams
+func (h *Handlers) caller(c *gin.Context) *team.Caller {
+	if caller := team.CallerFromContext(c.Request.Context()); caller != nil {
+		return caller
+	}
+	return &team.Caller{Username: team.Anonymous}
+}
+
+// visibleTeams returns every team for admins and the caller's own teams
+// for everyone else
+func (h *Handlers) visibleTeams(c *gin.Context) ([]team.Team, error) {
+	caller := h.caller(c)
+	if caller.Admin {
+		return h.teamRepo.GetAll(c.Request.Context())
+	}
+
+	teams := make([]team.Team, 0, len(caller.Memberships))
+	for _, m := range caller.Memberships {
+		teams = append(teams, team.Team{ID: m.TeamID, Name: m.TeamName, CreatedAt: m.CreatedAt})
+	}
+	return teams, nil
+}
+
+// editableTeams returns the teams in which the caller can manage services
+func (h *Handlers) editableTeams(c *gin.Context) []team.Team {
+	teams, err := h.visibleTeams(c)
+	if err != nil {
+		return nil
+	}
+
+	caller := h.caller(c)
+	editable := make([]team.Team, 0, len(teams))
+	for _, t := range teams {
+		if caller.Can(t.ID, team.RoleEditor) {
+			editable = append(editable, t)
+		}
+	}
+	return editable
+}
+
+// owningTeam picks the team a new service should belong to: the requested
+// one, otherwise the active team, otherwise the caller's only editable team
+func (h *Handlers) owningTeam(c *gin.Context, requested string) string {
+	if requested != "" {
+		return requested
+	}
+
+	caller := h.caller(c)
+	if caller.ActiveTeamID != "" {
+		return caller.ActiveTeamID
+	}
+
+	if editable := h.editableTeams(c); len(editable) == 1 && !caller.Admin {
+		return editable[0].ID
+	}
+	return ""
+}
+
+// canEditService reports whether the caller may change services owned by
+// teamID. Unowned services can only be managed by admins.
+func (h *Handlers) canEditService(c *gin.Context, teamID string) bool {
+	return h.caller(c).Can(teamID, team.RoleEditor)
+}
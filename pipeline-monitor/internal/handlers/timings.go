@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timingsWindow is how far back the average request phases reach
+const timingsWindow = time.Hour
+
+// waterfallPhase is one bar of a request waterfall, positioned in percent
+// of the longest request shown
+type waterfallPhase struct {
+	Name   string
+	Millis float64
+	Offset float64
+	Width  float64
+	Color  string
+}
+
+// waterfall is the phases of one (or an average) request
+type waterfall struct {
+	Label  string
+	Total  float64
+	Phases []waterfallPhase
+}
+
+// ServiceTimingsPartial renders the request phases of the latest check of
+// a service next to the average over the last hour, as waterfalls
+func (h *Handlers) ServiceTimingsPartial(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := h.serviceRepo.GetByID(c.Request.Context(), id); err != nil {
+		c.HTML(http.StatusNotFound, "partials/service-timings.html", gin.H{
+			"error": "Service not found",
+		})
+		return
+	}
+
+	checks, err := h.checkRepo.History(c.Request.Context(), id, time.Now().Add(-timingsWindow))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "partials/service-timings.html", gin.H{
+			"error": "Failed to load check history",
+		})
+		return
+	}
+
+	// Connection phases are averaged over the checks that opened a
+	// connection, so reused connections do not hide slow handshakes
+	var latest *service.HealthCheck
+	var sum service.Timings
+	count, connections := 0, 0
+	for i := range checks {
+		t := checks[i].Timings
+		if t == nil {
+			continue
+		}
+		latest = &checks[i]
+		if !t.Reused {
+			sum.DNS += t.DNS
+			sum.Connect += t.Connect
+			sum.TLS += t.TLS
+			connections++
+		}
+		sum.TTFB += t.TTFB
+		sum.Transfer += t.Transfer
+		count++
+	}
+
+	if latest == nil {
+		c.HTML(http.StatusOK, "partials/service-timings.html", gin.H{})
+		return
+	}
+
+	n := float64(count)
+	average := service.Timings{
+		TTFB:     sum.TTFB / n,
+		Transfer: sum.Transfer / n,
+	}
+	if connections > 0 {
+		c := float64(connections)
+		average.DNS = sum.DNS / c
+		average.Connect = sum.Connect / c
+		average.TLS = sum.TLS / c
+	}
+
+	latestLabel := "Latest check"
+	if latest.Timings.Reused {
+		latestLabel = "Latest check, reused connection"
+	}
+
+	scale := latest.Timings.Total()
+	if average.Total() > scale {
+		scale = average.Total()
+	}
+
+	c.HTML(http.StatusOK, "partials/service-timings.html", gin.H{
+		"checkedAt":   latest.Timestamp,
+		"count":       count,
+		"connections": connections,
+		"waterfalls": []waterfall{
+			buildWaterfall(latestLabel, latest.Timings, scale),
+			buildWaterfall("Average, last hour", &average, scale),
+		},
+	})
+}
+
+// buildWaterfall lays out the phases of a request one after another
+func buildWaterfall(label string, t *service.Timings, scale float64) waterfall {
+	w := waterfall{Label: label, Total: t.Total()}
+
+	phases := []struct {
+		name   string
+		millis float64
+		color  string
+	}{
+		{"DNS", t.DNS, "bg-teal-500"},
+		{"Connect", t.Connect, "bg-yellow-500"},
+		{"TLS", t.TLS, "bg-purple-500"},
+		{"Time to first byte", t.TTFB, "bg-green-500"},
+		{"Transfer", t.Transfer, "bg-blue-500"},
+	}
+
+	offset := 0.0
+	for _, p := range phases {
+		phase := waterfallPhase{Name: p.name, Millis: p.millis, Color: p.color}
+		if scale > 0 {
+			phase.Offset = offset / scale * 100
+			phase.Width = p.millis / scale * 100
+		}
+		w.Phases = append(w.Phases, phase)
+		offset += p.millis
+	}
+
+	return w
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// transportForm holds the transport fields of the service form
+type transportForm struct {
+	TransportClientCert string `form:"transport_client_cert"`
+	TransportCABundle   string `form:"transport_ca_bundle"`
+	TransportSkipVerify bool   `form:"transport_skip_verify"`
+	TransportServerName string `form:"transport_server_name"`
+	TransportProxy      string `form:"transport_proxy"`
+}
+
+// transport builds the transport settings of an HTTP or synthetic service
+// from the form. Services with other check types, and checks left at the
+// defaults, get none.
+func (f transportForm) transport(checkType string) *service.Transport {
+	if !service.CheckType(checkType).SendsHTTP() {
+		return nil
+	}
+
+	transport := &service.Transport{
+		ClientCert: f.TransportClientCert,
+		CABundle:   f.TransportCABundle,
+		SkipVerify: f.TransportSkipVerify,
+		ServerName: strings.TrimSpace(f.TransportServerName),
+		Proxy:      strings.TrimSpace(f.TransportProxy),
+	}
+	if *transport == (service.Transport{}) {
+		return nil
+	}
+	return transport
+}
+
+// caBundleNames returns the configured CA bundles HTTP checks can trust
+func (h *Handlers) caBundleNames() []string {
+	names := make([]string, 0, len(h.config.CABundles))
+	for name := range h.config.CABundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/audit"
+
+	"github.com/google/uuid"
+)
+
+// AuditRepository implements the audit.Repository interface using PostgreSQL
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Record stores a single audit entry
+func (r *AuditRepository) Record(ctx context.Context, entry *audit.Entry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (id, actor, source, action, service_id, service_name, team_id, created_at, before, after, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		entry.ID, entry.Actor, entry.Source, entry.Action,
+		entry.ServiceID, entry.ServiceName, nullString(entry.TeamID), entry.Timestamp,
+		nullJSON(entry.Before), nullJSON(entry.After), changes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// List returns audit entries matching the filter, newest first
+func (r *AuditRepository) List(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	scope, args := teamScope(ctx, "team_id", nil)
+	conditions := []string{scope}
+
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.Actor != "" {
+		addCondition("actor = $%d", filter.Actor)
+	}
+	if filter.Source != "" {
+		addCondition("source = $%d", filter.Source)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.ServiceID != "" {
+		addCondition("service_id = $%d", filter.ServiceID)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		addCondition("created_at <= $%d", filter.Until)
+	}
+
+	query := `
+		SELECT id, actor, source, action, service_id, service_name, team_id,
+		       created_at, before, after, changes
+		FROM audit_log
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at DESC
+	`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []audit.Entry
+	for rows.Next() {
+		var entry audit.Entry
+		var serviceID, serviceName, teamID sql.NullString
+		var before, after, changes []byte
+
+		err := rows.Scan(
+			&entry.ID, &entry.Actor, &entry.Source, &entry.Action,
+			&serviceID, &serviceName, &teamID, &entry.Timestamp,
+			&before, &after, &changes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+
+		entry.ServiceID = serviceID.String
+		entry.ServiceName = serviceName.String
+		entry.TeamID = teamID.String
+		entry.Before = before
+		entry.After = after
+		if len(changes) > 0 {
+			if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+				return nil, fmt.Errorf("failed to decode audit changes: %w", err)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteBefore removes audit entries older than the cutoff
+func (r *AuditRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM audit_log WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// nullJSON stores empty JSON documents as NULL
+func nullJSON(data json.RawMessage) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return []byte(data)
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/certificate"
+
+	"github.com/lib/pq"
+)
+
+// CertificateRepository implements the certificate.Repository interface using PostgreSQL
+type CertificateRepository struct {
+	db *sql.DB
+}
+
+// NewCertificateRepository creates a new certificate repository
+func NewCertificateRepository(db *sql.DB) *CertificateRepository {
+	return &CertificateRepository{db: db}
+}
+
+// certificateColumns is the column list matching scanChain. Queries must
+// join services as s.
+const certificateColumns = `
+	c.service_id, s.name, c.host, c.chain, c.problem, c.error, c.checked_at
+`
+
+// scanChain reads a row selected with certificateColumns
+func scanChain(row rowScanner) (*certificate.Chain, error) {
+	var chain certificate.Chain
+	var certs []byte
+
+	err := row.Scan(
+		&chain.ServiceID, &chain.ServiceName, &chain.Host, &certs, &chain.Problem, &chain.Error, &chain.CheckedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(certs, &chain.Certificates); err != nil {
+		return nil, fmt.Errorf("failed to decode certificate chain: %w", err)
+	}
+	return &chain, nil
+}
+
+// Save replaces the chain recorded for a service
+func (r *CertificateRepository) Save(ctx context.Context, chain *certificate.Chain) error {
+	certs, err := json.Marshal(chain.Certificates)
+	if err != nil {
+		return fmt.Errorf("failed to encode certificate chain: %w", err)
+	}
+
+	var notAfter any
+	if t := chain.NotAfter(); !t.IsZero() {
+		notAfter = t
+	}
+
+	query := `
+		INSERT INTO certificates (service_id, host, chain, not_after, problem, error, checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (service_id) DO UPDATE SET
+			host = EXCLUDED.host,
+			chain = EXCLUDED.chain,
+			not_after = EXCLUDED.not_after,
+			problem = EXCLUDED.problem,
+			error = EXCLUDED.error,
+			checked_at = EXCLUDED.checked_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		chain.ServiceID, chain.Host, certs, notAfter, chain.Problem, chain.Error, chain.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save certificate chain: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the chain recorded for a service, or nil if there is none
+func (r *CertificateRepository) Get(ctx context.Context, serviceID string) (*certificate.Chain, error) {
+	query := `SELECT ` + certificateColumns + `
+		FROM certificates c JOIN services s ON s.id = c.service_id
+		WHERE c.service_id = $1`
+
+	chain, err := scanChain(r.db.QueryRowContext(ctx, query, serviceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificate chain: %w", err)
+	}
+
+	return chain, nil
+}
+
+// ListExpiring returns the chains of the caller's services that expire
+// before the given time or failed validation, soonest first
+func (r *CertificateRepository) ListExpiring(ctx context.Context, before time.Time) ([]certificate.Chain, error) {
+	args := []any{before, certificate.ProblemHostnameMismatch, certificate.ProblemChainInvalid}
+	scope, args := teamScope(ctx, "s.team_id", args)
+
+	query := `SELECT ` + certificateColumns + `
+		FROM certificates c JOIN services s ON s.id = c.service_id
+		WHERE (c.not_after < $1 OR c.problem IN ($2, $3)) AND ` + scope + `
+		ORDER BY c.not_after NULLS FIRST, s.name`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expiring certificates: %w", err)
+	}
+	defer rows.Close()
+
+	var chains []certificate.Chain
+	for rows.Next() {
+		chain, err := scanChain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan certificate chain: %w", err)
+		}
+		chains = append(chains, *chain)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return chains, nil
+}
+
+// RecordAlert stores an alert unless the same certificate already alerted
+// at that threshold, and reports whether it was new
+func (r *CertificateRepository) RecordAlert(ctx context.Context, alert *certificate.Alert) (bool, error) {
+	query := `
+		INSERT INTO certificate_alerts (service_id, host, subject, not_after, days)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (service_id, not_after, days) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		alert.ServiceID, alert.Host, alert.Subject, alert.NotAfter, alert.Days,
+	).Scan(&alert.ID, &alert.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record certificate alert: %w", err)
+	}
+
+	return true, nil
+}
+
+// ListAlerts returns certificate alerts of the given services since the
+// given time, newest first
+func (r *CertificateRepository) ListAlerts(ctx context.Context, serviceIDs []string, since time.Time, limit int) ([]certificate.Alert, error) {
+	var args []any
+	conditions := []string{"TRUE"}
+
+	if serviceIDs != nil {
+		args = append(args, pq.Array(serviceIDs))
+		conditions = append(conditions, fmt.Sprintf("a.service_id = ANY($%d)", len(args)))
+	}
+	if !since.IsZero() {
+		args = append(args, since)
+		conditions = append(conditions, fmt.Sprintf("a.created_at >= $%d", len(args)))
+	}
+
+	query := `
+		SELECT a.id, a.service_id, s.name, a.host, a.subject, a.not_after, a.days, a.created_at
+		FROM certificate_alerts a JOIN services s ON s.id = a.service_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY a.created_at DESC`
+
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query certificate alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []certificate.Alert
+	for rows.Next() {
+		var alert certificate.Alert
+		err := rows.Scan(
+			&alert.ID, &alert.ServiceID, &alert.ServiceName, &alert.Host, &alert.Subject,
+			&alert.NotAfter, &alert.Days, &alert.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan certificate alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/lib/pq"
+)
+
+// CheckRepository implements the service.CheckRepository interface using
+// PostgreSQL. Raw checks live in health_checks and rollups in
+// health_check_rollups; queries over a range pick the resolution using the
+// retention policy.
+type CheckRepository struct {
+	db        *sql.DB
+	retention service.Retention
+}
+
+// NewCheckRepository creates a new health check history repository
+func NewCheckRepository(db *sql.DB, retention service.Retention) *CheckRepository {
+	return &CheckRepository{db: db, retention: retention}
+}
+
+// Record stores the result of a single health check
+func (r *CheckRepository) Record(ctx context.Context, check *service.HealthCheck) error {
+	var timings []byte
+	if check.Timings != nil {
+		data, err := json.Marshal(check.Timings)
+		if err != nil {
+			return fmt.Errorf("failed to encode timings: %w", err)
+		}
+		timings = data
+	}
+
+	var metrics []byte
+	if len(check.Metrics) > 0 {
+		data, err := json.Marshal(check.Metrics)
+		if err != nil {
+			return fmt.Errorf("failed to encode metrics: %w", err)
+		}
+		metrics = data
+	}
+
+	query := `
+		INSERT INTO health_checks (service_id, status, response_time, error, checked_at, timings, metrics)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		check.ServiceID, check.Status, check.ResponseTime, nullString(check.Error), check.Timestamp, timings, metrics,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record health check: %w", err)
+	}
+
+	return nil
+}
+
+// DailyUptime counts checks and healthy checks per UTC day for each service
+// since the given time, read from the daily rollups. Days without checks
+// are omitted.
+func (r *CheckRepository) DailyUptime(ctx context.Context, serviceIDs []string, since time.Time) (map[string][]service.DailyUptime, error) {
+	query := `
+		SELECT service_id, bucket_start, count, count - failures
+		FROM health_check_rollups
+		WHERE resolution = $1 AND service_id = ANY($2) AND bucket_start >= $3
+		ORDER BY service_id, bucket_start
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		service.ResolutionDay, pq.Array(serviceIDs), service.ResolutionDay.BucketStart(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily uptime: %w", err)
+	}
+	defer rows.Close()
+
+	uptime := make(map[string][]service.DailyUptime)
+	for rows.Next() {
+		var serviceID string
+		var day service.DailyUptime
+		if err := rows.Scan(&serviceID, &day.Day, &day.Checks, &day.Healthy); err != nil {
+			return nil, fmt.Errorf("failed to scan daily uptime: %w", err)
+		}
+		day.Day = day.Day.UTC()
+		uptime[serviceID] = append(uptime[serviceID], day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return uptime, nil
+}
+
+// Uptime counts checks and healthy checks of a service since the given
+// time, at the resolution the retention policy picks for the range
+func (r *CheckRepository) Uptime(ctx context.Context, serviceID string, since time.Time) (service.Uptime, error) {
+	var uptime service.Uptime
+
+	res := r.retention.Resolution(since, time.Now())
+
+	var row *sql.Row
+	if res == service.ResolutionRaw {
+		query := `
+			SELECT COUNT(*), COUNT(*) FILTER (WHERE status IN ('healthy', 'warning'))
+			FROM health_checks
+			WHERE service_id = $1 AND checked_at >= $2
+		`
+		row = r.db.QueryRowContext(ctx, query, serviceID, since)
+	} else {
+		query := `
+			SELECT COALESCE(SUM(count), 0), COALESCE(SUM(count - failures), 0)
+			FROM health_check_rollups
+			WHERE service_id = $1 AND resolution = $2 AND bucket_start >= $3
+		`
+		row = r.db.QueryRowContext(ctx, query, serviceID, res, res.BucketStart(since))
+	}
+
+	if err := row.Scan(&uptime.Checks, &uptime.Healthy); err != nil {
+		return uptime, fmt.Errorf("failed to query uptime: %w", err)
+	}
+
+	return uptime, nil
+}
+
+// History returns the checks of a service since the given time, oldest first
+func (r *CheckRepository) History(ctx context.Context, serviceID string, since time.Time) ([]service.HealthCheck, error) {
+	query := `
+		SELECT service_id, status, response_time, error, checked_at, timings, metrics
+		FROM health_checks
+		WHERE service_id = $1 AND checked_at >= $2
+		ORDER BY checked_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query check history: %w", err)
+	}
+	defer rows.Close()
+
+	var checks []service.HealthCheck
+	for rows.Next() {
+		var check service.HealthCheck
+		var checkError sql.NullString
+		var timings, metrics []byte
+
+		if err := rows.Scan(&check.ServiceID, &check.Status, &check.ResponseTime, &checkError, &check.Timestamp, &timings, &metrics); err != nil {
+			return nil, fmt.Errorf("failed to scan health check: %w", err)
+		}
+
+		check.Error = checkError.String
+		if timings != nil {
+			check.Timings = &service.Timings{}
+			if err := json.Unmarshal(timings, check.Timings); err != nil {
+				return nil, fmt.Errorf("failed to decode timings: %w", err)
+			}
+		}
+		if metrics != nil {
+			if err := json.Unmarshal(metrics, &check.Metrics); err != nil {
+				return nil, fmt.Errorf("failed to decode metrics: %w", err)
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return checks, nil
+}
+
+// Transitions returns the status changes of the given services since the
+// given time, newest first. Since is filled from the previous change when
+// it happened within the same period.
+func (r *CheckRepository) Transitions(ctx context.Context, serviceIDs []string, since time.Time, limit int) ([]service.Transition, error) {
+	query := `
+		WITH checks AS (
+			SELECT service_id, status, error, checked_at,
+			       LAG(status) OVER (PARTITION BY service_id ORDER BY checked_at) AS previous
+			FROM health_checks
+			WHERE service_id = ANY($1) AND checked_at >= $2
+		), changes AS (
+			SELECT service_id, previous, status, error, checked_at,
+			       LAG(checked_at) OVER (PARTITION BY service_id ORDER BY checked_at) AS previous_change
+			FROM checks
+			WHERE previous IS NOT NULL AND previous <> status
+		)
+		SELECT c.service_id, s.name, c.previous, c.status, c.error, c.checked_at, c.previous_change
+		FROM changes c
+		JOIN services s ON s.id = c.service_id
+		ORDER BY c.checked_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(serviceIDs), since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var transitions []service.Transition
+	for rows.Next() {
+		var t service.Transition
+		var checkError sql.NullString
+		var previousChange sql.NullTime
+
+		if err := rows.Scan(&t.ServiceID, &t.ServiceName, &t.From, &t.To, &checkError, &t.At, &previousChange); err != nil {
+			return nil, fmt.Errorf("failed to scan status transition: %w", err)
+		}
+
+		t.Error = checkError.String
+		if previousChange.Valid {
+			t.Since = &previousChange.Time
+		}
+		transitions = append(transitions, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return transitions, nil
+}
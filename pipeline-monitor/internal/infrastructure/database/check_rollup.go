@@ -0,0 +1,270 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// rollupColumns is the column list matching scanRollup
+const rollupColumns = `
+	service_id, resolution, bucket_start, count, failures,
+	min_response_time, max_response_time, sum_response_time, sketch
+`
+
+// scanRollup reads a row selected with rollupColumns
+func scanRollup(row rowScanner) (*service.Rollup, error) {
+	var rollup service.Rollup
+	var sketch []byte
+
+	err := row.Scan(
+		&rollup.ServiceID, &rollup.Resolution, &rollup.Start, &rollup.Count, &rollup.Failures,
+		&rollup.Min, &rollup.Max, &rollup.Sum, &sketch,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(sketch, &rollup.Sketch); err != nil {
+		return nil, fmt.Errorf("failed to decode sketch: %w", err)
+	}
+	return &rollup, nil
+}
+
+// Series returns the history of a service since the given time as buckets,
+// oldest first. Short recent ranges come from raw checks, one bucket per
+// check; longer ones from the finest rollup that covers the range.
+func (r *CheckRepository) Series(ctx context.Context, serviceID string, since time.Time) (service.Resolution, []service.Rollup, error) {
+	res := r.retention.Resolution(since, time.Now())
+
+	if res == service.ResolutionRaw {
+		checks, err := r.History(ctx, serviceID, since)
+		if err != nil {
+			return res, nil, err
+		}
+		return res, service.RollupChecks(checks, res), nil
+	}
+
+	rollups, err := r.rollups(ctx, res, "service_id = $3", res.BucketStart(since), serviceID)
+	return res, rollups, err
+}
+
+// LatestRollup returns the start of the newest bucket at a resolution, or
+// the zero time if nothing was rolled up yet
+func (r *CheckRepository) LatestRollup(ctx context.Context, res service.Resolution) (time.Time, error) {
+	var latest sql.NullTime
+
+	query := `SELECT MAX(bucket_start) FROM health_check_rollups WHERE resolution = $1`
+	if err := r.db.QueryRowContext(ctx, query, res).Scan(&latest); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query latest rollup: %w", err)
+	}
+
+	return latest.Time, nil
+}
+
+// RollUp (re)computes the buckets at a resolution from its source
+// resolution, starting with the bucket containing from. Buckets are
+// upserted, so the current, still-filling bucket is simply recomputed on
+// the next run. Each run covers a bounded window after the first source
+// data at or after from, so a large backlog is worked off over several runs.
+func (r *CheckRepository) RollUp(ctx context.Context, res service.Resolution, from time.Time) (int, error) {
+	step := res.Step()
+	if step == 0 {
+		return 0, fmt.Errorf("cannot roll up to resolution %s", res)
+	}
+	source := res.Source()
+
+	first, err := r.firstSourceTime(ctx, source, res.BucketStart(from))
+	if err != nil || first.IsZero() {
+		return 0, err
+	}
+
+	start := res.BucketStart(first)
+	end := start.Add(step * rollupBatchBuckets)
+
+	var rollups []service.Rollup
+	if source == service.ResolutionRaw {
+		checks, err := r.checksBetween(ctx, start, end)
+		if err != nil {
+			return 0, err
+		}
+		rollups = service.RollupChecks(checks, res)
+	} else {
+		finer, err := r.rollups(ctx, source, "bucket_start < $3", start, end)
+		if err != nil {
+			return 0, err
+		}
+		rollups = service.MergeRollups(finer, res)
+	}
+
+	if err := r.saveRollups(ctx, rollups); err != nil {
+		return 0, err
+	}
+
+	return len(rollups), nil
+}
+
+// rollupBatchBuckets bounds how many buckets per service a single RollUp
+// run covers
+const rollupBatchBuckets = 1440
+
+// DeleteBefore removes history at a resolution older than the cutoff
+func (r *CheckRepository) DeleteBefore(ctx context.Context, res service.Resolution, cutoff time.Time) (int64, error) {
+	var result sql.Result
+	var err error
+
+	if res == service.ResolutionRaw {
+		result, err = r.db.ExecContext(ctx, `DELETE FROM health_checks WHERE checked_at < $1`, cutoff)
+	} else {
+		result, err = r.db.ExecContext(ctx,
+			`DELETE FROM health_check_rollups WHERE resolution = $1 AND bucket_start < $2`, res, cutoff)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete %s check history: %w", res, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// firstSourceTime returns the time of the first raw check or bucket start
+// at or after from, or the zero time if there is none
+func (r *CheckRepository) firstSourceTime(ctx context.Context, source service.Resolution, from time.Time) (time.Time, error) {
+	var first sql.NullTime
+	var err error
+
+	if source == service.ResolutionRaw {
+		err = r.db.QueryRowContext(ctx,
+			`SELECT MIN(checked_at) FROM health_checks WHERE checked_at >= $1`, from,
+		).Scan(&first)
+	} else {
+		err = r.db.QueryRowContext(ctx,
+			`SELECT MIN(bucket_start) FROM health_check_rollups WHERE resolution = $1 AND bucket_start >= $2`,
+			source, from,
+		).Scan(&first)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query %s check history: %w", source, err)
+	}
+
+	return first.Time, nil
+}
+
+// checksBetween returns the raw checks of all services in [start, end)
+func (r *CheckRepository) checksBetween(ctx context.Context, start, end time.Time) ([]service.HealthCheck, error) {
+	query := `
+		SELECT service_id, status, response_time, checked_at
+		FROM health_checks
+		WHERE checked_at >= $1 AND checked_at < $2
+		ORDER BY service_id, checked_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query health checks: %w", err)
+	}
+	defer rows.Close()
+
+	var checks []service.HealthCheck
+	for rows.Next() {
+		var check service.HealthCheck
+		if err := rows.Scan(&check.ServiceID, &check.Status, &check.ResponseTime, &check.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan health check: %w", err)
+		}
+		checks = append(checks, check)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return checks, nil
+}
+
+// rollups returns the rollups at a resolution from start on that match an
+// extra condition on $3, oldest first
+func (r *CheckRepository) rollups(ctx context.Context, res service.Resolution, condition string, start time.Time, arg any) ([]service.Rollup, error) {
+	query := `SELECT ` + rollupColumns + `
+		FROM health_check_rollups
+		WHERE resolution = $1 AND bucket_start >= $2 AND ` + condition + `
+		ORDER BY service_id, bucket_start`
+
+	rows, err := r.db.QueryContext(ctx, query, res, start, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var rollups []service.Rollup
+	for rows.Next() {
+		rollup, err := scanRollup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rollup: %w", err)
+		}
+		rollups = append(rollups, *rollup)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return rollups, nil
+}
+
+// saveRollups upserts rollups in a single transaction
+func (r *CheckRepository) saveRollups(ctx context.Context, rollups []service.Rollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO health_check_rollups (`+rollupColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (service_id, resolution, bucket_start) DO UPDATE SET
+			count = EXCLUDED.count,
+			failures = EXCLUDED.failures,
+			min_response_time = EXCLUDED.min_response_time,
+			max_response_time = EXCLUDED.max_response_time,
+			sum_response_time = EXCLUDED.sum_response_time,
+			sketch = EXCLUDED.sketch
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare rollup upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rollup := range rollups {
+		sketch, err := json.Marshal(rollup.Sketch)
+		if err != nil {
+			return fmt.Errorf("failed to encode sketch: %w", err)
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			rollup.ServiceID, rollup.Resolution, rollup.Start, rollup.Count, rollup.Failures,
+			rollup.Min, rollup.Max, rollup.Sum, sketch,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save rollup: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rollups: %w", err)
+	}
+
+	return nil
+}
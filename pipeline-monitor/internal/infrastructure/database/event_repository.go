@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pipeline-monitor/internal/domain/event"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// EventRepository implements the event.Repository interface using PostgreSQL
+type EventRepository struct {
+	db *sql.DB
+}
+
+// NewEventRepository creates a new event repository
+func NewEventRepository(db *sql.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// eventColumns is the column list matching scanEvent
+const eventColumns = `id, service_id, tag, kind, title, version, author, link, occurred_at, created_at, committed_at`
+
+// scanEvent reads a row selected with eventColumns
+func scanEvent(row rowScanner) (*event.Event, error) {
+	var e event.Event
+	var serviceID sql.NullString
+	var committedAt sql.NullTime
+
+	err := row.Scan(
+		&e.ID, &serviceID, &e.Tag, &e.Kind, &e.Title, &e.Version, &e.Author, &e.Link, &e.OccurredAt, &e.CreatedAt, &committedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	e.ServiceID = serviceID.String
+	if committedAt.Valid {
+		e.CommittedAt = &committedAt.Time
+	}
+	return &e, nil
+}
+
+// Create stores an event
+func (r *EventRepository) Create(ctx context.Context, e *event.Event) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+
+	var serviceID any
+	if e.ServiceID != "" {
+		serviceID = e.ServiceID
+	}
+
+	query := `
+		INSERT INTO events (id, service_id, tag, kind, title, version, author, link, occurred_at, committed_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		e.ID, serviceID, e.Tag, e.Kind, e.Title, e.Version, e.Author, e.Link, e.OccurredAt, e.CommittedAt,
+	).Scan(&e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID returns an event by ID
+func (r *EventRepository) GetByID(ctx context.Context, id string) (*event.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = $1`
+
+	e, err := scanEvent(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("event with ID %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	return e, nil
+}
+
+// ListForService returns the events of a service and the tag-wide events
+// of its tags that occurred between from and to (either may be zero),
+// oldest first
+func (r *EventRepository) ListForService(ctx context.Context, serviceID string, tags []string, from, to time.Time) ([]event.Event, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	return r.list(ctx, `(service_id = $1 OR tag = ANY($2))`, []any{serviceID, pq.Array(tags)}, from, to)
+}
+
+// ListByKind returns the events of a kind of the given services that
+// occurred between from and to (either may be zero), oldest first.
+// Tag-wide events are not included.
+func (r *EventRepository) ListByKind(ctx context.Context, kind event.Kind, serviceIDs []string, from, to time.Time) ([]event.Event, error) {
+	if serviceIDs == nil {
+		serviceIDs = []string{}
+	}
+	return r.list(ctx, `kind = $1 AND service_id = ANY($2)`, []any{kind, pq.Array(serviceIDs)}, from, to)
+}
+
+// ListForTag returns the tag-wide events of a tag that occurred between
+// from and to (either may be zero), oldest first
+func (r *EventRepository) ListForTag(ctx context.Context, tag string, from, to time.Time) ([]event.Event, error) {
+	return r.list(ctx, `tag = $1`, []any{tag}, from, to)
+}
+
+// list returns the events matching a condition within a time range
+func (r *EventRepository) list(ctx context.Context, where string, args []any, from, to time.Time) ([]event.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE ` + where
+
+	if !from.IsZero() {
+		args = append(args, from)
+		query += fmt.Sprintf(" AND occurred_at >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		query += fmt.Sprintf(" AND occurred_at <= $%d", len(args))
+	}
+	query += " ORDER BY occurred_at, id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []event.Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, *e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return events, nil
+}
+
+// Delete removes an event
+func (r *EventRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM events WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+
+	return expectOneRow(result, fmt.Sprintf("event with ID %s not found", id))
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pipeline-monitor/internal/domain/heartbeat"
+)
+
+// HeartbeatRepository implements the heartbeat.Repository interface using PostgreSQL
+type HeartbeatRepository struct {
+	db *sql.DB
+}
+
+// NewHeartbeatRepository creates a new heartbeat repository
+func NewHeartbeatRepository(db *sql.DB) *HeartbeatRepository {
+	return &HeartbeatRepository{db: db}
+}
+
+// pingColumns is the column list matching scanPing
+const pingColumns = `id, service_id, kind, payload, remote_addr, received_at, duration_ms`
+
+// scanPing reads a row selected with pingColumns
+func scanPing(row rowScanner) (*heartbeat.Ping, error) {
+	var ping heartbeat.Ping
+	var duration sql.NullInt64
+
+	err := row.Scan(
+		&ping.ID, &ping.ServiceID, &ping.Kind, &ping.Payload, &ping.RemoteAddr, &ping.ReceivedAt, &duration,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if duration.Valid {
+		ms := int(duration.Int64)
+		ping.Duration = &ms
+	}
+	return &ping, nil
+}
+
+// Record stores a ping. Success and fail pings get the duration since the
+// start of the run, if a start ping opened it.
+func (r *HeartbeatRepository) Record(ctx context.Context, ping *heartbeat.Ping) error {
+	if ping.Kind != heartbeat.KindStart {
+		start, err := r.runStart(ctx, ping.ServiceID)
+		if err != nil {
+			return err
+		}
+		if start != nil {
+			ms := int(ping.ReceivedAt.Sub(*start).Milliseconds())
+			ping.Duration = &ms
+		}
+	}
+
+	var duration any
+	if ping.Duration != nil {
+		duration = *ping.Duration
+	}
+
+	query := `
+		INSERT INTO heartbeat_pings (service_id, kind, payload, remote_addr, received_at, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		ping.ServiceID, ping.Kind, ping.Payload, ping.RemoteAddr, ping.ReceivedAt, duration,
+	).Scan(&ping.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record ping: %w", err)
+	}
+
+	return nil
+}
+
+// runStart returns when the current run of a service started: the latest
+// start ping after its last success or fail ping, or nil if there is none
+func (r *HeartbeatRepository) runStart(ctx context.Context, serviceID string) (*time.Time, error) {
+	query := `
+		SELECT received_at FROM heartbeat_pings
+		WHERE service_id = $1 AND kind = $2 AND received_at > COALESCE((
+			SELECT MAX(received_at) FROM heartbeat_pings
+			WHERE service_id = $1 AND kind <> $2
+		), '-infinity')
+		ORDER BY received_at DESC
+		LIMIT 1
+	`
+
+	var start time.Time
+	err := r.db.QueryRowContext(ctx, query, serviceID, heartbeat.KindStart).Scan(&start)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up run start: %w", err)
+	}
+
+	return &start, nil
+}
+
+// LastCompleted returns the latest success or fail ping of a service, or
+// nil if there is none
+func (r *HeartbeatRepository) LastCompleted(ctx context.Context, serviceID string) (*heartbeat.Ping, error) {
+	query := `SELECT ` + pingColumns + `
+		FROM heartbeat_pings
+		WHERE service_id = $1 AND kind <> $2
+		ORDER BY received_at DESC
+		LIMIT 1`
+
+	ping, err := scanPing(r.db.QueryRowContext(ctx, query, serviceID, heartbeat.KindStart))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last ping: %w", err)
+	}
+
+	return ping, nil
+}
+
+// List returns the latest pings of a service, newest first
+func (r *HeartbeatRepository) List(ctx context.Context, serviceID string, limit int) ([]heartbeat.Ping, error) {
+	query := `SELECT ` + pingColumns + `
+		FROM heartbeat_pings
+		WHERE service_id = $1
+		ORDER BY received_at DESC
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pings: %w", err)
+	}
+	defer rows.Close()
+
+	var pings []heartbeat.Ping
+	for rows.Next() {
+		ping, err := scanPing(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ping: %w", err)
+		}
+		pings = append(pings, *ping)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return pings, nil
+}
+
+// DeleteBefore removes pings received before the given time
+func (r *HeartbeatRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM heartbeat_pings WHERE received_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune pings: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/incident"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// IncidentRepository implements the incident.Repository interface using PostgreSQL
+type IncidentRepository struct {
+	db *sql.DB
+}
+
+// NewIncidentRepository creates a new incident repository
+func NewIncidentRepository(db *sql.DB) *IncidentRepository {
+	return &IncidentRepository{db: db}
+}
+
+// incidentColumns is the column list matching scanIncident. Queries must
+// join services as s.
+const incidentColumns = `
+	i.id, i.service_id, s.name, i.title, i.message, i.cause, i.status, i.automatic,
+	i.started_at, i.resolved_at, i.updated_at
+`
+
+// scanIncident reads a row selected with incidentColumns
+func scanIncident(row rowScanner) (*incident.Incident, error) {
+	var inc incident.Incident
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&inc.ID, &inc.ServiceID, &inc.ServiceName, &inc.Title, &inc.Message, &inc.Cause,
+		&inc.Status, &inc.Automatic, &inc.StartedAt, &resolvedAt, &inc.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedAt.Valid {
+		inc.ResolvedAt = &resolvedAt.Time
+	}
+	return &inc, nil
+}
+
+// Create opens a new incident
+func (r *IncidentRepository) Create(ctx context.Context, inc *incident.Incident) error {
+	if inc.ID == "" {
+		inc.ID = uuid.New().String()
+	}
+	if inc.Status == "" {
+		inc.Status = incident.StatusOpen
+	}
+	if inc.StartedAt.IsZero() {
+		inc.StartedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO incidents (id, service_id, title, message, cause, status, automatic, started_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		inc.ID, inc.ServiceID, inc.Title, inc.Message, inc.Cause, inc.Status, inc.Automatic, inc.StartedAt,
+	).Scan(&inc.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create incident: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a single incident by ID
+func (r *IncidentRepository) GetByID(ctx context.Context, id string) (*incident.Incident, error) {
+	query := `SELECT ` + incidentColumns + `
+		FROM incidents i JOIN services s ON s.id = i.service_id
+		WHERE i.id = $1`
+
+	inc, err := scanIncident(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("incident with ID %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+
+	return inc, nil
+}
+
+// GetActive returns the open incident of a service, or nil if there is none
+func (r *IncidentRepository) GetActive(ctx context.Context, serviceID string) (*incident.Incident, error) {
+	query := `SELECT ` + incidentColumns + `
+		FROM incidents i JOIN services s ON s.id = i.service_id
+		WHERE i.service_id = $1 AND i.status = $2`
+
+	inc, err := scanIncident(r.db.QueryRowContext(ctx, query, serviceID, incident.StatusOpen))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active incident: %w", err)
+	}
+
+	return inc, nil
+}
+
+// List returns the incidents of the caller's services matching the
+// filter, newest first
+func (r *IncidentRepository) List(ctx context.Context, filter incident.Filter) ([]incident.Incident, error) {
+	scope, args := teamScope(ctx, "s.team_id", nil)
+	conditions := []string{scope}
+
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.ServiceIDs != nil {
+		addCondition("i.service_id = ANY($%d)", pq.Array(filter.ServiceIDs))
+	}
+	if filter.ActiveOnly {
+		addCondition("i.status = $%d", incident.StatusOpen)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("(i.resolved_at IS NULL OR i.resolved_at >= $%d)", filter.Since)
+	}
+
+	query := `SELECT ` + incidentColumns + `
+		FROM incidents i JOIN services s ON s.id = i.service_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY i.started_at DESC`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var incidents []incident.Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, *inc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return incidents, nil
+}
+
+// Update changes the title and message of an incident
+func (r *IncidentRepository) Update(ctx context.Context, inc *incident.Incident) error {
+	query := `
+		UPDATE incidents
+		SET title = $2, message = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, inc.ID, inc.Title, inc.Message).Scan(&inc.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("incident with ID %s not found", inc.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update incident: %w", err)
+	}
+
+	return nil
+}
+
+// Resolve closes an open incident
+func (r *IncidentRepository) Resolve(ctx context.Context, id string, at time.Time) error {
+	query := `
+		UPDATE incidents
+		SET status = $2, resolved_at = $3, updated_at = NOW()
+		WHERE id = $1 AND status = $4
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, incident.StatusResolved, at, incident.StatusOpen)
+	if err != nil {
+		return fmt.Errorf("failed to resolve incident: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no open incident with ID %s", id)
+	}
+
+	return nil
+}
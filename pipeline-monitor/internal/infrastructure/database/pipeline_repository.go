@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"pipeline-monitor/internal/domain/pipeline"
+
+	"github.com/google/uuid"
+)
+
+// PipelineRepository implements the pipeline.Repository interface using PostgreSQL
+type PipelineRepository struct {
+	db *sql.DB
+}
+
+// NewPipelineRepository creates a new pipeline repository
+func NewPipelineRepository(db *sql.DB) *PipelineRepository {
+	return &PipelineRepository{db: db}
+}
+
+// pipelineColumns is the column list matching scanPipeline. Queries join
+// the linked service as s.
+const pipelineColumns = `p.id, p.provider, p.repository, p.name, p.url, p.service_id, s.name, p.created_at, p.updated_at`
+
+// runColumns is the column list matching scanRun
+const runColumns = `
+	id, pipeline_id, external_id, attempt, number, branch, commit_sha, actor, url,
+	outcome, stages, started_at, finished_at, updated_at
+`
+
+// scanPipeline reads a row selected with pipelineColumns
+func scanPipeline(row rowScanner) (*pipeline.Pipeline, error) {
+	var p pipeline.Pipeline
+	var serviceID, serviceName sql.NullString
+
+	err := row.Scan(
+		&p.ID, &p.Provider, &p.Repository, &p.Name, &p.URL, &serviceID, &serviceName, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p.ServiceID = serviceID.String
+	p.ServiceName = serviceName.String
+	return &p, nil
+}
+
+// scanRun reads a row selected with runColumns
+func scanRun(row rowScanner) (*pipeline.Run, error) {
+	var run pipeline.Run
+	var stages []byte
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&run.ID, &run.PipelineID, &run.ExternalID, &run.Attempt, &run.Number, &run.Branch, &run.Commit, &run.Actor, &run.URL,
+		&run.Outcome, &stages, &startedAt, &finishedAt, &run.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(stages, &run.Stages); err != nil {
+		return nil, fmt.Errorf("failed to decode stages: %w", err)
+	}
+	if startedAt.Valid {
+		run.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
+
+// pipelineScope limits pipelines to those linked to services the caller
+// can see, plus unlinked ones
+func pipelineScope(ctx context.Context, args []any) (string, []any) {
+	scope, args := teamScope(ctx, "s.team_id", args)
+	return "(p.service_id IS NULL OR " + scope + ")", args
+}
+
+// Record stores a webhook update: it creates the pipeline if it is new
+// (filling in its ID) and merges the run into the stored one
+func (r *PipelineRepository) Record(ctx context.Context, p *pipeline.Pipeline, run *pipeline.Run) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var serviceID sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO pipelines (id, provider, repository, name, url, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (provider, repository, name)
+		DO UPDATE SET url = EXCLUDED.url, updated_at = NOW()
+		RETURNING id, service_id, created_at, updated_at
+	`, uuid.New().String(), p.Provider, p.Repository, p.Name, p.URL).Scan(&p.ID, &serviceID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record pipeline: %w", err)
+	}
+	p.ServiceID = serviceID.String
+
+	// Make sure the run exists, then lock it so concurrent deliveries for
+	// the same run merge one after the other
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pipeline_runs (pipeline_id, external_id, attempt, outcome, stages, updated_at)
+		VALUES ($1, $2, $3, '', '[]', NOW())
+		ON CONFLICT (pipeline_id, external_id) DO NOTHING
+	`, p.ID, run.ExternalID, run.Attempt)
+	if err != nil {
+		return fmt.Errorf("failed to record pipeline run: %w", err)
+	}
+
+	stored, err := scanRun(tx.QueryRowContext(ctx, `SELECT `+runColumns+`
+		FROM pipeline_runs
+		WHERE pipeline_id = $1 AND external_id = $2
+		FOR UPDATE`, p.ID, run.ExternalID))
+	if err != nil {
+		return fmt.Errorf("failed to get pipeline run: %w", err)
+	}
+
+	stored.Merge(run)
+	if stored.Stages == nil {
+		stored.Stages = []pipeline.Stage{}
+	}
+
+	stages, err := json.Marshal(stored.Stages)
+	if err != nil {
+		return fmt.Errorf("failed to encode stages: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE pipeline_runs
+		SET attempt = $2, number = $3, branch = $4, commit_sha = $5, actor = $6, url = $7,
+			outcome = $8, stages = $9, started_at = $10, finished_at = $11, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, stored.ID, stored.Attempt, stored.Number, stored.Branch, stored.Commit, stored.Actor, stored.URL,
+		stored.Outcome, stages, stored.StartedAt, stored.FinishedAt,
+	).Scan(&stored.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update pipeline run: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	*run = *stored
+	return nil
+}
+
+// Get returns a pipeline by ID
+func (r *PipelineRepository) Get(ctx context.Context, id string) (*pipeline.Pipeline, error) {
+	scope, args := pipelineScope(ctx, []any{id})
+
+	query := `SELECT ` + pipelineColumns + `
+		FROM pipelines p
+		LEFT JOIN services s ON s.id = p.service_id
+		WHERE p.id = $1 AND ` + scope
+
+	p, err := scanPipeline(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("pipeline with ID %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline: %w", err)
+	}
+
+	return p, nil
+}
+
+// List returns all pipelines visible to the caller, ordered by repository
+// and name. Pipelines not linked to a service are visible to everyone.
+func (r *PipelineRepository) List(ctx context.Context) ([]pipeline.Pipeline, error) {
+	scope, args := pipelineScope(ctx, nil)
+
+	query := `SELECT ` + pipelineColumns + `
+		FROM pipelines p
+		LEFT JOIN services s ON s.id = p.service_id
+		WHERE ` + scope + `
+		ORDER BY p.repository, p.name`
+
+	return r.queryPipelines(ctx, query, args...)
+}
+
+// ListByService returns the pipelines linked to a service
+func (r *PipelineRepository) ListByService(ctx context.Context, serviceID string) ([]pipeline.Pipeline, error) {
+	scope, args := pipelineScope(ctx, []any{serviceID})
+
+	query := `SELECT ` + pipelineColumns + `
+		FROM pipelines p
+		LEFT JOIN services s ON s.id = p.service_id
+		WHERE p.service_id = $1 AND ` + scope + `
+		ORDER BY p.repository, p.name`
+
+	return r.queryPipelines(ctx, query, args...)
+}
+
+// queryPipelines runs a query selecting pipelineColumns
+func (r *PipelineRepository) queryPipelines(ctx context.Context, query string, args ...any) ([]pipeline.Pipeline, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pipelines: %w", err)
+	}
+	defer rows.Close()
+
+	var pipelines []pipeline.Pipeline
+	for rows.Next() {
+		p, err := scanPipeline(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pipeline: %w", err)
+		}
+		pipelines = append(pipelines, *p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return pipelines, nil
+}
+
+// SetService links a pipeline to a service, or unlinks it if the service
+// ID is empty
+func (r *PipelineRepository) SetService(ctx context.Context, id, serviceID string) error {
+	var linked any
+	if serviceID != "" {
+		linked = serviceID
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE pipelines SET service_id = $2, updated_at = NOW() WHERE id = $1`, id, linked)
+	if err != nil {
+		return fmt.Errorf("failed to link pipeline: %w", err)
+	}
+
+	return expectOneRow(result, fmt.Sprintf("pipeline with ID %s not found", id))
+}
+
+// Runs returns the latest runs of a pipeline, newest first
+func (r *PipelineRepository) Runs(ctx context.Context, pipelineID string, limit int) ([]pipeline.Run, error) {
+	query := `SELECT ` + runColumns + `
+		FROM pipeline_runs
+		WHERE pipeline_id = $1
+		ORDER BY id DESC
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, pipelineID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pipeline runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []pipeline.Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pipeline run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return runs, nil
+}
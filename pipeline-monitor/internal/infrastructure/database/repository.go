@@ -3,10 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/team"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -61,22 +63,402 @@ func CreateSchema(db *sql.DB) error {
 
 	CREATE INDEX IF NOT EXISTS idx_services_status ON services(status);
 	CREATE INDEX IF NOT EXISTS idx_services_last_check ON services(last_check);
+
+	CREATE TABLE IF NOT EXISTS teams (
+		id VARCHAR(36) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL UNIQUE,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS team_memberships (
+		team_id VARCHAR(36) NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		username VARCHAR(255) NOT NULL,
+		role VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		PRIMARY KEY (team_id, username)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_team_memberships_username ON team_memberships(username);
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS team_id VARCHAR(36) REFERENCES teams(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS idx_services_team_id ON services(team_id);
+
+	CREATE INDEX IF NOT EXISTS idx_services_tags ON services USING GIN (tags);
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+	CREATE INDEX IF NOT EXISTS idx_services_labels ON services USING GIN (labels);
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS badge_token VARCHAR(64);
+	CREATE INDEX IF NOT EXISTS idx_services_search ON services
+		USING GIN (to_tsvector('simple', name || ' ' || COALESCE(description, '') || ' ' || url));
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id VARCHAR(36) PRIMARY KEY,
+		actor VARCHAR(255) NOT NULL,
+		source VARCHAR(20) NOT NULL,
+		action VARCHAR(20) NOT NULL,
+		service_id VARCHAR(36),
+		service_name VARCHAR(255),
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		before JSONB,
+		after JSONB,
+		changes JSONB
+	);
+
+	ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS team_id VARCHAR(36);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_service_id ON audit_log(service_id);
+
+	CREATE TABLE IF NOT EXISTS service_revisions (
+		id VARCHAR(36) PRIMARY KEY,
+		service_id VARCHAR(36) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+		version INTEGER NOT NULL,
+		actor VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		definition JSONB NOT NULL,
+		UNIQUE (service_id, version)
+	);
+
+	CREATE TABLE IF NOT EXISTS health_checks (
+		id BIGSERIAL PRIMARY KEY,
+		service_id VARCHAR(36) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+		status VARCHAR(50) NOT NULL,
+		response_time INTEGER NOT NULL DEFAULT 0,
+		error TEXT,
+		checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_health_checks_service_checked_at ON health_checks(service_id, checked_at);
+	CREATE INDEX IF NOT EXISTS idx_health_checks_checked_at ON health_checks(checked_at);
+
+	ALTER TABLE health_checks ADD COLUMN IF NOT EXISTS timings JSONB;
+
+	CREATE TABLE IF NOT EXISTS health_check_rollups (
+		service_id VARCHAR(36) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+		resolution VARCHAR(8) NOT NULL,
+		bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+		count INTEGER NOT NULL,
+		failures INTEGER NOT NULL,
+		min_response_time INTEGER NOT NULL,
+		max_response_time INTEGER NOT NULL,
+		sum_response_time BIGINT NOT NULL,
+		sketch JSONB NOT NULL DEFAULT '{}',
+		PRIMARY KEY (service_id, resolution, bucket_start)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_health_check_rollups_resolution_start ON health_check_rollups(resolution, bucket_start);
+
+	CREATE TABLE IF NOT EXISTS incidents (
+		id VARCHAR(36) PRIMARY KEY,
+		service_id VARCHAR(36) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+		title VARCHAR(255) NOT NULL,
+		message TEXT NOT NULL DEFAULT '',
+		status VARCHAR(20) NOT NULL,
+		automatic BOOLEAN NOT NULL DEFAULT FALSE,
+		started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		resolved_at TIMESTAMP WITH TIME ZONE,
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
+	ALTER TABLE incidents ADD COLUMN IF NOT EXISTS cause TEXT NOT NULL DEFAULT '';
+
+	CREATE INDEX IF NOT EXISTS idx_incidents_service_id ON incidents(service_id, started_at);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_incidents_one_open ON incidents(service_id) WHERE status = 'open';
+
+	CREATE TABLE IF NOT EXISTS status_components (
+		id VARCHAR(36) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		position INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS status_component_services (
+		component_id VARCHAR(36) NOT NULL REFERENCES status_components(id) ON DELETE CASCADE,
+		service_id VARCHAR(36) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+		display_name VARCHAR(255) NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (component_id, service_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS certificates (
+		service_id VARCHAR(36) PRIMARY KEY REFERENCES services(id) ON DELETE CASCADE,
+		host VARCHAR(255) NOT NULL,
+		chain JSONB NOT NULL DEFAULT '[]',
+		not_after TIMESTAMP WITH TIME ZONE,
+		problem VARCHAR(50) NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		checked_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_certificates_not_after ON certificates(not_after);
+
+	CREATE TABLE IF NOT EXISTS certificate_alerts (
+		id BIGSERIAL PRIMARY KEY,
+		service_id VARCHAR(36) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+		host VARCHAR(255) NOT NULL,
+		subject TEXT NOT NULL,
+		not_after TIMESTAMP WITH TIME ZONE NOT NULL,
+		days INTEGER NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		UNIQUE (service_id, not_after, days)
+	);
+
+	CREATE TABLE IF NOT EXISTS response_snapshots (
+		id BIGSERIAL PRIMARY KEY,
+		service_id VARCHAR(36) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+		status VARCHAR(50) NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		request JSONB NOT NULL,
+		response JSONB,
+		captured_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_response_snapshots_service ON response_snapshots(service_id, captured_at DESC);
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS check_type VARCHAR(20) NOT NULL DEFAULT 'http';
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS heartbeat_period INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS heartbeat_grace INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS ping_token VARCHAR(64);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_services_ping_token ON services(ping_token);
+
+	CREATE TABLE IF NOT EXISTS heartbeat_pings (
+		id BIGSERIAL PRIMARY KEY,
+		service_id VARCHAR(36) NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+		kind VARCHAR(20) NOT NULL,
+		payload TEXT NOT NULL DEFAULT '',
+		remote_addr VARCHAR(255) NOT NULL DEFAULT '',
+		received_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		duration_ms INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_heartbeat_pings_service ON heartbeat_pings(service_id, received_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_heartbeat_pings_received_at ON heartbeat_pings(received_at);
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS sql_check JSONB;
+
+	CREATE TABLE IF NOT EXISTS pipelines (
+		id VARCHAR(36) PRIMARY KEY,
+		provider VARCHAR(20) NOT NULL,
+		repository VARCHAR(255) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		url VARCHAR(512) NOT NULL DEFAULT '',
+		service_id VARCHAR(36) REFERENCES services(id) ON DELETE SET NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		UNIQUE (provider, repository, name)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_pipelines_service ON pipelines(service_id);
+
+	CREATE TABLE IF NOT EXISTS pipeline_runs (
+		id BIGSERIAL PRIMARY KEY,
+		pipeline_id VARCHAR(36) NOT NULL REFERENCES pipelines(id) ON DELETE CASCADE,
+		external_id VARCHAR(64) NOT NULL,
+		attempt INTEGER NOT NULL DEFAULT 0,
+		number INTEGER NOT NULL DEFAULT 0,
+		branch VARCHAR(255) NOT NULL DEFAULT '',
+		commit_sha VARCHAR(64) NOT NULL DEFAULT '',
+		actor VARCHAR(255) NOT NULL DEFAULT '',
+		url VARCHAR(512) NOT NULL DEFAULT '',
+		outcome VARCHAR(20) NOT NULL,
+		stages JSONB NOT NULL DEFAULT '[]',
+		started_at TIMESTAMP WITH TIME ZONE,
+		finished_at TIMESTAMP WITH TIME ZONE,
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		UNIQUE (pipeline_id, external_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_pipeline_runs_pipeline ON pipeline_runs(pipeline_id, id DESC);
+
+	CREATE TABLE IF NOT EXISTS events (
+		id VARCHAR(36) PRIMARY KEY,
+		service_id VARCHAR(36) REFERENCES services(id) ON DELETE CASCADE,
+		tag VARCHAR(255) NOT NULL DEFAULT '',
+		kind VARCHAR(20) NOT NULL,
+		title VARCHAR(255) NOT NULL,
+		version VARCHAR(255) NOT NULL DEFAULT '',
+		author VARCHAR(255) NOT NULL DEFAULT '',
+		link VARCHAR(512) NOT NULL DEFAULT '',
+		occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_events_service ON events(service_id, occurred_at);
+	CREATE INDEX IF NOT EXISTS idx_events_tag ON events(tag, occurred_at) WHERE tag <> '';
+
+	ALTER TABLE events ADD COLUMN IF NOT EXISTS committed_at TIMESTAMP WITH TIME ZONE;
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS exec_check JSONB;
+	ALTER TABLE health_checks ADD COLUMN IF NOT EXISTS metrics JSONB;
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS grpc_check JSONB;
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS database_check JSONB;
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS redis_check JSONB;
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS oauth2_check JSONB;
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS transport JSONB;
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS synthetic_check JSONB;
+
+	CREATE TABLE IF NOT EXISTS secrets (
+		name VARCHAR(64) PRIMARY KEY,
+		key_id VARCHAR(16) NOT NULL,
+		data_key BYTEA NOT NULL,
+		ciphertext BYTEA NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+
+	ALTER TABLE secrets ADD COLUMN IF NOT EXISTS team_id VARCHAR(36) REFERENCES teams(id) ON DELETE SET NULL;
 	`
 
 	_, err := db.Exec(query)
 	return err
 }
 
-// GetAll retrieves all services from the database
+// serviceColumns is the column list matching scanService
+const serviceColumns = `
+	id, name, url, status, last_check, response_time,
+	created_at, updated_at, description, tags, labels, team_id, badge_token,
+	check_type, heartbeat_period, heartbeat_grace, ping_token, sql_check, exec_check, grpc_check,
+	database_check, redis_check, oauth2_check, transport, synthetic_check
+`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanService reads a row selected with serviceColumns
+func scanService(row rowScanner) (*service.Service, error) {
+	var svc service.Service
+	var teamID, badgeToken, pingToken sql.NullString
+	var labels, sqlCheck, execCheck, grpcCheck, databaseCheck, redisCheck, oauth2Check, transport, syntheticCheck []byte
+
+	err := row.Scan(
+		&svc.ID, &svc.Name, &svc.URL, &svc.Status, &svc.LastCheck,
+		&svc.ResponseTime, &svc.CreatedAt, &svc.UpdatedAt,
+		&svc.Description, pq.Array(&svc.Tags), &labels, &teamID, &badgeToken,
+		&svc.CheckType, &svc.HeartbeatPeriod, &svc.HeartbeatGrace, &pingToken, &sqlCheck, &execCheck, &grpcCheck,
+		&databaseCheck, &redisCheck, &oauth2Check, &transport, &syntheticCheck,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(labels, &svc.Labels); err != nil {
+		return nil, fmt.Errorf("failed to decode labels: %w", err)
+	}
+	if sqlCheck != nil {
+		if err := json.Unmarshal(sqlCheck, &svc.SQL); err != nil {
+			return nil, fmt.Errorf("failed to decode sql check: %w", err)
+		}
+	}
+	if execCheck != nil {
+		if err := json.Unmarshal(execCheck, &svc.Exec); err != nil {
+			return nil, fmt.Errorf("failed to decode exec check: %w", err)
+		}
+	}
+	if grpcCheck != nil {
+		if err := json.Unmarshal(grpcCheck, &svc.GRPC); err != nil {
+			return nil, fmt.Errorf("failed to decode grpc check: %w", err)
+		}
+	}
+	if databaseCheck != nil {
+		if err := json.Unmarshal(databaseCheck, &svc.Database); err != nil {
+			return nil, fmt.Errorf("failed to decode database check: %w", err)
+		}
+	}
+	if redisCheck != nil {
+		if err := json.Unmarshal(redisCheck, &svc.Redis); err != nil {
+			return nil, fmt.Errorf("failed to decode redis check: %w", err)
+		}
+	}
+	if oauth2Check != nil {
+		if err := json.Unmarshal(oauth2Check, &svc.OAuth2); err != nil {
+			return nil, fmt.Errorf("failed to decode oauth2 check: %w", err)
+		}
+	}
+	if transport != nil {
+		if err := json.Unmarshal(transport, &svc.Transport); err != nil {
+			return nil, fmt.Errorf("failed to decode transport: %w", err)
+		}
+	}
+	if syntheticCheck != nil {
+		if err := json.Unmarshal(syntheticCheck, &svc.Synthetic); err != nil {
+			return nil, fmt.Errorf("failed to decode synthetic check: %w", err)
+		}
+	}
+
+	svc.TeamID = teamID.String
+	svc.BadgeToken = badgeToken.String
+	svc.PingToken = pingToken.String
+	return &svc, nil
+}
+
+// teamScope returns a SQL condition limiting teamColumn to the teams visible
+// to the caller in ctx, appending its parameter to args. The condition is
+// always true for contexts without a caller, such as the monitor.
+func teamScope(ctx context.Context, teamColumn string, args []any) (string, []any) {
+	scope := team.ScopeFromContext(ctx)
+	if scope.All {
+		return "TRUE", args
+	}
+
+	args = append(args, pq.Array(scope.TeamIDs))
+	return fmt.Sprintf("%s = ANY($%d)", teamColumn, len(args)), args
+}
+
+// labelsJSON encodes service labels for the JSONB labels column
+func labelsJSON(labels map[string]string) []byte {
+	if labels == nil {
+		return []byte("{}")
+	}
+	data, _ := json.Marshal(labels)
+	return data
+}
+
+// checkJSON encodes the settings of a check type, storing services without
+// them as NULL
+func checkJSON[T any](check *T) any {
+	if check == nil {
+		return nil
+	}
+	data, _ := json.Marshal(check)
+	return data
+}
+
+// checkType stores services without a check type as HTTP checks
+func checkType(t service.CheckType) service.CheckType {
+	if t == "" {
+		return service.CheckHTTP
+	}
+	return t
+}
+
+// nullString stores empty strings as NULL
+func nullString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// GetAll retrieves all services visible to the caller from the database
 func (r *ServiceRepository) GetAll(ctx context.Context) ([]service.Service, error) {
-	query := `
-		SELECT id, name, url, status, last_check, response_time,
-		       created_at, updated_at, description, tags
+	scope, args := teamScope(ctx, "team_id", nil)
+
+	query := `SELECT ` + serviceColumns + `
 		FROM services
+		WHERE ` + scope + `
 		ORDER BY name
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query services: %w", err)
 	}
@@ -84,16 +466,11 @@ func (r *ServiceRepository) GetAll(ctx context.Context) ([]service.Service, erro
 
 	var services []service.Service
 	for rows.Next() {
-		var svc service.Service
-		err := rows.Scan(
-			&svc.ID, &svc.Name, &svc.URL, &svc.Status, &svc.LastCheck,
-			&svc.ResponseTime, &svc.CreatedAt, &svc.UpdatedAt,
-			&svc.Description, pq.Array(&svc.Tags),
-		)
+		svc, err := scanService(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan service: %w", err)
 		}
-		services = append(services, svc)
+		services = append(services, *svc)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -105,20 +482,13 @@ func (r *ServiceRepository) GetAll(ctx context.Context) ([]service.Service, erro
 
 // GetByID retrieves a single service by ID
 func (r *ServiceRepository) GetByID(ctx context.Context, id string) (*service.Service, error) {
-	query := `
-		SELECT id, name, url, status, last_check, response_time,
-		       created_at, updated_at, description, tags
-		FROM services
-		WHERE id = $1
-	`
+	scope, args := teamScope(ctx, "team_id", []any{id})
 
-	var svc service.Service
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&svc.ID, &svc.Name, &svc.URL, &svc.Status, &svc.LastCheck,
-		&svc.ResponseTime, &svc.CreatedAt, &svc.UpdatedAt,
-		&svc.Description, pq.Array(&svc.Tags),
-	)
+	query := `SELECT ` + serviceColumns + `
+		FROM services
+		WHERE id = $1 AND ` + scope
 
+	svc, err := scanService(r.db.QueryRowContext(ctx, query, args...))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("service with ID %s not found", id)
 	}
@@ -126,7 +496,7 @@ func (r *ServiceRepository) GetByID(ctx context.Context, id string) (*service.Se
 		return nil, fmt.Errorf("failed to get service: %w", err)
 	}
 
-	return &svc, nil
+	return svc, nil
 }
 
 // Create inserts a new service into the database
@@ -137,13 +507,21 @@ func (r *ServiceRepository) Create(ctx context.Context, svc *service.Service) er
 	}
 
 	query := `
-		INSERT INTO services (id, name, url, status, description, tags, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		INSERT INTO services (
+			id, name, url, status, description, tags, labels, team_id,
+			check_type, heartbeat_period, heartbeat_grace, ping_token, sql_check, exec_check, grpc_check,
+			database_check, redis_check, oauth2_check, transport, synthetic_check, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, NOW(), NOW())
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		svc.ID, svc.Name, svc.URL, svc.Status,
-		svc.Description, pq.Array(svc.Tags),
+		svc.Description, pq.Array(svc.Tags), labelsJSON(svc.Labels), nullString(svc.TeamID),
+		checkType(svc.CheckType), svc.HeartbeatPeriod, svc.HeartbeatGrace, nullString(svc.PingToken),
+		checkJSON(svc.SQL), checkJSON(svc.Exec), checkJSON(svc.GRPC),
+		checkJSON(svc.Database), checkJSON(svc.Redis), checkJSON(svc.OAuth2),
+		checkJSON(svc.Transport), checkJSON(svc.Synthetic),
 	)
 
 	if err != nil {
@@ -155,15 +533,25 @@ func (r *ServiceRepository) Create(ctx context.Context, svc *service.Service) er
 
 // Update modifies an existing service
 func (r *ServiceRepository) Update(ctx context.Context, svc *service.Service) error {
+	scope, args := teamScope(ctx, "team_id", []any{
+		svc.ID, svc.Name, svc.URL, svc.Description, pq.Array(svc.Tags),
+		labelsJSON(svc.Labels), nullString(svc.TeamID),
+		checkType(svc.CheckType), svc.HeartbeatPeriod, svc.HeartbeatGrace, nullString(svc.PingToken),
+		checkJSON(svc.SQL), checkJSON(svc.Exec), checkJSON(svc.GRPC),
+		checkJSON(svc.Database), checkJSON(svc.Redis), checkJSON(svc.OAuth2),
+		checkJSON(svc.Transport), checkJSON(svc.Synthetic),
+	})
+
 	query := `
 		UPDATE services
-		SET name = $2, url = $3, description = $4, tags = $5, updated_at = NOW()
-		WHERE id = $1
-	`
-
-	result, err := r.db.ExecContext(ctx, query,
-		svc.ID, svc.Name, svc.URL, svc.Description, pq.Array(svc.Tags),
-	)
+		SET name = $2, url = $3, description = $4, tags = $5, labels = $6, team_id = $7,
+			check_type = $8, heartbeat_period = $9, heartbeat_grace = $10, ping_token = $11,
+			sql_check = $12, exec_check = $13, grpc_check = $14,
+			database_check = $15, redis_check = $16, oauth2_check = $17,
+			transport = $18, synthetic_check = $19, updated_at = NOW()
+		WHERE id = $1 AND ` + scope
+
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update service: %w", err)
 	}
@@ -182,9 +570,11 @@ func (r *ServiceRepository) Update(ctx context.Context, svc *service.Service) er
 
 // Delete removes a service from the database
 func (r *ServiceRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM services WHERE id = $1`
+	scope, args := teamScope(ctx, "team_id", []any{id})
+
+	query := `DELETE FROM services WHERE id = $1 AND ` + scope
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to delete service: %w", err)
 	}
@@ -226,12 +616,56 @@ func (r *ServiceRepository) UpdateStatus(ctx context.Context, id string, status
 	return nil
 }
 
+// GetByPingToken returns the heartbeat service with the given ping token
+func (r *ServiceRepository) GetByPingToken(ctx context.Context, token string) (*service.Service, error) {
+	scope, args := teamScope(ctx, "team_id", []any{token, service.CheckHeartbeat})
+
+	query := `SELECT ` + serviceColumns + `
+		FROM services
+		WHERE ping_token = $1 AND check_type = $2 AND ` + scope
+
+	svc, err := scanService(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no heartbeat service with this ping token")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+
+	return svc, nil
+}
+
+// SetBadgeToken sets or, with an empty token, clears the badge token of a service
+func (r *ServiceRepository) SetBadgeToken(ctx context.Context, id string, token string) error {
+	scope, args := teamScope(ctx, "team_id", []any{id, nullString(token)})
+
+	query := `UPDATE services SET badge_token = $2 WHERE id = $1 AND ` + scope
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set badge token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("service with ID %s not found", id)
+	}
+
+	return nil
+}
+
 // GetHealthyCount returns the count of healthy services
 func (r *ServiceRepository) GetHealthyCount(ctx context.Context) (int, error) {
-	query := `SELECT COUNT(*) FROM services WHERE status = 'healthy'`
+	scope, args := teamScope(ctx, "team_id", nil)
+
+	query := `SELECT COUNT(*) FROM services WHERE status = 'healthy' AND ` + scope
 
 	var count int
-	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get healthy count: %w", err)
 	}
@@ -241,13 +675,16 @@ func (r *ServiceRepository) GetHealthyCount(ctx context.Context) (int, error) {
 
 // GetStatusCounts returns counts for each status
 func (r *ServiceRepository) GetStatusCounts(ctx context.Context) (map[string]int, error) {
+	scope, args := teamScope(ctx, "team_id", nil)
+
 	query := `
 		SELECT status, COUNT(*)
 		FROM services
+		WHERE ` + scope + `
 		GROUP BY status
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query status counts: %w", err)
 	}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"pipeline-monitor/internal/domain/revision"
+
+	"github.com/google/uuid"
+)
+
+// RevisionRepository implements the revision.Repository interface using PostgreSQL
+type RevisionRepository struct {
+	db *sql.DB
+}
+
+// NewRevisionRepository creates a new revision repository
+func NewRevisionRepository(db *sql.DB) *RevisionRepository {
+	return &RevisionRepository{db: db}
+}
+
+// Record stores a new revision with the next version number for its service
+func (r *RevisionRepository) Record(ctx context.Context, rev *revision.Revision) error {
+	if rev.ID == "" {
+		rev.ID = uuid.New().String()
+	}
+
+	definition, err := json.Marshal(rev.Service)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision: %w", err)
+	}
+
+	query := `
+		INSERT INTO service_revisions (id, service_id, version, actor, created_at, definition)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5
+		FROM service_revisions
+		WHERE service_id = $2
+		RETURNING version
+	`
+
+	err = r.db.QueryRowContext(ctx, query,
+		rev.ID, rev.ServiceID, rev.Actor, rev.CreatedAt, definition,
+	).Scan(&rev.Version)
+	if err != nil {
+		return fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	return nil
+}
+
+// ListByService returns all revisions of a service, newest first
+func (r *RevisionRepository) ListByService(ctx context.Context, serviceID string) ([]revision.Revision, error) {
+	query := `
+		SELECT id, service_id, version, actor, created_at, definition
+		FROM service_revisions
+		WHERE service_id = $1
+		ORDER BY version DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []revision.Revision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// Get retrieves a single revision of a service
+func (r *RevisionRepository) Get(ctx context.Context, serviceID string, version int) (*revision.Revision, error) {
+	query := `
+		SELECT id, service_id, version, actor, created_at, definition
+		FROM service_revisions
+		WHERE service_id = $1 AND version = $2
+	`
+
+	rev, err := scanRevision(r.db.QueryRowContext(ctx, query, serviceID, version))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("revision %d of service %s not found", version, serviceID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+// scanRevision reads a revision row and decodes its stored definition
+func scanRevision(row rowScanner) (*revision.Revision, error) {
+	var rev revision.Revision
+	var definition []byte
+
+	err := row.Scan(&rev.ID, &rev.ServiceID, &rev.Version, &rev.Actor, &rev.CreatedAt, &definition)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan revision: %w", err)
+	}
+
+	if err := json.Unmarshal(definition, &rev.Service); err != nil {
+		return nil, fmt.Errorf("failed to decode revision definition: %w", err)
+	}
+
+	return &rev, nil
+}
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pipeline-monitor/internal/domain/secret"
+)
+
+// SecretRepository implements the secret.Repository interface using
+// PostgreSQL. Values are sealed with the keyring before they are stored,
+// so the database only ever holds ciphertext.
+type SecretRepository struct {
+	db      *sql.DB
+	keyring *secret.Keyring
+}
+
+// NewSecretRepository creates a new secret repository. Without a keyring
+// secrets can be listed and deleted, but not stored or read.
+func NewSecretRepository(db *sql.DB, keyring *secret.Keyring) *SecretRepository {
+	return &SecretRepository{db: db, keyring: keyring}
+}
+
+// secretColumns is the column list matching scanSecret. Queries must
+// select from secrets as s and left join teams as t.
+const secretColumns = `s.name, COALESCE(s.team_id, ''), COALESCE(t.name, ''), s.key_id, s.created_at, s.updated_at`
+
+// scanSecret reads a row selected with secretColumns
+func scanSecret(row rowScanner) (*secret.Secret, error) {
+	var s secret.Secret
+	if err := row.Scan(&s.Name, &s.TeamID, &s.TeamName, &s.KeyID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// secretScope restricts a query to the shared secrets and those of the
+// teams in the context's scope
+func secretScope(ctx context.Context, args []any) (string, []any) {
+	scope, args := teamScope(ctx, "s.team_id", args)
+	return "(s.team_id IS NULL OR " + scope + ")", args
+}
+
+// List returns the secrets in scope ordered by name
+func (r *SecretRepository) List(ctx context.Context) ([]secret.Secret, error) {
+	scope, args := secretScope(ctx, nil)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+secretColumns+`
+		FROM secrets s LEFT JOIN teams t ON t.id = s.team_id
+		WHERE `+scope+`
+		ORDER BY s.name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	var secrets []secret.Secret
+	for rows.Next() {
+		s, err := scanSecret(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan secret: %w", err)
+		}
+		secrets = append(secrets, *s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return secrets, nil
+}
+
+// Get returns a secret in scope without its value
+func (r *SecretRepository) Get(ctx context.Context, name string) (*secret.Secret, error) {
+	scope, args := secretScope(ctx, []any{name})
+	s, err := scanSecret(r.db.QueryRowContext(ctx, `SELECT `+secretColumns+`
+		FROM secrets s LEFT JOIN teams t ON t.id = s.team_id
+		WHERE s.name = $1 AND `+scope, args...))
+	if err == sql.ErrNoRows {
+		return nil, secret.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	return s, nil
+}
+
+// Value decrypts the value of a secret in scope
+func (r *SecretRepository) Value(ctx context.Context, name string) (string, error) {
+	if r.keyring == nil {
+		return "", secret.ErrNoKey
+	}
+
+	scope, args := secretScope(ctx, []any{name})
+	var sealed secret.Sealed
+	err := r.db.QueryRowContext(ctx,
+		`SELECT s.key_id, s.data_key, s.ciphertext FROM secrets s WHERE s.name = $1 AND `+scope, args...,
+	).Scan(&sealed.KeyID, &sealed.DataKey, &sealed.Ciphertext)
+	if err == sql.ErrNoRows {
+		return "", secret.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	value, err := r.keyring.Open(name, &sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// Put seals a value under the current key and stores it, replacing the
+// previous value and owning team of the secret
+func (r *SecretRepository) Put(ctx context.Context, name, teamID, value string) (*secret.Secret, error) {
+	if r.keyring == nil {
+		return nil, secret.ErrNoKey
+	}
+
+	sealed, err := r.keyring.Seal(name, []byte(value))
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO secrets (name, team_id, key_id, data_key, ciphertext, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			team_id = EXCLUDED.team_id,
+			key_id = EXCLUDED.key_id,
+			data_key = EXCLUDED.data_key,
+			ciphertext = EXCLUDED.ciphertext,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	s := secret.Secret{Name: name, TeamID: teamID, KeyID: sealed.KeyID}
+	err = r.db.QueryRowContext(ctx, query, name, nullString(teamID), sealed.KeyID, sealed.DataKey, sealed.Ciphertext).Scan(&s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store secret: %w", err)
+	}
+
+	return &s, nil
+}
+
+// Delete removes a secret
+func (r *SecretRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM secrets WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return secret.ErrNotFound
+	}
+
+	return nil
+}
+
+// Rotate re-encrypts every secret sealed with a previous key under the
+// current key, with a new data key, in a single transaction. A secret
+// sealed with a key that is no longer configured fails the rotation.
+func (r *SecretRepository) Rotate(ctx context.Context) (int, error) {
+	if r.keyring == nil {
+		return 0, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT name, key_id, data_key, ciphertext FROM secrets
+		WHERE key_id <> $1
+		FOR UPDATE
+	`, r.keyring.CurrentKeyID())
+	if err != nil {
+		return 0, fmt.Errorf("failed to query secrets: %w", err)
+	}
+
+	// Read every row before updating, as the connection cannot run
+	// statements while rows are open
+	type staleSecret struct {
+		name   string
+		sealed secret.Sealed
+	}
+	var stale []staleSecret
+	for rows.Next() {
+		var s staleSecret
+		if err := rows.Scan(&s.name, &s.sealed.KeyID, &s.sealed.DataKey, &s.sealed.Ciphertext); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan secret: %w", err)
+		}
+		stale = append(stale, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	for _, s := range stale {
+		value, err := r.keyring.Open(s.name, &s.sealed)
+		if err != nil {
+			return 0, err
+		}
+		resealed, err := r.keyring.Seal(s.name, value)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE secrets SET key_id = $2, data_key = $3, ciphertext = $4
+			WHERE name = $1
+		`, s.name, resealed.KeyID, resealed.DataKey, resealed.Ciphertext)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt secret %q: %w", s.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(stale), nil
+}
@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/lib/pq"
+)
+
+// sortColumns maps sort fields to their column and SQL type, which is used
+// to cast cursor values back for keyset comparisons
+var sortColumns = map[service.SortField]struct {
+	column  string
+	sqlType string
+}{
+	service.SortByName:         {"name", "text"},
+	service.SortByStatus:       {"status", "text"},
+	service.SortByResponseTime: {"response_time", "integer"},
+	service.SortByLastCheck:    {"last_check", "timestamptz"},
+}
+
+// searchDocument is the text searched by Query.Search. It must match the
+// expression of idx_services_search.
+const searchDocument = `to_tsvector('simple', name || ' ' || COALESCE(description, '') || ' ' || url)`
+
+// cursor marks the last row of a page: its sort value and ID
+type cursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// Find returns a page of services matching the query, using keyset
+// pagination on the sort column and ID
+func (r *ServiceRepository) Find(ctx context.Context, q service.Query) (*service.Page, error) {
+	sort, ok := sortColumns[q.Sort]
+	if !ok {
+		q.Sort = service.SortByName
+		sort = sortColumns[q.Sort]
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = service.DefaultPageSize
+	}
+	if limit > service.MaxPageSize {
+		limit = service.MaxPageSize
+	}
+
+	scope, args := teamScope(ctx, "team_id", nil)
+	conditions := []string{scope}
+
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if tsquery := prefixTSQuery(q.Search); tsquery != "" {
+		addCondition(searchDocument+" @@ to_tsquery('simple', $%d)", tsquery)
+	}
+
+	if len(q.Tags) > 0 {
+		if q.TagMatch == service.TagMatchAll {
+			addCondition("tags @> $%d", pq.Array(q.Tags))
+		} else {
+			addCondition("tags && $%d", pq.Array(q.Tags))
+		}
+	}
+
+	if len(q.Statuses) > 0 {
+		statuses := make([]string, len(q.Statuses))
+		for i, status := range q.Statuses {
+			statuses[i] = string(status)
+		}
+		addCondition("status = ANY($%d)", pq.Array(statuses))
+	}
+
+	for _, req := range q.Labels {
+		switch req.Operator {
+		case service.OpEquals:
+			args = append(args, req.Key, req.Values[0])
+			conditions = append(conditions, fmt.Sprintf("labels->>$%d::text = $%d", len(args)-1, len(args)))
+		case service.OpNotEquals:
+			args = append(args, req.Key, req.Values[0])
+			conditions = append(conditions, fmt.Sprintf("labels->>$%d::text IS DISTINCT FROM $%d", len(args)-1, len(args)))
+		case service.OpIn:
+			args = append(args, req.Key, pq.Array(req.Values))
+			conditions = append(conditions, fmt.Sprintf("labels->>$%d::text = ANY($%d)", len(args)-1, len(args)))
+		case service.OpNotIn:
+			args = append(args, req.Key, pq.Array(req.Values))
+			conditions = append(conditions, fmt.Sprintf("COALESCE(labels->>$%d::text <> ALL($%d), TRUE)", len(args)-1, len(args)))
+		case service.OpExists:
+			addCondition("labels ? $%d", req.Key)
+		case service.OpDoesNotExist:
+			addCondition("NOT (labels ? $%d)", req.Key)
+		}
+	}
+
+	direction, comparison := "ASC", ">"
+	if q.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, after.Value, after.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d)",
+			sort.column, comparison, len(args)-1, sort.sqlType, len(args)))
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	args = append(args, limit+1)
+	query := `SELECT ` + serviceColumns + `
+		FROM services
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + sort.column + ` ` + direction + `, id ` + direction + `
+		LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query services: %w", err)
+	}
+	defer rows.Close()
+
+	page := &service.Page{Services: []service.Service{}}
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service: %w", err)
+		}
+		page.Services = append(page.Services, *svc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	if len(page.Services) > limit {
+		page.Services = page.Services[:limit]
+		last := page.Services[limit-1]
+		page.NextCursor = encodeCursor(cursor{Value: sortValue(&last, q.Sort), ID: last.ID})
+	}
+
+	return page, nil
+}
+
+// sortValue returns the value of the sort column for a service as text
+func sortValue(svc *service.Service, field service.SortField) string {
+	switch field {
+	case service.SortByStatus:
+		return string(svc.Status)
+	case service.SortByResponseTime:
+		return strconv.Itoa(svc.ResponseTime)
+	case service.SortByLastCheck:
+		return svc.LastCheck.Format(time.RFC3339Nano)
+	default:
+		return svc.Name
+	}
+}
+
+// prefixTSQuery turns free text into a tsquery matching every word as a
+// prefix, e.g. "pay api" becomes "pay:* & api:*". Punctuation is dropped so
+// user input can never form invalid tsquery syntax.
+func prefixTSQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (cursor, error) {
+	var c cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	return c, nil
+}
+
+// LabelCardinality summarises every label key in use by the services
+// visible to the caller, ordered by key
+func (r *ServiceRepository) LabelCardinality(ctx context.Context) ([]service.LabelStats, error) {
+	scope, args := teamScope(ctx, "team_id", nil)
+
+	query := `
+		SELECT l.key, l.value, COUNT(*)
+		FROM services, jsonb_each_text(labels) AS l(key, value)
+		WHERE ` + scope + `
+		GROUP BY l.key, l.value
+		ORDER BY l.key, COUNT(*) DESC, l.value
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query label cardinality: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []service.LabelStats
+	for rows.Next() {
+		var key string
+		var value service.LabelValue
+		if err := rows.Scan(&key, &value.Value, &value.Services); err != nil {
+			return nil, fmt.Errorf("failed to scan label value: %w", err)
+		}
+
+		if len(stats) == 0 || stats[len(stats)-1].Key != key {
+			stats = append(stats, service.LabelStats{Key: key})
+		}
+		current := &stats[len(stats)-1]
+		current.Values = append(current.Values, value)
+		current.Services += value.Services
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pipeline-monitor/internal/domain/snapshot"
+)
+
+// SnapshotRepository implements the snapshot.Repository interface using PostgreSQL
+type SnapshotRepository struct {
+	db *sql.DB
+}
+
+// NewSnapshotRepository creates a new snapshot repository
+func NewSnapshotRepository(db *sql.DB) *SnapshotRepository {
+	return &SnapshotRepository{db: db}
+}
+
+// Save stores a snapshot and drops all but the newest keep snapshots of its
+// service
+func (r *SnapshotRepository) Save(ctx context.Context, snap *snapshot.Snapshot, keep int) error {
+	request, err := json.Marshal(snap.Request)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot request: %w", err)
+	}
+
+	var response []byte
+	if snap.Response != nil {
+		if response, err = json.Marshal(snap.Response); err != nil {
+			return fmt.Errorf("failed to encode snapshot response: %w", err)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO response_snapshots (service_id, status, error, request, response, captured_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	err = tx.QueryRowContext(ctx, query,
+		snap.ServiceID, snap.Status, snap.Error, request, response, snap.CapturedAt,
+	).Scan(&snap.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save response snapshot: %w", err)
+	}
+
+	prune := `
+		DELETE FROM response_snapshots
+		WHERE service_id = $1 AND id NOT IN (
+			SELECT id FROM response_snapshots
+			WHERE service_id = $1
+			ORDER BY captured_at DESC, id DESC
+			LIMIT $2
+		)
+	`
+	if _, err := tx.ExecContext(ctx, prune, snap.ServiceID, keep); err != nil {
+		return fmt.Errorf("failed to prune response snapshots: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// List returns the snapshots of a service captured between from and to,
+// newest first. A zero to means up to now.
+func (r *SnapshotRepository) List(ctx context.Context, serviceID string, from, to time.Time) ([]snapshot.Snapshot, error) {
+	args := []any{serviceID, from}
+	query := `
+		SELECT id, service_id, status, error, request, response, captured_at
+		FROM response_snapshots
+		WHERE service_id = $1 AND captured_at >= $2`
+
+	if !to.IsZero() {
+		args = append(args, to)
+		query += " AND captured_at <= $3"
+	}
+	query += " ORDER BY captured_at DESC, id DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query response snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []snapshot.Snapshot
+	for rows.Next() {
+		var snap snapshot.Snapshot
+		var request, response []byte
+		err := rows.Scan(
+			&snap.ID, &snap.ServiceID, &snap.Status, &snap.Error, &request, &response, &snap.CapturedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan response snapshot: %w", err)
+		}
+
+		if err := json.Unmarshal(request, &snap.Request); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot request: %w", err)
+		}
+		if response != nil {
+			snap.Response = &snapshot.Response{}
+			if err := json.Unmarshal(response, snap.Response); err != nil {
+				return nil, fmt.Errorf("failed to decode snapshot response: %w", err)
+			}
+		}
+
+		snapshots = append(snapshots, snap)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return snapshots, nil
+}
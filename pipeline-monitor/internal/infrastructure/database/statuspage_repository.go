@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/statuspage"
+
+	"github.com/google/uuid"
+)
+
+// StatusPageRepository implements the statuspage.Repository interface using PostgreSQL
+type StatusPageRepository struct {
+	db *sql.DB
+}
+
+// NewStatusPageRepository creates a new status page repository
+func NewStatusPageRepository(db *sql.DB) *StatusPageRepository {
+	return &StatusPageRepository{db: db}
+}
+
+// List returns every component with its entries, in display order. The
+// status page is public, so this is deliberately not scoped to teams.
+func (r *StatusPageRepository) List(ctx context.Context) ([]statuspage.Component, error) {
+	query := `
+		SELECT c.id, c.name, c.description, c.position, c.created_at,
+		       e.service_id, e.display_name, e.position, s.name, s.status, s.last_check
+		FROM status_components c
+		LEFT JOIN status_component_services e ON e.component_id = c.id
+		LEFT JOIN services s ON s.id = e.service_id
+		ORDER BY c.position, c.name, c.id, e.position, e.display_name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status page components: %w", err)
+	}
+	defer rows.Close()
+
+	var components []statuspage.Component
+	for rows.Next() {
+		var c statuspage.Component
+		var serviceID, displayName, serviceName, status sql.NullString
+		var position sql.NullInt64
+		var lastCheck sql.NullTime
+
+		err := rows.Scan(
+			&c.ID, &c.Name, &c.Description, &c.Position, &c.CreatedAt,
+			&serviceID, &displayName, &position, &serviceName, &status, &lastCheck,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status page component: %w", err)
+		}
+
+		if len(components) == 0 || components[len(components)-1].ID != c.ID {
+			components = append(components, c)
+		}
+
+		if serviceID.Valid {
+			current := &components[len(components)-1]
+			current.Entries = append(current.Entries, statuspage.Entry{
+				ComponentID: c.ID,
+				ServiceID:   serviceID.String,
+				DisplayName: displayName.String,
+				Position:    int(position.Int64),
+				ServiceName: serviceName.String,
+				Status:      service.Status(status.String),
+				LastCheck:   lastCheck.Time,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return components, nil
+}
+
+// CreateComponent adds a component to the status page
+func (r *StatusPageRepository) CreateComponent(ctx context.Context, c *statuspage.Component) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+
+	query := `
+		INSERT INTO status_components (id, name, description, position, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING created_at
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, c.ID, c.Name, c.Description, c.Position).Scan(&c.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create status page component: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateComponent renames, describes or moves a component
+func (r *StatusPageRepository) UpdateComponent(ctx context.Context, c *statuspage.Component) error {
+	query := `
+		UPDATE status_components
+		SET name = $2, description = $3, position = $4
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, c.ID, c.Name, c.Description, c.Position)
+	if err != nil {
+		return fmt.Errorf("failed to update status page component: %w", err)
+	}
+
+	return expectOneRow(result, fmt.Sprintf("status page component with ID %s not found", c.ID))
+}
+
+// DeleteComponent removes a component and its entries
+func (r *StatusPageRepository) DeleteComponent(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_components WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete status page component: %w", err)
+	}
+
+	return expectOneRow(result, fmt.Sprintf("status page component with ID %s not found", id))
+}
+
+// SetEntry adds a service to a component or updates its display name and
+// position
+func (r *StatusPageRepository) SetEntry(ctx context.Context, e *statuspage.Entry) error {
+	query := `
+		INSERT INTO status_component_services (component_id, service_id, display_name, position)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (component_id, service_id)
+		DO UPDATE SET display_name = EXCLUDED.display_name, position = EXCLUDED.position
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, e.ComponentID, e.ServiceID, e.DisplayName, e.Position); err != nil {
+		return fmt.Errorf("failed to set status page entry: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveEntry takes a service off a component
+func (r *StatusPageRepository) RemoveEntry(ctx context.Context, componentID, serviceID string) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM status_component_services WHERE component_id = $1 AND service_id = $2`,
+		componentID, serviceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove status page entry: %w", err)
+	}
+
+	return expectOneRow(result, fmt.Sprintf("service %s is not part of component %s", serviceID, componentID))
+}
+
+// expectOneRow turns an update that touched no rows into a not-found error
+func expectOneRow(result sql.Result, notFound string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New(notFound)
+	}
+
+	return nil
+}
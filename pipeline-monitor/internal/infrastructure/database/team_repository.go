@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"pipeline-monitor/internal/domain/team"
+
+	"github.com/google/uuid"
+)
+
+// TeamRepository implements the team.Repository interface using PostgreSQL
+type TeamRepository struct {
+	db *sql.DB
+}
+
+// NewTeamRepository creates a new team repository
+func NewTeamRepository(db *sql.DB) *TeamRepository {
+	return &TeamRepository{db: db}
+}
+
+// GetAll retrieves all teams ordered by name
+func (r *TeamRepository) GetAll(ctx context.Context) ([]team.Team, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, created_at FROM teams ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query teams: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []team.Team
+	for rows.Next() {
+		var t team.Team
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return teams, nil
+}
+
+// GetByID retrieves a single team by ID
+func (r *TeamRepository) GetByID(ctx context.Context, id string) (*team.Team, error) {
+	var t team.Team
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, created_at FROM teams WHERE id = $1`, id,
+	).Scan(&t.ID, &t.Name, &t.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("team with ID %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	return &t, nil
+}
+
+// Create inserts a new team
+func (r *TeamRepository) Create(ctx context.Context, t *team.Team) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO teams (id, name, created_at) VALUES ($1, $2, NOW()) RETURNING created_at`,
+		t.ID, t.Name,
+	).Scan(&t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a team. Its services are kept but lose their owner.
+func (r *TeamRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM teams WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("team with ID %s not found", id)
+	}
+
+	return nil
+}
+
+// ListMembers returns the members of a team ordered by username
+func (r *TeamRepository) ListMembers(ctx context.Context, teamID string) ([]team.Membership, error) {
+	query := `
+		SELECT m.team_id, t.name, m.username, m.role, m.created_at
+		FROM team_memberships m
+		JOIN teams t ON t.id = m.team_id
+		WHERE m.team_id = $1
+		ORDER BY m.username
+	`
+	return r.queryMemberships(ctx, query, teamID)
+}
+
+// ListMemberships returns the teams a user belongs to ordered by team name
+func (r *TeamRepository) ListMemberships(ctx context.Context, username string) ([]team.Membership, error) {
+	query := `
+		SELECT m.team_id, t.name, m.username, m.role, m.created_at
+		FROM team_memberships m
+		JOIN teams t ON t.id = m.team_id
+		WHERE m.username = $1
+		ORDER BY t.name
+	`
+	return r.queryMemberships(ctx, query, username)
+}
+
+// SetMember adds a user to a team or changes their role
+func (r *TeamRepository) SetMember(ctx context.Context, m *team.Membership) error {
+	query := `
+		INSERT INTO team_memberships (team_id, username, role, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (team_id, username) DO UPDATE SET role = EXCLUDED.role
+		RETURNING created_at
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, m.TeamID, m.Username, m.Role).Scan(&m.CreatedAt); err != nil {
+		return fmt.Errorf("failed to set team member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveMember removes a user from a team
+func (r *TeamRepository) RemoveMember(ctx context.Context, teamID, username string) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM team_memberships WHERE team_id = $1 AND username = $2`, teamID, username,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove team member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s is not a member of team %s", username, teamID)
+	}
+
+	return nil
+}
+
+// queryMemberships runs a membership query and scans the results
+func (r *TeamRepository) queryMemberships(ctx context.Context, query string, arg any) ([]team.Membership, error) {
+	rows, err := r.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team memberships: %w", err)
+	}
+	defer rows.Close()
+
+	var memberships []team.Membership
+	for rows.Next() {
+		var m team.Membership
+		if err := rows.Scan(&m.TeamID, &m.TeamName, &m.Username, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team membership: %w", err)
+		}
+		memberships = append(memberships, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return memberships, nil
+}
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"pipeline-monitor/internal/domain/certificate"
+	"pipeline-monitor/internal/domain/service"
+)
+
+// newChain converts the certificates a peer presented, leaf first
+func newChain(host string, peers []*x509.Certificate) *certificate.Chain {
+	chain := &certificate.Chain{Host: host, CheckedAt: time.Now()}
+	for _, cert := range peers {
+		sans := append([]string(nil), cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		chain.Certificates = append(chain.Certificates, certificate.Certificate{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			SANs:      sans,
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+		})
+	}
+	return chain
+}
+
+// classifyTLSError tells a hostname mismatch apart from other chain
+// validation failures. It returns ProblemNone for errors unrelated to
+// certificates.
+func classifyTLSError(err error) certificate.Problem {
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &hostnameErr) {
+		return certificate.ProblemHostnameMismatch
+	}
+
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &invalidErr) {
+		if invalidErr.Reason == x509.Expired {
+			return certificate.ProblemExpired
+		}
+		return certificate.ProblemChainInvalid
+	}
+
+	var authorityErr x509.UnknownAuthorityError
+	var verificationErr *tls.CertificateVerificationError
+	if errors.As(err, &authorityErr) || errors.As(err, &verificationErr) {
+		return certificate.ProblemChainInvalid
+	}
+
+	return certificate.ProblemNone
+}
+
+// certificateError describes a validation failure for the check error
+func certificateError(problem certificate.Problem, err error) error {
+	switch problem {
+	case certificate.ProblemHostnameMismatch:
+		return fmt.Errorf("certificate hostname mismatch: %w", err)
+	case certificate.ProblemExpired:
+		return fmt.Errorf("certificate expired: %w", err)
+	default:
+		return fmt.Errorf("certificate chain invalid: %w", err)
+	}
+}
+
+// fetchChain connects without verification to read the chain a server
+// presents, so chains that fail validation can still be shown. It sends
+// the server name of the transport, if it overrides one.
+func fetchChain(ctx context.Context, rawURL string, transport *service.Transport) *certificate.Chain {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	serverName := u.Hostname()
+	if transport != nil && transport.ServerName != "" {
+		serverName = transport.ServerName
+	}
+
+	dialer := &tls.Dialer{Config: &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true, // only used to report the chain, never to pass a check
+	}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		log.Printf("Failed to fetch certificate chain of %s: %v", u.Host, err)
+		return nil
+	}
+	defer conn.Close()
+
+	return newChain(u.Hostname(), conn.(*tls.Conn).ConnectionState().PeerCertificates)
+}
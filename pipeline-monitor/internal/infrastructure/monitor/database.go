@@ -0,0 +1,222 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"pipeline-monitor/internal/domain/secret"
+	"pipeline-monitor/internal/domain/service"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// performDatabaseCheck connects to the Postgres or MySQL server of a URL
+// over a fresh connection and runs SELECT 1. When the check sets a lag
+// threshold it also reads the replication lag, which replicas report as a
+// metric.
+func (m *ServiceMonitor) performDatabaseCheck(ctx context.Context, checkType service.CheckType, rawURL string, check *service.DatabaseCheck) checkResult {
+	if check == nil {
+		check = &service.DatabaseCheck{}
+	}
+
+	password, err := m.secret(ctx, check.PasswordSecret)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+
+	connector, err := databaseConnector(checkType, rawURL, check.User, password)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	var one int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return databaseErrorResult(ctx, fmt.Errorf("SELECT 1 failed: %w", err))
+	}
+
+	if check.MaxLag == 0 {
+		return checkResult{status: service.StatusHealthy}
+	}
+
+	var lag *float64
+	if checkType == service.CheckMySQL {
+		lag, err = mysqlLag(ctx, db)
+	} else {
+		lag, err = postgresLag(ctx, db)
+	}
+	if err != nil {
+		return databaseErrorResult(ctx, err)
+	}
+	if lag == nil {
+		return checkResult{status: service.StatusHealthy}
+	}
+
+	result := checkResult{
+		status: service.StatusHealthy,
+		metrics: []service.Metric{{
+			Label: "replication_lag",
+			Value: math.Round(*lag*10) / 10,
+			Unit:  "s",
+			Crit:  strconv.Itoa(check.MaxLag),
+		}},
+	}
+	if err := check.EvaluateLag(*lag); err != nil {
+		result.status = service.StatusUnhealthy
+		result.err = err
+	}
+	return result
+}
+
+// databaseConnector returns a connector to the server of a database check
+// URL that logs in with the user and password. MySQL connections are
+// configured directly, so passwords never need escaping in a DSN.
+func databaseConnector(checkType service.CheckType, rawURL, user, password string) (driver.Connector, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	if checkType == service.CheckPostgres {
+		switch {
+		case user != "" && password != "":
+			u.User = url.UserPassword(user, password)
+		case user != "":
+			u.User = url.User(user)
+		}
+		connector, err := pq.NewConnector(u.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid url: %w", err)
+		}
+		return connector, nil
+	}
+
+	config := mysql.NewConfig()
+	config.User = user
+	config.Passwd = password
+	config.Net = "tcp"
+	config.Addr = u.Host
+	if u.Port() == "" {
+		config.Addr = net.JoinHostPort(u.Hostname(), "3306")
+	}
+	config.DBName = strings.TrimPrefix(u.Path, "/")
+	config.TLSConfig = u.Query().Get("tls")
+
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	return connector, nil
+}
+
+// postgresLag returns how many seconds a Postgres replica is behind its
+// primary, or nil for a primary. A replica that has replayed everything
+// it received is not behind, however long ago the last transaction was.
+func postgresLag(ctx context.Context, db *sql.DB) (*float64, error) {
+	var replica bool
+	var lag sql.NullFloat64
+	err := db.QueryRowContext(ctx, `
+		SELECT pg_is_in_recovery(),
+			CASE
+				WHEN NOT pg_is_in_recovery() THEN NULL
+				WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+				ELSE EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp())
+			END
+	`).Scan(&replica, &lag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replication lag: %w", err)
+	}
+
+	if !replica {
+		return nil, nil
+	}
+	if !lag.Valid {
+		return nil, fmt.Errorf("replica has not replayed any transaction yet")
+	}
+	return &lag.Float64, nil
+}
+
+// mysqlLag returns Seconds_Behind_Source of a MySQL replica, or nil for a
+// server that does not replicate
+func mysqlLag(ctx context.Context, db *sql.DB) (*float64, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		// MySQL before 8.0.22 and MariaDB before 10.5.1 only know the old name
+		rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replication status: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read replication status: %w", err)
+		}
+		return nil, nil
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replication status: %w", err)
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to read replication status: %w", err)
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if !values[i].Valid {
+			return nil, fmt.Errorf("replication is not running")
+		}
+		lag, err := strconv.ParseFloat(values[i].String, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replication lag %q", values[i].String)
+		}
+		return &lag, nil
+	}
+	return nil, fmt.Errorf("replication status has no Seconds_Behind_Source")
+}
+
+// databaseErrorResult classifies a failed database check
+func databaseErrorResult(ctx context.Context, err error) checkResult {
+	if ctx.Err() == context.DeadlineExceeded {
+		return checkResult{status: service.StatusTimeout, err: fmt.Errorf("database check timeout: %w", err)}
+	}
+	return checkResult{status: service.StatusUnhealthy, err: err}
+}
+
+// secret decrypts a stored secret. Checks that name no secret get an
+// empty one.
+func (m *ServiceMonitor) secret(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	value, err := m.secretRepo.Value(ctx, name)
+	if errors.Is(err, secret.ErrNotFound) {
+		return "", fmt.Errorf("unknown secret %q", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %q: %w", name, err)
+	}
+	return value, nil
+}
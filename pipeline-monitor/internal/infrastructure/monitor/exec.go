@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// maxPluginOutput caps how much of a plugin's stdout and stderr is read
+const maxPluginOutput = 64 << 10
+
+// performExecCheck runs the plugin of an exec check in a process group of
+// its own and maps its exit code and output onto a status and metrics. The
+// timeout of the check is applied through ctx.
+func (m *ServiceMonitor) performExecCheck(ctx context.Context, check *service.ExecCheck) checkResult {
+	if check == nil {
+		return checkResult{status: service.StatusUnknown, err: fmt.Errorf("exec check has no settings")}
+	}
+	// Checks saved before a variable was reserved are refused here too
+	if err := check.Validate(); err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+
+	path, ok := m.execCommands[check.Command]
+	if !ok {
+		return checkResult{status: service.StatusUnknown, err: fmt.Errorf("command %q is not on the allow-list", check.Command)}
+	}
+
+	stdout := &bodyPrefix{max: maxPluginOutput}
+	stderr := &bodyPrefix{max: maxPluginOutput}
+
+	cmd := exec.CommandContext(ctx, path, check.Args...)
+	cmd.Env = pluginEnv(check.Env)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+	setProcessGroup(cmd)
+
+	err := cmd.Run()
+	text, metrics := service.ParsePluginOutput(string(stdout.buf))
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return checkResult{status: service.StatusTimeout, metrics: metrics, err: fmt.Errorf("%s timed out after %s", check.Command, check.TimeoutDuration())}
+	}
+
+	code := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return checkResult{status: service.StatusUnknown, err: fmt.Errorf("failed to run %s: %w", check.Command, err)}
+		}
+		code = exitErr.ExitCode()
+	}
+
+	result := checkResult{status: service.ExitStatus(code), metrics: metrics}
+	if result.status != service.StatusHealthy {
+		result.err = pluginError(check.Command, code, text, string(stderr.buf))
+	}
+	return result
+}
+
+// pluginEnv builds the environment of a plugin: only PATH of the monitor
+// itself, so its own secrets are not passed on, and the variables of the
+// check
+func pluginEnv(vars map[string]string) []string {
+	env := []string{"PATH=" + os.Getenv("PATH")}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+vars[name])
+	}
+	return env
+}
+
+// pluginError describes a failed plugin run by the text it printed, its
+// first line of stderr if it printed nothing, or else its exit code
+func pluginError(command string, code int, text, stderr string) error {
+	if text != "" {
+		return fmt.Errorf("%s", text)
+	}
+	if line, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n"); line != "" {
+		return fmt.Errorf("%s: %s", command, line)
+	}
+	if code < 0 {
+		return fmt.Errorf("%s was killed", command)
+	}
+	return fmt.Errorf("%s exited with code %d", command, code)
+}
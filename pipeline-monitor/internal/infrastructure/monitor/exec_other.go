@@ -0,0 +1,9 @@
+//go:build !unix
+
+package monitor
+
+import "os/exec"
+
+// setProcessGroup leaves the command as it is where process groups are not
+// available; cancelling the check kills the plugin but not its children
+func setProcessGroup(cmd *exec.Cmd) {}
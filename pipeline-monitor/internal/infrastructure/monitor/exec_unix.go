@@ -0,0 +1,18 @@
+//go:build unix
+
+package monitor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a process group of its own and
+// kills the whole group when the check is cancelled, so processes a plugin
+// forked do not outlive its timeout
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"pipeline-monitor/internal/domain/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// performGRPCCheck calls grpc.health.v1.Health/Check on the target of a
+// grpc:// URL over a fresh connection and maps the serving status onto a
+// service status. Over TLS it also reports the server's certificate chain.
+func (m *ServiceMonitor) performGRPCCheck(ctx context.Context, rawURL string, check *service.GRPCCheck) checkResult {
+	if check == nil {
+		check = &service.GRPCCheck{}
+	}
+
+	creds, err := m.grpcCredentials(check)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+
+	target := service.GRPCTarget(rawURL)
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: fmt.Errorf("failed to create client: %w", err)}
+	}
+	defer conn.Close()
+
+	var p peer.Peer
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: check.Service}, grpc.Peer(&p))
+	if err != nil {
+		return grpcErrorResult(ctx, check.Service, err)
+	}
+
+	var result checkResult
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		host, _, _ := net.SplitHostPort(target)
+		result.chain = newChain(host, info.State.PeerCertificates)
+	}
+
+	switch resp.GetStatus() {
+	case healthpb.HealthCheckResponse_SERVING:
+		result.status = service.StatusHealthy
+		m.warnOnExpiry(&result)
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		result.status = service.StatusUnhealthy
+		result.err = fmt.Errorf("health service reports NOT_SERVING")
+	default:
+		result.status = service.StatusUnknown
+		result.err = fmt.Errorf("health service reports %s", resp.GetStatus())
+	}
+	return result
+}
+
+// grpcCredentials returns plaintext credentials, or TLS ones with the
+// client certificate of the check if it names one
+func (m *ServiceMonitor) grpcCredentials(check *service.GRPCCheck) (credentials.TransportCredentials, error) {
+	if !check.TLS {
+		return insecure.NewCredentials(), nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if check.ClientCert != "" {
+		cert, err := m.transports.clientCertificate(check.ClientCert)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// grpcErrorResult classifies a failed Check call
+func grpcErrorResult(ctx context.Context, serviceName string, err error) checkResult {
+	if ctx.Err() == context.DeadlineExceeded {
+		return checkResult{status: service.StatusTimeout, err: fmt.Errorf("health check timeout: %w", err)}
+	}
+
+	switch status.Code(err) {
+	case codes.NotFound:
+		return checkResult{status: service.StatusUnhealthy, err: fmt.Errorf("health service does not know service %q", serviceName)}
+	case codes.Unimplemented:
+		return checkResult{status: service.StatusUnknown, err: fmt.Errorf("server does not implement grpc.health.v1.Health")}
+	case codes.DeadlineExceeded:
+		return checkResult{status: service.StatusTimeout, err: fmt.Errorf("health check timeout: %w", err)}
+	default:
+		return checkResult{status: service.StatusUnhealthy, err: fmt.Errorf("health check failed: %w", err)}
+	}
+}
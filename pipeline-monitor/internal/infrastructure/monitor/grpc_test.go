@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startHealthServer serves the standard health service on a loopback port
+// and returns its grpc:// URL
+func startHealthServer(t *testing.T) (*health.Server, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	healthServer := health.NewServer()
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return healthServer, "grpc://" + lis.Addr().String()
+}
+
+func TestPerformGRPCCheck(t *testing.T) {
+	healthServer, url := startHealthServer(t)
+	healthServer.SetServingStatus("pay.v1.Pay", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("orders.v1.Orders", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthServer.SetServingStatus("stock.v1.Stock", healthpb.HealthCheckResponse_UNKNOWN)
+
+	tests := []struct {
+		name    string
+		service string
+		status  service.Status
+		err     string
+	}{
+		{name: "serving", service: "pay.v1.Pay", status: service.StatusHealthy},
+		{name: "whole server", service: "", status: service.StatusHealthy},
+		{name: "not serving", service: "orders.v1.Orders", status: service.StatusUnhealthy, err: "NOT_SERVING"},
+		{name: "unknown", service: "stock.v1.Stock", status: service.StatusUnknown, err: "reports UNKNOWN"},
+		{name: "unregistered service", service: "nope.v1.Nope", status: service.StatusUnhealthy, err: `does not know service "nope.v1.Nope"`},
+	}
+
+	m := &ServiceMonitor{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			result := m.performGRPCCheck(ctx, url, &service.GRPCCheck{Service: tt.service})
+			if result.status != tt.status {
+				t.Errorf("status = %s, want %s (err: %v)", result.status, tt.status, result.err)
+			}
+			if tt.err == "" {
+				if result.err != nil {
+					t.Errorf("unexpected error: %v", result.err)
+				}
+			} else if result.err == nil || !strings.Contains(result.err.Error(), tt.err) {
+				t.Errorf("err = %v, want it to contain %q", result.err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPerformGRPCCheckWithoutHealthService(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer()
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	m := &ServiceMonitor{}
+	result := m.performGRPCCheck(ctx, "grpc://"+lis.Addr().String(), nil)
+	if result.status != service.StatusUnknown {
+		t.Errorf("status = %s, want %s (err: %v)", result.status, service.StatusUnknown, result.err)
+	}
+}
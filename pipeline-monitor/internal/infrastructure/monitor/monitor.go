@@ -3,47 +3,104 @@ package monitor
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"sync"
 	"time"
 
+	"pipeline-monitor/internal/domain/certificate"
+	"pipeline-monitor/internal/domain/heartbeat"
+	"pipeline-monitor/internal/domain/incident"
+	"pipeline-monitor/internal/domain/secret"
 	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/snapshot"
+	"pipeline-monitor/internal/domain/team"
 )
 
 // ServiceMonitor handles concurrent monitoring of multiple services
 type ServiceMonitor struct {
 	repo         service.Repository
+	checkRepo    service.CheckRepository
+	incidentRepo incident.Repository
+	certRepo     certificate.Repository
 	interval     time.Duration
+	certWarning  time.Duration
+	snapshotRepo snapshot.Repository
+	snapshots    snapshot.Policy
+	pingRepo     heartbeat.Repository
+	dataSources  *dataSources
+	execCommands map[string]string
+	secretRepo   secret.Repository
 	updates      chan ServiceUpdate
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
-	client       *http.Client
+	transports   *transports
+	tokens       *tokenCache
 	activeChecks map[string]context.CancelFunc
 	checksMutex  sync.RWMutex
 }
 
 // ServiceUpdate represents a status update from a health check
 type ServiceUpdate struct {
-	ServiceID    string
-	Status       service.Status
-	ResponseTime int
-	Timestamp    time.Time
-	Error        error
+	ServiceID      string
+	ServiceName    string
+	PreviousStatus service.Status
+	Status         service.Status
+	ResponseTime   int
+	Timings        *service.Timings
+	Metrics        []service.Metric
+	Certificate    *certificate.Chain // nil unless the check used TLS
+	Snapshot       *snapshot.Snapshot // set when a failing check was captured
+	Timestamp      time.Time
+	Error          error
 }
 
-// New creates a new ServiceMonitor instance
-func New(repo service.Repository, intervalSeconds int) *ServiceMonitor {
+// checkResult is the outcome of a single health check
+type checkResult struct {
+	status   service.Status
+	timings  *service.Timings
+	metrics  []service.Metric
+	chain    *certificate.Chain
+	snapshot *snapshot.Snapshot
+	err      error
+	// started is when the timed part of the check began, for checks that
+	// do some work before it; zero when timing starts with the check
+	started time.Time
+}
+
+// maxBodyBytes caps how much of a response body a check reads when timing
+// the transfer
+const maxBodyBytes = 1 << 20
+
+// New creates a new ServiceMonitor instance. Services whose certificate
+// expires within certWarningDays get the warning status, and failing checks
+// are captured as snapshots according to the policy. Exec checks may only
+// run the commands in execCommands, checks authenticate with the client
+// certificates in clientCerts and the passwords in secretRepo, and HTTP
+// checks may trust the CA bundles in caBundles.
+func New(repo service.Repository, checkRepo service.CheckRepository, incidentRepo incident.Repository, certRepo certificate.Repository, snapshotRepo snapshot.Repository, pingRepo heartbeat.Repository, intervalSeconds, certWarningDays int, snapshots snapshot.Policy, dataSourceDSNs, execCommands, clientCerts, caBundles map[string]string, secretRepo secret.Repository) *ServiceMonitor {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &ServiceMonitor{
 		repo:         repo,
+		checkRepo:    checkRepo,
+		incidentRepo: incidentRepo,
+		certRepo:     certRepo,
 		interval:     time.Duration(intervalSeconds) * time.Second,
+		certWarning:  time.Duration(certWarningDays) * 24 * time.Hour,
+		snapshotRepo: snapshotRepo,
+		snapshots:    snapshots,
+		pingRepo:     pingRepo,
+		dataSources:  newDataSources(dataSourceDSNs),
+		execCommands: execCommands,
+		secretRepo:   secretRepo,
 		updates:      make(chan ServiceUpdate, 100), // Buffered channel for non-blocking updates
 		ctx:          ctx,
 		cancel:       cancel,
-		client:       &http.Client{Timeout: 10 * time.Second},
+		transports:   newTransports(clientCerts, caBundles),
+		tokens:       newTokenCache(),
 		activeChecks: make(map[string]context.CancelFunc),
 	}
 }
@@ -83,6 +140,9 @@ func (m *ServiceMonitor) Stop() error {
 	// Close the updates channel
 	close(m.updates)
 
+	// Close data source pools opened by SQL checks
+	m.dataSources.close()
+
 	log.Println("Service monitor stopped")
 	return nil
 }
@@ -134,8 +194,10 @@ func (m *ServiceMonitor) checkAllServices() {
 func (m *ServiceMonitor) checkService(svc service.Service) {
 	defer m.wg.Done()
 
-	// Create a context for this specific check with timeout
-	checkCtx, cancel := context.WithTimeout(m.ctx, 8*time.Second)
+	// Create a context for this specific check with timeout. It sees the
+	// data of the service's team only, so checks can only read the secrets
+	// of that team and the shared ones.
+	checkCtx, cancel := context.WithTimeout(team.WithTeam(m.ctx, svc.TeamID), checkTimeout(&svc))
 	defer cancel()
 
 	// Store the cancel function for potential early termination
@@ -150,18 +212,58 @@ func (m *ServiceMonitor) checkService(svc service.Service) {
 		m.checksMutex.Unlock()
 	}()
 
-	start := time.Now()
-	status, err := m.performHealthCheck(checkCtx, svc.URL)
-	responseTime := int(time.Since(start).Milliseconds())
+	var result checkResult
+	var responseTime int
+	switch svc.CheckType {
+	case service.CheckHeartbeat:
+		result, responseTime = m.evaluateHeartbeat(checkCtx, &svc)
+	case service.CheckSQL:
+		start := time.Now()
+		result = m.performSQLCheck(checkCtx, svc.SQL)
+		responseTime = int(time.Since(start).Milliseconds())
+	case service.CheckExec:
+		start := time.Now()
+		result = m.performExecCheck(checkCtx, svc.Exec)
+		responseTime = int(time.Since(start).Milliseconds())
+	case service.CheckGRPC:
+		start := time.Now()
+		result = m.performGRPCCheck(checkCtx, svc.URL, svc.GRPC)
+		responseTime = int(time.Since(start).Milliseconds())
+	case service.CheckPostgres, service.CheckMySQL:
+		start := time.Now()
+		result = m.performDatabaseCheck(checkCtx, svc.CheckType, svc.URL, svc.Database)
+		responseTime = int(time.Since(start).Milliseconds())
+	case service.CheckRedis:
+		start := time.Now()
+		result = m.performRedisCheck(checkCtx, svc.URL, svc.Redis)
+		responseTime = int(time.Since(start).Milliseconds())
+	case service.CheckSynthetic:
+		start := time.Now()
+		result = m.performSyntheticCheck(checkCtx, svc.URL, svc.Synthetic, svc.Transport)
+		responseTime = int(time.Since(start).Milliseconds())
+	default:
+		start := time.Now()
+		result = m.performHealthCheck(checkCtx, svc.URL, svc.OAuth2, svc.Transport)
+		if !result.started.IsZero() {
+			start = result.started
+		}
+		responseTime = int(time.Since(start).Milliseconds())
+	}
 
 	// Send update through channel (non-blocking due to buffer)
 	select {
 	case m.updates <- ServiceUpdate{
-		ServiceID:    svc.ID,
-		Status:       status,
-		ResponseTime: responseTime,
-		Timestamp:    time.Now(),
-		Error:        err,
+		ServiceID:      svc.ID,
+		ServiceName:    svc.Name,
+		PreviousStatus: svc.Status,
+		Status:         result.status,
+		ResponseTime:   responseTime,
+		Timings:        result.timings,
+		Metrics:        result.metrics,
+		Certificate:    result.chain,
+		Snapshot:       result.snapshot,
+		Timestamp:      time.Now(),
+		Error:          result.err,
 	}:
 	case <-m.ctx.Done():
 		return
@@ -171,29 +273,153 @@ func (m *ServiceMonitor) checkService(svc service.Service) {
 	}
 }
 
-// performHealthCheck makes an HTTP request to check service health
-func (m *ServiceMonitor) performHealthCheck(ctx context.Context, url string) (service.Status, error) {
+// checkTimeout returns how long a check of the service may take: the
+// timeout of an exec or synthetic check, otherwise 8 seconds
+func checkTimeout(svc *service.Service) time.Duration {
+	if svc.CheckType == service.CheckExec && svc.Exec != nil {
+		return svc.Exec.TimeoutDuration()
+	}
+	if svc.CheckType == service.CheckSynthetic && svc.Synthetic != nil {
+		return svc.Synthetic.TimeoutDuration()
+	}
+	return 8 * time.Second
+}
+
+// evaluateHeartbeat derives the status of a heartbeat service from its
+// last completed run. The response time is the duration of that run, if
+// it sent a start ping.
+func (m *ServiceMonitor) evaluateHeartbeat(ctx context.Context, svc *service.Service) (checkResult, int) {
+	last, err := m.pingRepo.LastCompleted(ctx, svc.ID)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}, 0
+	}
+
+	status, err := heartbeat.Evaluate(svc, last, time.Now())
+	result := checkResult{status: status, err: err}
+	if last != nil && last.Duration != nil {
+		return result, *last.Duration
+	}
+	return result, 0
+}
+
+// CheckNow checks a service right away instead of waiting for the next
+// round, e.g. after a heartbeat ping
+func (m *ServiceMonitor) CheckNow(svc service.Service) {
+	if m.ctx.Err() != nil {
+		return
+	}
+	m.wg.Add(1)
+	go m.checkService(svc)
+}
+
+// performHealthCheck makes an HTTP request to check service health and
+// reports how long each phase of the request took and, for HTTPS, the
+// certificate chain the server presented. With an OAuth2 check the
+// request carries a bearer token, fetched before the request is timed.
+// Requests, token requests included, go through the client of the
+// service's transport settings.
+func (m *ServiceMonitor) performHealthCheck(ctx context.Context, url string, oauth2 *service.OAuth2Check, transport *service.Transport) (result checkResult) {
+	client, err := m.transports.client(transport)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+
+	var token, clientSecret string
+	if oauth2 != nil {
+		clientSecret, err = m.secret(ctx, oauth2.Secret)
+		if err != nil {
+			return checkResult{status: service.StatusUnknown, err: err}
+		}
+		tokenClient, err := m.transports.client(transport.ForHost())
+		if err != nil {
+			return checkResult{status: service.StatusUnknown, err: err}
+		}
+		token, err = m.tokens.token(ctx, tokenClient, oauth2, clientSecret)
+		if err != nil {
+			return checkResult{status: service.StatusUnknown, err: err}
+		}
+	}
+
+	started := time.Now()
+	defer func() { result.started = started }()
+
+	ctx, trace := withPhaseTrace(ctx)
+
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return service.StatusUnknown, fmt.Errorf("failed to create request: %w", err)
+		return checkResult{status: service.StatusUnknown, err: fmt.Errorf("failed to create request: %w", err)}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
-	resp, err := m.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		// Check if it's a timeout
 		if ctx.Err() == context.DeadlineExceeded {
-			return service.StatusTimeout, fmt.Errorf("request timeout: %w", err)
+			return checkResult{status: service.StatusTimeout, timings: trace.timings(), snapshot: m.newSnapshot(req, nil, nil), err: fmt.Errorf("request timeout: %w", err)}
+		}
+
+		if problem := classifyTLSError(err); problem != certificate.ProblemNone {
+			result := checkResult{status: service.StatusUnhealthy, timings: trace.timings(), snapshot: m.newSnapshot(req, nil, nil), err: certificateError(problem, err)}
+			result.chain = fetchChain(ctx, url, transport)
+			if result.chain == nil {
+				result.chain = &certificate.Chain{Host: req.URL.Hostname(), CheckedAt: time.Now()}
+			}
+			result.chain.Problem = problem
+			result.chain.Error = result.err.Error()
+			return result
 		}
-		return service.StatusUnhealthy, fmt.Errorf("request failed: %w", err)
+
+		return checkResult{status: service.StatusUnhealthy, timings: trace.timings(), snapshot: m.newSnapshot(req, nil, nil), err: fmt.Errorf("request failed: %w", err)}
 	}
 	defer resp.Body.Close()
 
+	// Read the body so the transfer phase is measured, keeping its start
+	// for the snapshot of a failing check
+	body := &bodyPrefix{max: m.snapshots.BodyBytes}
+	_, err = io.Copy(body, io.LimitReader(resp.Body, maxBodyBytes))
+	trace.finish()
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return checkResult{status: service.StatusTimeout, timings: trace.timings(), snapshot: m.newSnapshot(req, resp, body), err: fmt.Errorf("request timeout: %w", err)}
+	}
+
+	result = checkResult{timings: trace.timings()}
+	if resp.TLS != nil {
+		result.chain = newChain(req.URL.Hostname(), resp.TLS.PeerCertificates)
+	}
+
 	// Consider 200-399 as healthy
-	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-		return service.StatusHealthy, nil
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		result.status = service.StatusUnhealthy
+		result.snapshot = m.newSnapshot(req, resp, body)
+		result.err = fmt.Errorf("unhealthy status code: %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusUnauthorized && token != "" {
+			// The token was revoked or the service does not accept it;
+			// the next check fetches a new one
+			m.tokens.invalidate(oauth2, clientSecret)
+			result.err = fmt.Errorf("unhealthy status code: %d, bearer token rejected", resp.StatusCode)
+		}
+		return result
 	}
 
-	return service.StatusUnhealthy, fmt.Errorf("unhealthy status code: %d", resp.StatusCode)
+	result.status = service.StatusHealthy
+	m.warnOnExpiry(&result)
+
+	return result
+}
+
+// warnOnExpiry turns a healthy result into a warning when the certificate
+// the server presented expires within the warning period
+func (m *ServiceMonitor) warnOnExpiry(result *checkResult) {
+	if result.chain == nil || time.Until(result.chain.NotAfter()) >= m.certWarning {
+		return
+	}
+	days := result.chain.DaysLeft(time.Now())
+	result.status = service.StatusWarning
+	result.err = fmt.Errorf("certificate expires in %d days, on %s", days, result.chain.NotAfter().Format("2006-01-02"))
+	result.chain.Problem = certificate.ProblemExpiringSoon
+	result.chain.Error = result.err.Error()
 }
 
 // processUpdates handles incoming service updates
@@ -223,6 +449,32 @@ func (m *ServiceMonitor) handleUpdate(update ServiceUpdate) {
 		return
 	}
 
+	// Keep the check history used for uptime reporting
+	check := &service.HealthCheck{
+		ServiceID:    update.ServiceID,
+		Status:       update.Status,
+		ResponseTime: update.ResponseTime,
+		Timestamp:    update.Timestamp,
+		Timings:      update.Timings,
+		Metrics:      update.Metrics,
+	}
+	if update.Error != nil {
+		check.Error = update.Error.Error()
+	}
+	if err := m.checkRepo.Record(m.ctx, check); err != nil {
+		log.Printf("Failed to record health check for service %s: %v", update.ServiceID, err)
+	}
+
+	m.trackIncident(update)
+
+	if update.Snapshot != nil {
+		m.saveSnapshot(update)
+	}
+
+	if update.Certificate != nil {
+		m.trackCertificate(update)
+	}
+
 	// Log the update
 	if update.Error != nil {
 		log.Printf("Service %s: %s (error: %v)", update.ServiceID, update.Status, update.Error)
@@ -230,3 +482,100 @@ func (m *ServiceMonitor) handleUpdate(update ServiceUpdate) {
 		log.Printf("Service %s: %s (%dms)", update.ServiceID, update.Status, update.ResponseTime)
 	}
 }
+
+// trackIncident opens an incident when a service starts failing and
+// resolves the automatic incident once it is healthy again
+func (m *ServiceMonitor) trackIncident(update ServiceUpdate) {
+	// Only status changes matter, and an unknown result says nothing
+	// about whether the service recovered
+	if update.Status == update.PreviousStatus || update.Status == service.StatusUnknown {
+		return
+	}
+	failing := isFailing(update.Status)
+
+	active, err := m.incidentRepo.GetActive(m.ctx, update.ServiceID)
+	if err != nil {
+		log.Printf("Failed to look up incident for service %s: %v", update.ServiceID, err)
+		return
+	}
+
+	switch {
+	case failing && active == nil:
+		inc := &incident.Incident{
+			ServiceID: update.ServiceID,
+			Title:     fmt.Sprintf("%s is %s", update.ServiceName, update.Status),
+			Automatic: true,
+			StartedAt: update.Timestamp,
+		}
+		if update.Error != nil {
+			inc.Cause = update.Error.Error()
+		}
+		if err := m.incidentRepo.Create(m.ctx, inc); err != nil {
+			log.Printf("Failed to open incident for service %s: %v", update.ServiceID, err)
+		}
+	case !failing && active != nil && active.Automatic:
+		if err := m.incidentRepo.Resolve(m.ctx, active.ID, update.Timestamp); err != nil {
+			log.Printf("Failed to resolve incident %s: %v", active.ID, err)
+		}
+	}
+}
+
+// isFailing reports whether a status counts as an outage
+func isFailing(status service.Status) bool {
+	return status == service.StatusUnhealthy || status == service.StatusTimeout
+}
+
+// saveSnapshot stores the captured request and response of a failing check
+func (m *ServiceMonitor) saveSnapshot(update ServiceUpdate) {
+	snap := update.Snapshot
+	snap.ServiceID = update.ServiceID
+	snap.Status = update.Status
+	snap.CapturedAt = update.Timestamp
+	if update.Error != nil {
+		snap.Error = update.Error.Error()
+	}
+
+	if err := m.snapshotRepo.Save(m.ctx, snap, m.snapshots.Keep); err != nil {
+		log.Printf("Failed to save response snapshot for service %s: %v", update.ServiceID, err)
+	}
+}
+
+// trackCertificate stores the chain a check saw and raises an alert the
+// first time the certificate comes within one of the alert thresholds
+func (m *ServiceMonitor) trackCertificate(update ServiceUpdate) {
+	chain := update.Certificate
+	chain.ServiceID = update.ServiceID
+
+	if err := m.certRepo.Save(m.ctx, chain); err != nil {
+		log.Printf("Failed to save certificate chain for service %s: %v", update.ServiceID, err)
+		return
+	}
+
+	leaf := chain.Leaf()
+	if leaf == nil {
+		return
+	}
+
+	days := chain.DaysLeft(update.Timestamp)
+	threshold, ok := certificate.AlertThreshold(days)
+	if !ok {
+		return
+	}
+
+	alert := &certificate.Alert{
+		ServiceID: update.ServiceID,
+		Host:      chain.Host,
+		Subject:   leaf.Subject,
+		NotAfter:  chain.NotAfter(),
+		Days:      threshold,
+	}
+	created, err := m.certRepo.RecordAlert(m.ctx, alert)
+	if err != nil {
+		log.Printf("Failed to record certificate alert for service %s: %v", update.ServiceID, err)
+		return
+	}
+	if created {
+		log.Printf("Certificate alert: %s (%s) expires within %d days, on %s",
+			update.ServiceName, chain.Host, threshold, alert.NotAfter.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// tokenExpiryMargin is how long before it expires a cached token is
+// replaced, so a token never runs out while a check is in flight
+const tokenExpiryMargin = 30 * time.Second
+
+// defaultTokenLifetime is how long a token is kept when the server does not
+// say when it expires
+const defaultTokenLifetime = time.Hour
+
+// maxTokenResponse caps how much of a token endpoint response is read
+const maxTokenResponse = 64 << 10
+
+// tokenError is a failure to obtain an OAuth2 token for a check. It says
+// nothing about the health of the checked service, so such checks report
+// the unknown status.
+type tokenError struct {
+	TokenURL string
+	Err      error
+}
+
+func (e *tokenError) Error() string {
+	return fmt.Sprintf("oauth2 token request to %s failed: %v", e.TokenURL, e.Err)
+}
+
+func (e *tokenError) Unwrap() error {
+	return e.Err
+}
+
+// tokenCache fetches client credentials tokens and keeps them until
+// shortly before they expire. Services with the same token URL, client,
+// secret and scopes share a token. Expired tokens are dropped whenever a
+// token is looked up, so clients that are no longer used do not pile up.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]*cachedToken
+}
+
+// cachedToken is the token of one client configuration. Its mutex is held
+// while the token is fetched, so concurrent checks wait for a single
+// request instead of each sending their own.
+type cachedToken struct {
+	mu     sync.Mutex
+	value  string
+	expiry time.Time
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{tokens: make(map[string]*cachedToken)}
+}
+
+// token returns a valid access token for the client, fetching a new one
+// with the HTTP client when none is cached or the cached one is about to
+// expire
+func (c *tokenCache) token(ctx context.Context, client *http.Client, check *service.OAuth2Check, secret string) (string, error) {
+	entry := c.entry(tokenKey(check, secret))
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.value != "" && time.Until(entry.expiry) > tokenExpiryMargin {
+		return entry.value, nil
+	}
+
+	value, expiry, err := fetchToken(ctx, client, check, secret)
+	if err != nil {
+		entry.value = ""
+		return "", &tokenError{TokenURL: check.TokenURL, Err: err}
+	}
+	entry.value = value
+	entry.expiry = expiry
+	return value, nil
+}
+
+// invalidate drops the cached token of a client, e.g. after the service
+// rejected it, so the next check fetches a new one
+func (c *tokenCache) invalidate(check *service.OAuth2Check, secret string) {
+	entry := c.entry(tokenKey(check, secret))
+	entry.mu.Lock()
+	entry.value = ""
+	entry.mu.Unlock()
+}
+
+// entry returns the cache entry of a client configuration, dropping the
+// expired entries of others on the way. Entries that are being fetched or
+// used are left alone.
+func (c *tokenCache) entry(key string) *cachedToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.tokens {
+		if k != key && entry.mu.TryLock() {
+			if now.After(entry.expiry) {
+				delete(c.tokens, k)
+			}
+			entry.mu.Unlock()
+		}
+	}
+
+	entry, ok := c.tokens[key]
+	if !ok {
+		entry = &cachedToken{}
+		c.tokens[key] = entry
+	}
+	return entry
+}
+
+// tokenKey identifies a client configuration. The secret is hashed into
+// the key, so changing it fetches a new token.
+func tokenKey(check *service.OAuth2Check, secret string) string {
+	h := sha256.New()
+	for _, part := range []string{check.TokenURL, check.ClientID, secret, strings.Join(check.Scopes, " ")} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fetchToken requests a token with the client credentials grant of RFC
+// 6749, authenticating the client with HTTP Basic auth
+func fetchToken(ctx context.Context, client *http.Client, check *service.OAuth2Check, secret string) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(check.Scopes) > 0 {
+		form.Set("scope", strings.Join(check.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", check.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(check.ClientID), url.QueryEscape(secret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The token URL is already part of the tokenError
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponse))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.Unmarshal(body, &token)
+
+	if resp.StatusCode != http.StatusOK {
+		if decodeErr == nil && token.Error != "" {
+			if token.ErrorDescription != "" {
+				return "", time.Time{}, fmt.Errorf("status %d: %s: %s", resp.StatusCode, token.Error, token.ErrorDescription)
+			}
+			return "", time.Time{}, fmt.Errorf("status %d: %s", resp.StatusCode, token.Error)
+		}
+		return "", time.Time{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return "", time.Time{}, fmt.Errorf("invalid token response: %w", decodeErr)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", time.Time{}, fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+
+	expiry := time.Now().Add(defaultTokenLifetime)
+	if token.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token.AccessToken, expiry, nil
+}
@@ -0,0 +1,249 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pipeline-monitor/internal/domain/secret"
+	"pipeline-monitor/internal/domain/service"
+)
+
+// tokenServer is a client credentials token endpoint that issues numbered
+// tokens to the client "monitor" with the secret "s3cret"
+type tokenServer struct {
+	*httptest.Server
+	requests  atomic.Int32
+	expiresIn int64
+}
+
+func startTokenServer(t *testing.T, expiresIn int64) *tokenServer {
+	t.Helper()
+
+	ts := &tokenServer{expiresIn: expiresIn}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := ts.requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if r.Method != "POST" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "unsupported_grant_type"})
+			return
+		}
+		if !ok || clientID != "monitor" || clientSecret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client", "error_description": "bad credentials"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d-%s", n, r.FormValue("scope")),
+			"token_type":   "Bearer",
+			"expires_in":   ts.expiresIn,
+		})
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *tokenServer) check() *service.OAuth2Check {
+	return &service.OAuth2Check{TokenURL: ts.URL + "/token", ClientID: "monitor", Secret: "api-client", Scopes: []string{"health:read"}}
+}
+
+func TestTokenCacheFetchesAndReuses(t *testing.T) {
+	ts := startTokenServer(t, 3600)
+	cache := newTokenCache()
+	ctx := context.Background()
+
+	token, err := cache.token(ctx, ts.Client(), ts.check(), "s3cret")
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if token != "token-1-health:read" {
+		t.Errorf("token = %q, want %q", token, "token-1-health:read")
+	}
+
+	again, err := cache.token(ctx, ts.Client(), ts.check(), "s3cret")
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if again != token {
+		t.Errorf("cached token = %q, want %q", again, token)
+	}
+	if n := ts.requests.Load(); n != 1 {
+		t.Errorf("token requests = %d, want 1", n)
+	}
+}
+
+func TestTokenCacheRefreshesExpiringToken(t *testing.T) {
+	// A token that expires within the margin is replaced on each use
+	ts := startTokenServer(t, int64(tokenExpiryMargin/time.Second)-10)
+	cache := newTokenCache()
+	ctx := context.Background()
+
+	first, err := cache.token(ctx, ts.Client(), ts.check(), "s3cret")
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	second, err := cache.token(ctx, ts.Client(), ts.check(), "s3cret")
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if first == second {
+		t.Errorf("expiring token %q was reused", first)
+	}
+	if n := ts.requests.Load(); n != 2 {
+		t.Errorf("token requests = %d, want 2", n)
+	}
+
+	// Once the server hands out long-lived tokens, the cache keeps them
+	ts.expiresIn = 3600
+	third, _ := cache.token(ctx, ts.Client(), ts.check(), "s3cret")
+	fourth, _ := cache.token(ctx, ts.Client(), ts.check(), "s3cret")
+	if third != fourth {
+		t.Errorf("token = %q, then %q, want it cached", third, fourth)
+	}
+	if n := ts.requests.Load(); n != 3 {
+		t.Errorf("token requests = %d, want 3", n)
+	}
+}
+
+func TestTokenCacheInvalidate(t *testing.T) {
+	ts := startTokenServer(t, 3600)
+	cache := newTokenCache()
+	ctx := context.Background()
+
+	first, _ := cache.token(ctx, ts.Client(), ts.check(), "s3cret")
+	cache.invalidate(ts.check(), "s3cret")
+	second, err := cache.token(ctx, ts.Client(), ts.check(), "s3cret")
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if first == second {
+		t.Errorf("invalidated token %q was reused", first)
+	}
+}
+
+func TestTokenCacheDropsExpiredEntries(t *testing.T) {
+	ts := startTokenServer(t, 3600)
+	cache := newTokenCache()
+	ctx := context.Background()
+
+	old := ts.check()
+	if _, err := cache.token(ctx, ts.Client(), old, "s3cret"); err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	for _, entry := range cache.tokens {
+		entry.expiry = time.Now().Add(-time.Second)
+	}
+
+	current := ts.check()
+	current.Scopes = []string{"health:write"}
+	if _, err := cache.token(ctx, ts.Client(), current, "s3cret"); err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if _, ok := cache.tokens[tokenKey(old, "s3cret")]; ok {
+		t.Error("expired token was kept")
+	}
+	if n := len(cache.tokens); n != 1 {
+		t.Errorf("cached tokens = %d, want 1", n)
+	}
+}
+
+func TestTokenCacheError(t *testing.T) {
+	ts := startTokenServer(t, 3600)
+	cache := newTokenCache()
+
+	_, err := cache.token(context.Background(), ts.Client(), ts.check(), "wrong")
+	var tokenErr *tokenError
+	if !errors.As(err, &tokenErr) {
+		t.Fatalf("err = %v, want a *tokenError", err)
+	}
+	if tokenErr.TokenURL != ts.URL+"/token" {
+		t.Errorf("TokenURL = %q, want %q", tokenErr.TokenURL, ts.URL+"/token")
+	}
+	want := "oauth2 token request to " + ts.URL + "/token failed: status 401: invalid_client: bad credentials"
+	if err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+}
+
+// secretValues serves secret values from a map
+type secretValues struct {
+	secret.Repository
+	values map[string]string
+}
+
+func (s secretValues) Value(ctx context.Context, name string) (string, error) {
+	value, ok := s.values[name]
+	if !ok {
+		return "", secret.ErrNotFound
+	}
+	return value, nil
+}
+
+func TestPerformHealthCheckWithOAuth2(t *testing.T) {
+	ts := startTokenServer(t, 3600)
+
+	var authorization atomic.Value
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		name   string
+		secret string
+		status service.Status
+		err    string
+	}{
+		{name: "token", secret: "s3cret", status: service.StatusHealthy},
+		{name: "token request fails", secret: "wrong", status: service.StatusUnknown, err: "oauth2 token request to " + ts.URL + "/token failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorization.Store("")
+			m := &ServiceMonitor{
+				transports: newTransports(nil, nil),
+				tokens:     newTokenCache(),
+				secretRepo: secretValues{values: map[string]string{"api-client": tt.secret}},
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			result := m.performHealthCheck(ctx, api.URL, ts.check(), nil)
+			if result.status != tt.status {
+				t.Errorf("status = %s, want %s (err: %v)", result.status, tt.status, result.err)
+			}
+			if tt.err == "" {
+				if result.err != nil {
+					t.Errorf("unexpected error: %v", result.err)
+				}
+				if got := authorization.Load(); !strings.HasPrefix(got.(string), "Bearer token-") {
+					t.Errorf("Authorization = %q, want a bearer token", got)
+				}
+				if result.started.IsZero() {
+					t.Error("the request was timed from before the token request")
+				}
+				return
+			}
+			var tokenErr *tokenError
+			if !errors.As(result.err, &tokenErr) || !strings.Contains(result.err.Error(), tt.err) {
+				t.Errorf("err = %v, want a token error containing %q", result.err, tt.err)
+			}
+			if got := authorization.Load(); got != "" {
+				t.Errorf("service was requested after the token request failed")
+			}
+		})
+	}
+}
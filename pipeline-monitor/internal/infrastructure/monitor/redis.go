@@ -0,0 +1,211 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// maxRedisReply caps the size of a bulk reply, such as INFO, a check reads
+const maxRedisReply = 1 << 20
+
+// performRedisCheck connects to the server of a redis:// or rediss:// URL,
+// authenticates, sends PING and compares the used memory and connected
+// clients reported by INFO to the thresholds. Over TLS it also reports the
+// server's certificate chain.
+func (m *ServiceMonitor) performRedisCheck(ctx context.Context, rawURL string, check *service.RedisCheck) checkResult {
+	if check == nil {
+		check = &service.RedisCheck{}
+	}
+
+	password, err := m.secret(ctx, check.PasswordSecret)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: fmt.Errorf("invalid url: %w", err)}
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return redisErrorResult(ctx, fmt.Errorf("failed to connect: %w", err))
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	var result checkResult
+	if u.Scheme == "rediss" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return redisErrorResult(ctx, fmt.Errorf("TLS handshake failed: %w", err))
+		}
+		result.chain = newChain(u.Hostname(), tlsConn.ConnectionState().PeerCertificates)
+		conn = tlsConn
+	}
+
+	client := &redisConn{conn: conn, r: bufio.NewReader(conn)}
+	if password != "" {
+		args := []string{"AUTH", password}
+		if check.Username != "" {
+			args = []string{"AUTH", check.Username, password}
+		}
+		if _, err := client.do(args...); err != nil {
+			return redisErrorResult(ctx, fmt.Errorf("AUTH failed: %w", err))
+		}
+	}
+
+	pong, err := client.do("PING")
+	if err != nil {
+		return redisErrorResult(ctx, fmt.Errorf("PING failed: %w", err))
+	}
+	if pong != "PONG" {
+		return checkResult{status: service.StatusUnhealthy, err: fmt.Errorf("PING returned %q", pong)}
+	}
+
+	info, err := client.do("INFO")
+	if err != nil {
+		return redisErrorResult(ctx, fmt.Errorf("INFO failed: %w", err))
+	}
+	fields := parseRedisInfo(info)
+	usedMemory, _ := strconv.ParseInt(fields["used_memory"], 10, 64)
+	clients, _ := strconv.Atoi(fields["connected_clients"])
+	result.metrics = redisMetrics(check, fields, usedMemory, clients)
+
+	if err := check.Evaluate(usedMemory, clients); err != nil {
+		result.status = service.StatusUnhealthy
+		result.err = err
+		return result
+	}
+
+	result.status = service.StatusHealthy
+	m.warnOnExpiry(&result)
+	return result
+}
+
+// redisMetrics reports the used memory, in megabytes, and the connected
+// clients of a server, with their thresholds and the limits configured on
+// the server
+func redisMetrics(check *service.RedisCheck, fields map[string]string, usedMemory int64, clients int) []service.Metric {
+	memory := service.Metric{Label: "used_memory", Value: megabytes(float64(usedMemory)), Unit: "MB"}
+	if check.MaxMemory > 0 {
+		memory.Crit = strconv.Itoa(check.MaxMemory)
+	}
+	if v, err := strconv.ParseFloat(fields["maxmemory"], 64); err == nil && v > 0 {
+		v = megabytes(v)
+		memory.Max = &v
+	}
+
+	connected := service.Metric{Label: "connected_clients", Value: float64(clients)}
+	if check.MaxClients > 0 {
+		connected.Crit = strconv.Itoa(check.MaxClients)
+	}
+	if v, err := strconv.ParseFloat(fields["maxclients"], 64); err == nil && v > 0 {
+		connected.Max = &v
+	}
+
+	return []service.Metric{memory, connected}
+}
+
+// megabytes converts bytes to megabytes, rounded to one decimal
+func megabytes(bytes float64) float64 {
+	return math.Round(bytes/(1<<20)*10) / 10
+}
+
+// parseRedisInfo parses the field:value lines of an INFO reply, skipping
+// the # section headers
+func parseRedisInfo(info string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// redisErrorResult classifies a failed Redis check
+func redisErrorResult(ctx context.Context, err error) checkResult {
+	if ctx.Err() == context.DeadlineExceeded || errors.Is(err, os.ErrDeadlineExceeded) {
+		return checkResult{status: service.StatusTimeout, err: fmt.Errorf("redis check timeout: %w", err)}
+	}
+	return checkResult{status: service.StatusUnhealthy, err: err}
+}
+
+// redisConn speaks just enough of the Redis protocol for AUTH, PING and
+// INFO: commands go out as arrays of bulk strings and replies are simple
+// strings, errors, integers or bulk strings
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// redisError is an error reply of the server, such as WRONGPASS
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// do sends a command and reads its reply
+func (c *redisConn) do(args ...string) (string, error) {
+	var cmd strings.Builder
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, cmd.String()); err != nil {
+		return "", err
+	}
+
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 || n > maxRedisReply {
+			return "", fmt.Errorf("unexpected reply %q", line)
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return "", err
+		}
+		return string(data[:n]), nil
+	default:
+		return "", fmt.Errorf("unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,60 @@
+package monitor
+
+import (
+	"net/http"
+	"strings"
+
+	"pipeline-monitor/internal/domain/snapshot"
+)
+
+// bodyPrefix keeps the first max bytes written to it and discards the rest
+type bodyPrefix struct {
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (b *bodyPrefix) Write(p []byte) (int, error) {
+	room := b.max - len(b.buf)
+	if len(p) > room {
+		b.buf = append(b.buf, p[:room]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+// newSnapshot records a request and, if one arrived, its response with
+// the policy's redaction applied. Status and service are filled in when
+// the update is handled.
+func (m *ServiceMonitor) newSnapshot(req *http.Request, resp *http.Response, body *bodyPrefix) *snapshot.Snapshot {
+	if m.snapshots.Keep <= 0 {
+		return nil
+	}
+
+	snap := &snapshot.Snapshot{
+		Request: snapshot.Request{
+			Method:  req.Method,
+			URL:     req.URL.Redacted(),
+			Headers: m.snapshots.Redact(req.Header),
+		},
+	}
+	if _, ok := snap.Request.Headers["Authorization"]; ok {
+		// The header carries the bearer tokens of OAuth2 checks and the
+		// credentials synthetic steps set, which are never kept,
+		// whatever the redaction policy says
+		snap.Request.Headers["Authorization"] = []string{snapshot.Redacted}
+	}
+	if resp != nil {
+		snap.Response = &snapshot.Response{
+			StatusCode: resp.StatusCode,
+			Headers:    m.snapshots.Redact(resp.Header),
+		}
+		if body != nil {
+			snap.Response.Body = strings.ToValidUTF8(string(body.buf), "�")
+			snap.Response.Truncated = body.truncated
+		}
+	}
+	return snap
+}
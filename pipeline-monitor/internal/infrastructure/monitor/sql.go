@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// dataSources opens a small connection pool per named data source the
+// first time a SQL check uses it
+type dataSources struct {
+	dsns  map[string]string
+	mu    sync.Mutex
+	pools map[string]*sql.DB
+}
+
+func newDataSources(dsns map[string]string) *dataSources {
+	return &dataSources{dsns: dsns, pools: make(map[string]*sql.DB)}
+}
+
+// get returns the pool of a data source
+func (d *dataSources) get(name string) (*sql.DB, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if db, ok := d.pools[name]; ok {
+		return db, nil
+	}
+
+	dsn, ok := d.dsns[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown data source %q", name)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data source %q: %w", name, err)
+	}
+	db.SetMaxOpenConns(2)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	d.pools[name] = db
+	return db, nil
+}
+
+// close closes every pool opened so far
+func (d *dataSources) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for name, db := range d.pools {
+		db.Close()
+		delete(d.pools, name)
+	}
+}
+
+// performSQLCheck runs the query of a SQL check in a read-only transaction
+// and compares the first column of its first row to the thresholds
+func (m *ServiceMonitor) performSQLCheck(ctx context.Context, check *service.SQLCheck) checkResult {
+	if check == nil {
+		return checkResult{status: service.StatusUnknown, err: fmt.Errorf("sql check has no settings")}
+	}
+
+	db, err := m.dataSources.get(check.DataSource)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+
+	value, err := queryValue(ctx, db, check.Query)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return checkResult{status: service.StatusTimeout, err: fmt.Errorf("query timeout: %w", err)}
+		}
+		return checkResult{status: service.StatusUnhealthy, err: err}
+	}
+
+	if err := check.Evaluate(value, time.Now()); err != nil {
+		return checkResult{status: service.StatusUnhealthy, err: err}
+	}
+	return checkResult{status: service.StatusHealthy}
+}
+
+// queryValue returns the first column of the first row of a query. The
+// transaction is read-only and always rolled back, and the query is
+// prepared, which Postgres only allows for a single statement, so a query
+// cannot end the transaction and run writes after it. Checks therefore
+// cannot change data even if the data source user could.
+func queryValue(ctx context.Context, db *sql.DB, query string) (any, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("query failed: %w", err)
+		}
+		return nil, fmt.Errorf("query returned no rows")
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to read query result: %w", err)
+	}
+
+	return values[0], nil
+}
@@ -0,0 +1,193 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+	"pipeline-monitor/internal/domain/snapshot"
+)
+
+// performSyntheticCheck runs the steps of a scenario in order with a
+// cookie jar of its own, passing the variables each step extracts on to
+// later steps. Every step reports its time as a metric, and the check
+// fails at the first step that does not meet its expectations, which the
+// error and the snapshot describe. Secrets inserted into a step never
+// appear in its snapshot.
+func (m *ServiceMonitor) performSyntheticCheck(ctx context.Context, baseURL string, check *service.SyntheticCheck, transport *service.Transport) checkResult {
+	if check == nil {
+		return checkResult{status: service.StatusUnknown, err: fmt.Errorf("synthetic check has no settings")}
+	}
+
+	pooled, err := m.transports.client(transport)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return checkResult{status: service.StatusUnknown, err: err}
+	}
+	client := &http.Client{Transport: pooled.Transport, Jar: jar, Timeout: pooled.Timeout}
+
+	vars := make(map[string]string)
+	var secrets []string
+	lookup := func(name string) (string, error) {
+		if secretName, ok := strings.CutPrefix(name, "secret."); ok {
+			value, err := m.secret(ctx, secretName)
+			if err == nil && value != "" {
+				secrets = append(secrets, value)
+			}
+			return value, err
+		}
+		value, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("variable %q is not set", name)
+		}
+		return value, nil
+	}
+
+	var result checkResult
+	for i := range check.Steps {
+		step := &check.Steps[i]
+		fail := func(status service.Status, err error) checkResult {
+			result.status = status
+			result.err = fmt.Errorf("step %d (%s): %w", i+1, step.Name, err)
+			return result
+		}
+
+		req, err := newStepRequest(ctx, baseURL, step, lookup)
+		if err != nil {
+			return fail(service.StatusUnknown, err)
+		}
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			result.snapshot = m.stepSnapshot(req, nil, nil, secrets)
+			if ctx.Err() == context.DeadlineExceeded {
+				return fail(service.StatusTimeout, fmt.Errorf("request timeout: %w", err))
+			}
+			return fail(service.StatusUnhealthy, fmt.Errorf("request failed: %w", err))
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+		resp.Body.Close()
+		elapsed := time.Since(start)
+
+		metric := service.Metric{Label: step.Name, Value: float64(elapsed.Milliseconds()), Unit: "ms"}
+		if step.Expect.MaxTime > 0 {
+			metric.Crit = strconv.Itoa(step.Expect.MaxTime)
+		}
+		result.metrics = append(result.metrics, metric)
+
+		if result.chain == nil && resp.TLS != nil {
+			result.chain = newChain(req.URL.Hostname(), resp.TLS.PeerCertificates)
+		}
+
+		if err != nil {
+			result.snapshot = m.stepSnapshot(req, resp, nil, secrets)
+			if ctx.Err() == context.DeadlineExceeded {
+				return fail(service.StatusTimeout, fmt.Errorf("request timeout: %w", err))
+			}
+			return fail(service.StatusUnhealthy, fmt.Errorf("failed to read response: %w", err))
+		}
+
+		if err := step.Evaluate(resp.StatusCode, body, elapsed); err != nil {
+			result.snapshot = m.stepSnapshot(req, resp, body, secrets)
+			return fail(service.StatusUnhealthy, err)
+		}
+
+		for _, e := range step.Extract {
+			value, err := e.Extract(body)
+			if err != nil {
+				result.snapshot = m.stepSnapshot(req, resp, body, secrets)
+				return fail(service.StatusUnhealthy, fmt.Errorf("extract %s: %w", e.Var, err))
+			}
+			vars[e.Var] = value
+		}
+	}
+
+	result.status = service.StatusHealthy
+	m.warnOnExpiry(&result)
+	return result
+}
+
+// newStepRequest builds the request of a step, expanding the placeholders
+// of its URL, headers and body
+func newStepRequest(ctx context.Context, baseURL string, step *service.SyntheticStep, lookup func(string) (string, error)) (*http.Request, error) {
+	rawURL, err := service.Expand(step.URL, lookup)
+	if err != nil {
+		return nil, err
+	}
+	u, err := service.ResolveStepURL(baseURL, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	body, err := service.Expand(step.Body, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	method := step.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for name, value := range step.Headers {
+		value, err := service.Expand(value, lookup)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// stepSnapshot records the request and response of a failing step, with
+// the values of the secrets inserted into the request hidden in its URL
+// and headers
+func (m *ServiceMonitor) stepSnapshot(req *http.Request, resp *http.Response, body []byte, secrets []string) *snapshot.Snapshot {
+	prefix := &bodyPrefix{max: m.snapshots.BodyBytes}
+	io.Copy(prefix, bytes.NewReader(body))
+	snap := m.newSnapshot(req, resp, prefix)
+	if snap == nil {
+		return nil
+	}
+
+	for _, value := range secrets {
+		// The URL may hold the value as inserted or escaped for its query
+		// or path
+		escapedPath := (&url.URL{Path: value}).EscapedPath()
+		for _, form := range []string{value, url.QueryEscape(value), url.PathEscape(value), escapedPath} {
+			snap.Request.URL = strings.ReplaceAll(snap.Request.URL, form, snapshot.Redacted)
+		}
+		for name, values := range snap.Request.Headers {
+			redacted := make([]string, len(values))
+			for i, v := range values {
+				redacted[i] = strings.ReplaceAll(v, value, snapshot.Redacted)
+			}
+			snap.Request.Headers[name] = redacted
+		}
+	}
+	return snap
+}
@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// phaseTrace records when each phase of an HTTP request starts and ends.
+// Callbacks may run on transport goroutines, hence the mutex.
+type phaseTrace struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	bodyDone     time.Time
+	reused       bool
+}
+
+// withPhaseTrace returns a context that records request phases into the
+// returned trace
+func withPhaseTrace(ctx context.Context) (context.Context, *phaseTrace) {
+	t := &phaseTrace{}
+
+	set := func(field *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { set(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { set(&t.connectDone) },
+		TLSHandshakeStart:    func() { set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { set(&t.tlsDone) },
+		GotConn:              t.gotConn,
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&t.wroteRequest) },
+		GotFirstResponseByte: func() { set(&t.firstByte) },
+	}
+
+	return httptrace.WithClientTrace(ctx, trace), t
+}
+
+// gotConn records whether the request got a kept-alive connection
+func (t *phaseTrace) gotConn(info httptrace.GotConnInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reused = info.Reused
+}
+
+// finish marks the end of reading the response body
+func (t *phaseTrace) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bodyDone = time.Now()
+}
+
+// timings returns the duration of each phase that completed. Only the
+// first connection attempt is measured, and a reused connection has no
+// DNS, connect or TLS phases.
+func (t *phaseTrace) timings() *service.Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return &service.Timings{
+		DNS:      elapsedMillis(t.dnsStart, t.dnsDone),
+		Connect:  elapsedMillis(t.connectStart, t.connectDone),
+		TLS:      elapsedMillis(t.tlsStart, t.tlsDone),
+		TTFB:     elapsedMillis(t.wroteRequest, t.firstByte),
+		Transfer: elapsedMillis(t.firstByte, t.bodyDone),
+		Reused:   t.reused,
+	}
+}
+
+// elapsedMillis returns the time between two events in milliseconds, or 0
+// if either did not happen
+func elapsedMillis(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return float64(end.Sub(start).Microseconds()) / 1000
+}
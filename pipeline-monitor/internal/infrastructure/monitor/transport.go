@@ -0,0 +1,124 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"pipeline-monitor/internal/domain/service"
+)
+
+// transports pools the HTTP clients of checks per transport configuration,
+// so services with the same settings share a client and connections, TLS
+// sessions and proxies never cross configurations. Connections are kept
+// alive between checks; the phase trace reports when one was reused.
+type transports struct {
+	clientCerts map[string]string
+	caBundles   map[string]string
+	mu          sync.Mutex
+	clients     map[service.Transport]*http.Client
+}
+
+func newTransports(clientCerts, caBundles map[string]string) *transports {
+	return &transports{
+		clientCerts: clientCerts,
+		caBundles:   caBundles,
+		clients:     make(map[service.Transport]*http.Client),
+	}
+}
+
+// client returns the client of a transport configuration, creating it the
+// first time the configuration is used. A nil configuration gets the
+// default client.
+func (t *transports) client(config *service.Transport) (*http.Client, error) {
+	var key service.Transport
+	if config != nil {
+		key = *config
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if client, ok := t.clients[key]; ok {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if key.ClientCert != "" || key.CABundle != "" || key.SkipVerify || key.ServerName != "" {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         key.ServerName,
+			InsecureSkipVerify: key.SkipVerify,
+		}
+		if key.ClientCert != "" {
+			cert, err := t.clientCertificate(key.ClientCert)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		if key.CABundle != "" {
+			roots, err := t.caBundle(key.CABundle)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = roots
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if key.Proxy != "" {
+		proxy, err := url.Parse(key.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	t.clients[key] = client
+	return client, nil
+}
+
+// clientCertificate loads a configured client certificate. The PEM file
+// holds both the certificate chain and the private key.
+func (t *transports) clientCertificate(name string) (tls.Certificate, error) {
+	path, ok := t.clientCerts[name]
+	if !ok {
+		return tls.Certificate{}, fmt.Errorf("unknown client certificate %q", name)
+	}
+
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read client certificate %q: %w", name, err)
+	}
+	cert, err := tls.X509KeyPair(pemData, pemData)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load client certificate %q: %w", name, err)
+	}
+	return cert, nil
+}
+
+// caBundle loads the certificates of a configured CA bundle
+func (t *transports) caBundle(name string) (*x509.CertPool, error) {
+	path, ok := t.caBundles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown CA bundle %q", name)
+	}
+
+	pemData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle %q: %w", name, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("CA bundle %q holds no PEM certificates", name)
+	}
+	return pool, nil
+}
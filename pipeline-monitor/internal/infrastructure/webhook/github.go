@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/pipeline"
+)
+
+// VerifyGitHub checks the X-Hub-Signature-256 header of a GitHub delivery,
+// an HMAC-SHA256 of the body keyed with the webhook secret. Without a
+// secret no delivery is valid.
+func VerifyGitHub(secret string, body []byte, signature string) error {
+	sum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok || secret == "" {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// githubRepository is the repository object of GitHub payloads
+type githubRepository struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+// githubWorkflowRun is the payload of a workflow_run event
+type githubWorkflowRun struct {
+	WorkflowRun struct {
+		ID           int64      `json:"id"`
+		Name         string     `json:"name"`
+		RunNumber    int        `json:"run_number"`
+		RunAttempt   int        `json:"run_attempt"`
+		HeadBranch   string     `json:"head_branch"`
+		HeadSHA      string     `json:"head_sha"`
+		Status       string     `json:"status"`
+		Conclusion   string     `json:"conclusion"`
+		HTMLURL      string     `json:"html_url"`
+		RunStartedAt *time.Time `json:"run_started_at"`
+		UpdatedAt    *time.Time `json:"updated_at"`
+		Actor        struct {
+			Login string `json:"login"`
+		} `json:"actor"`
+	} `json:"workflow_run"`
+	Repository githubRepository `json:"repository"`
+}
+
+// githubWorkflowJob is the payload of a workflow_job event
+type githubWorkflowJob struct {
+	WorkflowJob struct {
+		RunID        int64      `json:"run_id"`
+		RunAttempt   int        `json:"run_attempt"`
+		WorkflowName string     `json:"workflow_name"`
+		Name         string     `json:"name"`
+		HeadBranch   string     `json:"head_branch"`
+		HeadSHA      string     `json:"head_sha"`
+		Status       string     `json:"status"`
+		Conclusion   string     `json:"conclusion"`
+		StartedAt    *time.Time `json:"started_at"`
+		CompletedAt  *time.Time `json:"completed_at"`
+	} `json:"workflow_job"`
+	Repository githubRepository `json:"repository"`
+}
+
+// ParseGitHub reads a GitHub Actions delivery. The event is the value of
+// the X-GitHub-Event header; workflow_run events update a run and
+// workflow_job events update one of its stages.
+func ParseGitHub(event string, body []byte) (*Event, error) {
+	switch event {
+	case "workflow_run":
+		var payload githubWorkflowRun
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("invalid workflow_run payload: %w", err)
+		}
+		run := payload.WorkflowRun
+		if run.ID == 0 || payload.Repository.FullName == "" {
+			return nil, fmt.Errorf("workflow_run payload lacks the run or repository")
+		}
+
+		outcome := githubOutcome(run.Status, run.Conclusion)
+		var finishedAt *time.Time
+		if outcome.Finished() {
+			finishedAt = run.UpdatedAt
+		}
+
+		return &Event{
+			Pipeline: githubPipeline(payload.Repository, run.Name),
+			Run: pipeline.Run{
+				ExternalID: strconv.FormatInt(run.ID, 10),
+				Attempt:    run.RunAttempt,
+				Number:     run.RunNumber,
+				Branch:     run.HeadBranch,
+				Commit:     run.HeadSHA,
+				Actor:      run.Actor.Login,
+				URL:        run.HTMLURL,
+				Outcome:    outcome,
+				StartedAt:  run.RunStartedAt,
+				FinishedAt: finishedAt,
+			},
+		}, nil
+
+	case "workflow_job":
+		var payload githubWorkflowJob
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("invalid workflow_job payload: %w", err)
+		}
+		job := payload.WorkflowJob
+		if job.RunID == 0 || payload.Repository.FullName == "" {
+			return nil, fmt.Errorf("workflow_job payload lacks the run or repository")
+		}
+
+		return &Event{
+			Pipeline: githubPipeline(payload.Repository, job.WorkflowName),
+			Run: pipeline.Run{
+				ExternalID: strconv.FormatInt(job.RunID, 10),
+				Attempt:    job.RunAttempt,
+				Branch:     job.HeadBranch,
+				Commit:     job.HeadSHA,
+				URL:        fmt.Sprintf("%s/actions/runs/%d", payload.Repository.HTMLURL, job.RunID),
+				Stages: []pipeline.Stage{{
+					Name:       job.Name,
+					Outcome:    githubOutcome(job.Status, job.Conclusion),
+					StartedAt:  job.StartedAt,
+					FinishedAt: job.CompletedAt,
+				}},
+			},
+		}, nil
+	}
+
+	return nil, ErrUnsupportedEvent
+}
+
+// githubPipeline returns the pipeline of a workflow
+func githubPipeline(repo githubRepository, workflow string) pipeline.Pipeline {
+	return pipeline.Pipeline{
+		Provider:   pipeline.ProviderGitHub,
+		Repository: repo.FullName,
+		Name:       workflow,
+		URL:        repo.HTMLURL + "/actions",
+	}
+}
+
+// githubOutcome maps the status and conclusion of a GitHub run or job
+func githubOutcome(status, conclusion string) pipeline.Outcome {
+	if status != "completed" {
+		if status == "in_progress" {
+			return pipeline.OutcomeRunning
+		}
+		return pipeline.OutcomePending
+	}
+
+	switch conclusion {
+	case "success", "neutral":
+		return pipeline.OutcomeSuccess
+	case "cancelled":
+		return pipeline.OutcomeCancelled
+	case "skipped", "stale":
+		return pipeline.OutcomeSkipped
+	default: // failure, timed_out, action_required, startup_failure
+		return pipeline.OutcomeFailure
+	}
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// githubSignature signs a body the way GitHub does
+func githubSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyGitHub(t *testing.T) {
+	body := []byte(`{"action":"completed"}`)
+	valid := githubSignature("s3cret", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		valid     bool
+	}{
+		{name: "valid", secret: "s3cret", body: body, signature: valid, valid: true},
+		{name: "missing", secret: "s3cret", body: body, signature: ""},
+		{name: "other secret", secret: "s3cret", body: body, signature: githubSignature("other", body)},
+		{name: "other body", secret: "s3cret", body: []byte(`{"action":"requested"}`), signature: valid},
+		{name: "no algorithm prefix", secret: "s3cret", body: body, signature: strings.TrimPrefix(valid, "sha256=")},
+		{name: "sha1 prefix", secret: "s3cret", body: body, signature: "sha1=" + strings.TrimPrefix(valid, "sha256=")},
+		{name: "not hex", secret: "s3cret", body: body, signature: "sha256=zz"},
+		{name: "truncated", secret: "s3cret", body: body, signature: valid[:len(valid)-2]},
+		{name: "empty secret", secret: "", body: body, signature: githubSignature("", body)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyGitHub(tt.secret, tt.body, tt.signature)
+			if tt.valid {
+				if err != nil {
+					t.Errorf("VerifyGitHub: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
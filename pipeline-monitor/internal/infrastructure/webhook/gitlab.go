@@ -0,0 +1,221 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pipeline-monitor/internal/domain/pipeline"
+)
+
+// VerifyGitLab checks the X-Gitlab-Token header of a GitLab delivery, which
+// carries the secret token configured on the webhook. Without a secret no
+// delivery is valid.
+func VerifyGitLab(secret, token string) error {
+	if secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(token)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// gitlabTime reads the timestamps of GitLab payloads, which older GitLab
+// versions write as "2006-01-02 15:04:05 UTC" rather than RFC 3339
+type gitlabTime struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *gitlabTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil || value == "" {
+		return nil // null
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time %q", value)
+}
+
+// ptr returns the time, or nil if it is not set
+func (t gitlabTime) ptr() *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t.Time
+}
+
+// gitlabPipeline is the payload of a pipeline event
+type gitlabPipeline struct {
+	ObjectKind       string `json:"object_kind"`
+	ObjectAttributes struct {
+		ID         int64      `json:"id"`
+		IID        int        `json:"iid"`
+		Name       string     `json:"name"`
+		Ref        string     `json:"ref"`
+		SHA        string     `json:"sha"`
+		Status     string     `json:"status"`
+		Stages     []string   `json:"stages"`
+		CreatedAt  gitlabTime `json:"created_at"`
+		FinishedAt gitlabTime `json:"finished_at"`
+		URL        string     `json:"url"`
+	} `json:"object_attributes"`
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		WebURL            string `json:"web_url"`
+	} `json:"project"`
+	Builds []gitlabBuild `json:"builds"`
+}
+
+// gitlabBuild is a job of a GitLab pipeline
+type gitlabBuild struct {
+	Stage        string     `json:"stage"`
+	Status       string     `json:"status"`
+	AllowFailure bool       `json:"allow_failure"`
+	StartedAt    gitlabTime `json:"started_at"`
+	FinishedAt   gitlabTime `json:"finished_at"`
+}
+
+// ParseGitLab reads a GitLab delivery. Only pipeline events are supported;
+// they carry the whole pipeline, and its jobs are grouped into stages.
+func ParseGitLab(body []byte) (*Event, error) {
+	var payload gitlabPipeline
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid pipeline payload: %w", err)
+	}
+	if payload.ObjectKind != "pipeline" {
+		return nil, ErrUnsupportedEvent
+	}
+
+	attrs := payload.ObjectAttributes
+	if attrs.ID == 0 || payload.Project.PathWithNamespace == "" {
+		return nil, fmt.Errorf("pipeline payload lacks the pipeline or project")
+	}
+
+	name := attrs.Name
+	if name == "" {
+		name = "pipeline"
+	}
+
+	url := attrs.URL
+	if url == "" {
+		url = fmt.Sprintf("%s/-/pipelines/%d", payload.Project.WebURL, attrs.ID)
+	}
+
+	return &Event{
+		Pipeline: pipeline.Pipeline{
+			Provider:   pipeline.ProviderGitLab,
+			Repository: payload.Project.PathWithNamespace,
+			Name:       name,
+			URL:        payload.Project.WebURL + "/-/pipelines",
+		},
+		Run: pipeline.Run{
+			ExternalID: strconv.FormatInt(attrs.ID, 10),
+			Number:     attrs.IID,
+			Branch:     attrs.Ref,
+			Commit:     attrs.SHA,
+			Actor:      payload.User.Username,
+			URL:        url,
+			Outcome:    gitlabOutcome(attrs.Status),
+			Stages:     gitlabStages(attrs.Stages, payload.Builds),
+			StartedAt:  attrs.CreatedAt.ptr(),
+			FinishedAt: attrs.FinishedAt.ptr(),
+		},
+	}, nil
+}
+
+// gitlabStages groups the jobs of a pipeline into its stages, in the order
+// the pipeline declares them. A stage fails if a job that may not fail
+// failed, and runs while any of its jobs has not finished.
+func gitlabStages(names []string, builds []gitlabBuild) []pipeline.Stage {
+	for _, build := range builds {
+		if !containsString(names, build.Stage) {
+			names = append(names, build.Stage)
+		}
+	}
+
+	stages := make([]pipeline.Stage, 0, len(names))
+	for _, name := range names {
+		stage := pipeline.Stage{Name: name}
+		var running, pending, failed, cancelled, succeeded bool
+		for _, build := range builds {
+			if build.Stage != name {
+				continue
+			}
+			switch outcome := gitlabOutcome(build.Status); {
+			case outcome == pipeline.OutcomeRunning:
+				running = true
+			case outcome == pipeline.OutcomePending:
+				pending = true
+			case outcome == pipeline.OutcomeFailure && !build.AllowFailure:
+				failed = true
+			case outcome == pipeline.OutcomeCancelled:
+				cancelled = true
+			case outcome != pipeline.OutcomeSkipped:
+				succeeded = true
+			}
+			if start := build.StartedAt.ptr(); start != nil && (stage.StartedAt == nil || start.Before(*stage.StartedAt)) {
+				stage.StartedAt = start
+			}
+			if end := build.FinishedAt.ptr(); end != nil && (stage.FinishedAt == nil || end.After(*stage.FinishedAt)) {
+				stage.FinishedAt = end
+			}
+		}
+
+		switch {
+		case running:
+			stage.Outcome = pipeline.OutcomeRunning
+		case pending:
+			stage.Outcome = pipeline.OutcomePending
+		case failed:
+			stage.Outcome = pipeline.OutcomeFailure
+		case cancelled:
+			stage.Outcome = pipeline.OutcomeCancelled
+		case succeeded:
+			stage.Outcome = pipeline.OutcomeSuccess
+		default:
+			stage.Outcome = pipeline.OutcomeSkipped
+		}
+		if !stage.Outcome.Finished() {
+			stage.FinishedAt = nil
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+// gitlabOutcome maps the status of a GitLab pipeline or job
+func gitlabOutcome(status string) pipeline.Outcome {
+	switch strings.ToLower(status) {
+	case "running":
+		return pipeline.OutcomeRunning
+	case "success":
+		return pipeline.OutcomeSuccess
+	case "failed":
+		return pipeline.OutcomeFailure
+	case "canceled", "cancelled":
+		return pipeline.OutcomeCancelled
+	case "skipped", "manual":
+		return pipeline.OutcomeSkipped
+	default: // created, waiting_for_resource, preparing, pending, scheduled
+		return pipeline.OutcomePending
+	}
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyGitLab(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+		valid  bool
+	}{
+		{name: "valid", secret: "s3cret", token: "s3cret", valid: true},
+		{name: "missing", secret: "s3cret", token: ""},
+		{name: "wrong", secret: "s3cret", token: "s3cres"},
+		{name: "prefix", secret: "s3cret", token: "s3c"},
+		{name: "longer", secret: "s3cret", token: "s3cret!"},
+		{name: "empty secret", secret: "", token: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyGitLab(tt.secret, tt.token)
+			if tt.valid {
+				if err != nil {
+					t.Errorf("VerifyGitLab: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+// Package webhook turns CI/CD webhook deliveries into pipeline runs
+package webhook
+
+import (
+	"errors"
+
+	"pipeline-monitor/internal/domain/pipeline"
+)
+
+var (
+	// ErrInvalidSignature is returned when a delivery is not signed with
+	// the configured secret
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrUnsupportedEvent is returned for deliveries that carry no pipeline
+	// run, such as GitHub's ping event
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+)
+
+// Event is the pipeline run update carried by a webhook delivery
+type Event struct {
+	Pipeline pipeline.Pipeline
+	Run      pipeline.Run
+}